@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"seldom-platform/config"
+	"seldom-platform/database"
+	"seldom-platform/services"
+	"seldom-platform/utils"
+)
+
+// command 命令行子命令
+type command struct {
+	usage string
+	run   func(cfg *config.Config, args []string) error
+}
+
+// commands 已注册的子命令
+var commands = map[string]command{
+	"createsuperuser": {
+		usage: "创建超级用户",
+		run:   createSuperuser,
+	},
+	"migrate": {
+		usage: "数据库迁移：up、down、status、create",
+		run:   migrate,
+	},
+}
+
+// runCommand 执行命令行子命令，没有子命令时返回false继续启动服务
+func runCommand(cfg *config.Config, args []string) bool {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return false
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "未知命令: %s\n\n可用命令:\n", args[0])
+		for name, c := range commands {
+			fmt.Fprintf(os.Stderr, "  %-20s %s\n", name, c.usage)
+		}
+		os.Exit(2)
+	}
+
+	if err := cmd.run(cfg, args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+	return true
+}
+
+// createSuperuser 创建超级用户，密码依次从--password参数、SELDOM_SUPERUSER_PASSWORD环境变量和标准输入读取
+func createSuperuser(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("createsuperuser", flag.ExitOnError)
+	username := flags.String("username", "", "用户名")
+	email := flags.String("email", "", "邮箱")
+	password := flags.String("password", "", "密码，也可通过SELDOM_SUPERUSER_PASSWORD环境变量设置")
+	flags.Parse(args)
+
+	reader := bufio.NewReader(os.Stdin)
+	if *username == "" {
+		*username = prompt(reader, "Username: ")
+	}
+	if *password == "" {
+		*password = os.Getenv("SELDOM_SUPERUSER_PASSWORD")
+	}
+	if *password == "" {
+		*password = prompt(reader, "Password: ")
+	}
+
+	if err := utils.InitLogger(cfg.Log); err != nil {
+		return fmt.Errorf("初始化日志失败: %v", err)
+	}
+	defer utils.CloseLogger()
+	db, err := database.Init(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("连接数据库失败: %v", err)
+	}
+	defer database.Close(db)
+
+	user, err := services.NewUserService(cfg).CreateUser(services.CreateUserInput{
+		Username:    *username,
+		Password:    *password,
+		Email:       *email,
+		IsSuperuser: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Superuser %q created successfully (id=%d).\n", user.Username, user.ID)
+	return nil
+}
+
+// migrate 执行数据库迁移子命令
+//
+//	migrate up [--to 版本]        执行未执行的迁移，默认全部
+//	migrate down [--steps 数量]   回滚最近执行的迁移，默认1个
+//	migrate status               查看迁移状态并检查表结构
+//	migrate create <名称>         在 database/migrations 下为各数据库方言创建空的迁移文件
+func migrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("用法: migrate up|down|status|create")
+	}
+	action, args := args[0], args[1:]
+	if action == "create" {
+		return createMigration(args)
+	}
+
+	flags := flag.NewFlagSet("migrate "+action, flag.ExitOnError)
+	to := flags.Uint64("to", 0, "执行到指定版本（包含），0表示全部")
+	steps := flags.Int("steps", 1, "回滚的迁移数量")
+	flags.Parse(args)
+
+	if err := utils.InitLogger(cfg.Log); err != nil {
+		return fmt.Errorf("初始化日志失败: %v", err)
+	}
+	defer utils.CloseLogger()
+	db, err := database.Open(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("连接数据库失败: %v", err)
+	}
+	defer database.Close(db)
+
+	migrator, err := database.NewMigrator(db, cfg.Database.Driver)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "up":
+		done, err := migrator.Up(*to)
+		for _, m := range done {
+			fmt.Printf("Applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			fmt.Println("No migrations to apply.")
+		}
+	case "down":
+		if *steps < 1 {
+			return fmt.Errorf("--steps 必须大于0")
+		}
+		done, err := migrator.Down(*steps)
+		for _, m := range done {
+			fmt.Printf("Reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			fmt.Println("No migrations to revert.")
+		}
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format(utils.DateTimeFormat)
+			}
+			if status.Modified {
+				state += " (modified)"
+			}
+			if status.Missing {
+				state += " (unknown to this build)"
+			}
+			fmt.Printf("%04d_%-30s %s\n", status.Version, status.Name, state)
+		}
+		if err := migrator.Check(); err != nil {
+			return err
+		}
+		fmt.Println("Schema is up to date.")
+	default:
+		return fmt.Errorf("未知操作: %s，可用操作: up、down、status、create", action)
+	}
+	return nil
+}
+
+var migrationNameRe = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// createMigration 为各数据库方言创建空的迁移文件，版本号为当前最大版本加1
+func createMigration(args []string) error {
+	flags := flag.NewFlagSet("migrate create", flag.ExitOnError)
+	dir := flags.String("dir", filepath.Join("database", "migrations"), "迁移文件目录")
+	flags.Parse(args)
+	if flags.NArg() != 1 || !migrationNameRe.MatchString(flags.Arg(0)) {
+		return fmt.Errorf("用法: migrate create [--dir 目录] <名称>，名称只能包含小写字母、数字和下划线")
+	}
+	name := flags.Arg(0)
+
+	drivers := []string{"sqlite3", "mysql", "postgres"}
+	var version uint64
+	for _, driver := range drivers {
+		migrations, err := database.LoadMigrations(driver)
+		if err != nil {
+			return err
+		}
+		if n := len(migrations); n > 0 && migrations[n-1].Version > version {
+			version = migrations[n-1].Version
+		}
+	}
+	version++
+
+	header := fmt.Sprintf("-- %04d_%s，创建于 %s\n", version, name, time.Now().Format("2006-01-02"))
+	for _, driver := range drivers {
+		for _, direction := range []string{"up", "down"} {
+			file := filepath.Join(*dir, driver, fmt.Sprintf("%04d_%s.%s.sql", version, name, direction))
+			if err := os.WriteFile(file, []byte(header), 0644); err != nil {
+				return err
+			}
+			fmt.Println("Created", file)
+		}
+	}
+	return nil
+}
+
+// prompt 从标准输入读取一行
+func prompt(reader *bufio.Reader, label string) string {
+	fmt.Print(label)
+	line, _ := reader.ReadString('\n')
+	return strings.TrimSpace(line)
+}
@@ -1,86 +1,375 @@
+// Package config 加载平台配置：默认值、配置文件（YAML或TOML，可按profile叠加）、环境变量依次覆盖，加载后校验
 package config
 
 import (
-	"log"
-	"os"
-	"strconv"
+	"fmt"
+	"strings"
+	"sync/atomic"
 )
 
+// Config 平台配置，配置文件中的键为字段的yaml/toml标签
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	JWT      JWTConfig
+	Server     ServerConfig     `yaml:"server" toml:"server"`
+	Database   DatabaseConfig   `yaml:"database" toml:"database"`
+	Redis      RedisConfig      `yaml:"redis" toml:"redis"`
+	JWT        JWTConfig        `yaml:"jwt" toml:"jwt"`
+	Security   SecurityConfig   `yaml:"security" toml:"security"`
+	Mail       MailConfig       `yaml:"mail" toml:"mail"`
+	OIDC       OIDCConfig       `yaml:"oidc" toml:"oidc"`
+	LDAP       LDAPConfig       `yaml:"ldap" toml:"ldap"`
+	Trash      TrashConfig      `yaml:"trash" toml:"trash"`
+	Run        RunConfig        `yaml:"run" toml:"run"`
+	Metrics    MetricsConfig    `yaml:"metrics" toml:"metrics"`
+	Tracing    TracingConfig    `yaml:"tracing" toml:"tracing"`
+	Log        LogConfig        `yaml:"log" toml:"log"`
+	Health     HealthConfig     `yaml:"health" toml:"health"`
+	Middleware MiddlewareConfig `yaml:"middleware" toml:"middleware"`
+
+	file     string                  // 加载的配置文件，重新加载时使用
+	profile  string                  // 配置profile：dev、test、prod
+	reloaded *atomic.Pointer[Config] // 重新加载后生效的配置，由Load创建
 }
 
 type ServerConfig struct {
-	Port string
-	Mode string
+	Port            string `yaml:"port" toml:"port"`
+	Mode            string `yaml:"mode" toml:"mode"`
+	PublicURL       string `yaml:"public_url" toml:"public_url"`             // 平台对外访问地址，用于邮件中的链接
+	WebDir          string `yaml:"web_dir" toml:"web_dir"`                   // 前端构建产物目录，设置后代替内嵌的前端文件
+	ShutdownTimeout int    `yaml:"shutdown_timeout" toml:"shutdown_timeout"` // 退出时等待处理中的请求完成的时间（秒）
 }
 
 type DatabaseConfig struct {
-	Driver   string
-	Host     string
-	Port     string
-	Username string
-	Password string
-	Database string
-	SSLMode  string
+	Driver      string `yaml:"driver" toml:"driver"`
+	Host        string `yaml:"host" toml:"host"`
+	Port        string `yaml:"port" toml:"port"`
+	Username    string `yaml:"username" toml:"username"`
+	Password    string `yaml:"password" toml:"password"`
+	Database    string `yaml:"database" toml:"database"`
+	SSLMode     string `yaml:"ssl_mode" toml:"ssl_mode"`
+	AutoMigrate bool   `yaml:"auto_migrate" toml:"auto_migrate"` // 启动时是否自动执行未执行的迁移，关闭后需要先运行 migrate up
 }
 
 type RedisConfig struct {
-	Host     string
-	Port     string
-	Password string
-	DB       int
+	Host     string `yaml:"host" toml:"host"`
+	Port     string `yaml:"port" toml:"port"`
+	Password string `yaml:"password" toml:"password"`
+	DB       int    `yaml:"db" toml:"db"`
 }
 
 type JWTConfig struct {
-	Secret string
-	Expire int // hours
+	Secret        string `yaml:"secret" toml:"secret"`
+	AccessExpire  int    `yaml:"access_expire" toml:"access_expire"`   // access token有效期（分钟）
+	RefreshExpire int    `yaml:"refresh_expire" toml:"refresh_expire"` // refresh token有效期（小时）
+}
+
+// SecurityConfig 密码策略、登录锁定、认证接口限流以及密码重置和邮箱验证配置
+type SecurityConfig struct {
+	PasswordMinLength     int    `yaml:"password_min_length" toml:"password_min_length"`         // 密码最小长度
+	PasswordRequireUpper  bool   `yaml:"password_require_upper" toml:"password_require_upper"`   // 是否要求包含大写字母
+	PasswordRequireLower  bool   `yaml:"password_require_lower" toml:"password_require_lower"`   // 是否要求包含小写字母
+	PasswordRequireDigit  bool   `yaml:"password_require_digit" toml:"password_require_digit"`   // 是否要求包含数字
+	PasswordRequireSymbol bool   `yaml:"password_require_symbol" toml:"password_require_symbol"` // 是否要求包含特殊字符
+	LoginMaxAttempts      int    `yaml:"login_max_attempts" toml:"login_max_attempts"`           // 连续登录失败多少次后锁定账号，0表示不锁定
+	LoginLockoutMinutes   int    `yaml:"login_lockout_minutes" toml:"login_lockout_minutes"`     // 账号锁定时长（分钟）
+	AuthRateLimit         int    `yaml:"auth_rate_limit" toml:"auth_rate_limit"`                 // 登录、注册接口每分钟允许的请求数（按IP和用户名分别计数）
+	EmailVerification     bool   `yaml:"email_verification" toml:"email_verification"`           // 注册后是否需要验证邮箱才能激活账号
+	ResetTokenExpire      int    `yaml:"reset_token_expire" toml:"reset_token_expire"`           // 密码重置链接有效期（分钟）
+	VerifyTokenExpire     int    `yaml:"verify_token_expire" toml:"verify_token_expire"`         // 邮箱验证链接有效期（小时）
+	SecretKey             string `yaml:"secret_key" toml:"secret_key"`                           // 环境密钥变量的加密密钥，为空时不能保存新的密钥变量，只能解密之前使用JWT密钥加密的变量
+}
+
+// MailConfig SMTP邮件配置，用于发送测试报告、密码重置和邮箱验证邮件
+type MailConfig struct {
+	Host     string `yaml:"host" toml:"host"`
+	Port     string `yaml:"port" toml:"port"`
+	Username string `yaml:"username" toml:"username"`
+	Password string `yaml:"password" toml:"password"`
+	From     string `yaml:"from" toml:"from"`
+}
+
+// OIDCConfig OIDC单点登录配置，Issuer为空时不启用
+type OIDCConfig struct {
+	Issuer        string   `yaml:"issuer" toml:"issuer"`                 // 身份提供方地址，用于获取 /.well-known/openid-configuration
+	ClientID      string   `yaml:"client_id" toml:"client_id"`           // 客户端ID
+	ClientSecret  string   `yaml:"client_secret" toml:"client_secret"`   // 客户端密钥，公共客户端可为空
+	RedirectURL   string   `yaml:"redirect_url" toml:"redirect_url"`     // 回调地址
+	Scopes        []string `yaml:"scopes" toml:"scopes"`                 // 申请的scope
+	UsernameClaim string   `yaml:"username_claim" toml:"username_claim"` // 作为平台用户名的claim
+	GroupsClaim   string   `yaml:"groups_claim" toml:"groups_claim"`     // 用户组claim
+	GroupRoles    string   `yaml:"group_roles" toml:"group_roles"`       // 用户组到平台角色的映射，格式见README
+}
+
+// LDAPConfig LDAP目录服务登录配置，URL为空时不启用
+type LDAPConfig struct {
+	URL                string `yaml:"url" toml:"url"`                                   // 服务地址，如 ldap://ldap.example.com:389 或 ldaps://ldap.example.com:636
+	StartTLS           bool   `yaml:"start_tls" toml:"start_tls"`                       // ldap://连接是否升级为TLS
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify" toml:"insecure_skip_verify"` // 是否跳过TLS证书校验，仅用于测试环境
+	BindDN             string `yaml:"bind_dn" toml:"bind_dn"`                           // 用于搜索用户的服务账号DN，为空时匿名搜索
+	BindPassword       string `yaml:"bind_password" toml:"bind_password"`               // 服务账号密码
+	BaseDN             string `yaml:"base_dn" toml:"base_dn"`                           // 用户搜索的起点
+	UserFilter         string `yaml:"user_filter" toml:"user_filter"`                   // 用户搜索过滤器，%s替换为转义后的用户名
+	UsernameAttr       string `yaml:"username_attr" toml:"username_attr"`               // 用户名属性
+	EmailAttr          string `yaml:"email_attr" toml:"email_attr"`                     // 邮箱属性
+	FirstNameAttr      string `yaml:"first_name_attr" toml:"first_name_attr"`           // 名属性
+	LastNameAttr       string `yaml:"last_name_attr" toml:"last_name_attr"`             // 姓属性
+	GroupAttr          string `yaml:"group_attr" toml:"group_attr"`                     // 用户条目上记录所属组的属性
+	GroupBaseDN        string `yaml:"group_base_dn" toml:"group_base_dn"`               // 用户组搜索的起点，为空时使用BaseDN
+	GroupFilter        string `yaml:"group_filter" toml:"group_filter"`                 // 用户组搜索过滤器，%s替换为转义后的用户DN，为空时只使用GroupAttr
+	GroupRoles         string `yaml:"group_roles" toml:"group_roles"`                   // 用户组到平台角色的映射，格式同OIDC
+	Timeout            int    `yaml:"timeout" toml:"timeout"`                           // 连接和请求超时（秒）
+}
+
+// TrashConfig 回收站配置，删除的项目、环境、任务和团队保留一段时间后自动清理
+type TrashConfig struct {
+	RetentionDays int `yaml:"retention_days" toml:"retention_days"` // 回收站保留天数，0表示不自动清理
+	PurgeInterval int `yaml:"purge_interval" toml:"purge_interval"` // 清理检查间隔（分钟）
+}
+
+// RunConfig 任务和用例执行配置，用于优雅退出和恢复异常中断的执行
+type RunConfig struct {
+	DrainTimeout       int    `yaml:"drain_timeout" toml:"drain_timeout"`             // 退出时等待正在执行的任务完成的时间（秒），超时后标记为已中断
+	HeartbeatInterval  int    `yaml:"heartbeat_interval" toml:"heartbeat_interval"`   // 执行心跳间隔（秒），心跳超过3个间隔未更新的执行视为已中断
+	RequeueInterrupted bool   `yaml:"requeue_interrupted" toml:"requeue_interrupted"` // 是否重新执行被中断的任务和用例
+	MaxConcurrent      int    `yaml:"max_concurrent" toml:"max_concurrent"`           // 本实例同时执行的任务和用例数上限，超出时排队等待，0表示不限制
+	Workspace          string `yaml:"workspace" toml:"workspace"`                     // 项目代码的根目录，用例在 <workspace>/<项目名>/<仓库名> 中执行
+}
+
+// MetricsConfig Prometheus指标配置
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled" toml:"enabled"` // 是否开启 /metrics 接口
+	Token   string `yaml:"token" toml:"token"`     // 访问 /metrics 的Bearer令牌，为空时不校验
 }
 
-func Load() *Config {
+// LogConfig 日志配置，日志文件按大小和日期滚动
+type LogConfig struct {
+	Level      string `yaml:"level" toml:"level"`             // 日志级别：debug、info、warn、error
+	Format     string `yaml:"format" toml:"format"`           // 输出格式：console或json
+	Dir        string `yaml:"dir" toml:"dir"`                 // 日志文件目录，为空时只输出到控制台
+	MaxSize    int    `yaml:"max_size" toml:"max_size"`       // 单个日志文件的最大大小（MB），超过后滚动
+	MaxAge     int    `yaml:"max_age" toml:"max_age"`         // 滚动后的日志文件保留天数，0表示不按天数清理
+	MaxBackups int    `yaml:"max_backups" toml:"max_backups"` // 滚动后的日志文件最多保留个数，0表示不按个数清理
+	Compress   bool   `yaml:"compress" toml:"compress"`       // 是否gzip压缩滚动后的日志文件
+}
+
+// HealthConfig 健康检查配置，用于 /livez 和 /readyz
+type HealthConfig struct {
+	DiskPath      string `yaml:"disk_path" toml:"disk_path"`               // 检查剩余空间的工作目录
+	DiskMinFreeMB int    `yaml:"disk_min_free_mb" toml:"disk_min_free_mb"` // 工作目录最少剩余空间（MB），低于时就绪检查失败，0表示不检查
+	Redis         bool   `yaml:"redis" toml:"redis"`                       // 就绪检查是否包含Redis
+	Timeout       int    `yaml:"timeout" toml:"timeout"`                   // 单次检查的超时时间（秒）
+}
+
+// MiddlewareConfig HTTP中间件配置，全局策略作用于所有请求，路由组策略作用于路径前缀匹配的请求
+type MiddlewareConfig struct {
+	SecurityHeaders bool                   `yaml:"security_headers" toml:"security_headers"` // 是否添加X-Frame-Options、CSP等安全响应头
+	TrustedProxies  []string               `yaml:"trusted_proxies" toml:"trusted_proxies"`   // 可信的反向代理IP或CIDR，只采用这些代理设置的X-Forwarded-For作为客户端IP
+	MaxBodySize     int                    `yaml:"max_body_size" toml:"max_body_size"`       // 请求体最大大小（MB），0表示不限制
+	RateLimit       int                    `yaml:"rate_limit" toml:"rate_limit"`             // 每个客户端IP每分钟允许的请求数，0表示不限制
+	CORS            CORSConfig             `yaml:"cors" toml:"cors"`
+	Groups          map[string]GroupPolicy `yaml:"groups" toml:"groups"` // 路由组策略，键为路径前缀，如 /api/auth
+}
+
+// CORSConfig 跨域配置
+type CORSConfig struct {
+	AllowedOrigins   []string `yaml:"allowed_origins" toml:"allowed_origins"`     // 允许跨域访问的源，如 https://seldom.example.com，*表示允许所有源（不携带凭证）
+	AllowCredentials bool     `yaml:"allow_credentials" toml:"allow_credentials"` // 是否允许跨域请求携带凭证
+	MaxAge           int      `yaml:"max_age" toml:"max_age"`                     // 预检请求结果的缓存时间（秒）
+}
+
+// GroupPolicy 路由组策略，路径匹配多个路由组时依次应用
+type GroupPolicy struct {
+	RateLimit   int      `yaml:"rate_limit" toml:"rate_limit"`       // 每个客户端IP每分钟允许的请求数，0表示不限制
+	IPAllowlist []string `yaml:"ip_allowlist" toml:"ip_allowlist"`   // 允许访问的客户端IP或CIDR，为空时不限制
+	MaxBodySize int      `yaml:"max_body_size" toml:"max_body_size"` // 请求体最大大小（MB），0表示使用全局限制
+}
+
+// TracingConfig OpenTelemetry链路追踪配置，导出地址等通过OTEL_EXPORTER_OTLP_*环境变量配置
+type TracingConfig struct {
+	Enabled     bool    `yaml:"enabled" toml:"enabled"`           // 是否开启链路追踪
+	ServiceName string  `yaml:"service_name" toml:"service_name"` // 上报的服务名
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"` // 没有上游span时的采样比例，0~1
+}
+
+// profileModes 各profile默认的运行模式
+var profileModes = map[string]string{
+	"dev":  "debug",
+	"test": "test",
+	"prod": "release",
+}
+
+// insecureJWTSecret 默认的JWT密钥，只能用于开发环境
+const insecureJWTSecret = "django-insecure-shbnuusqqu0+f92j+=@%w31b02o$(ulzsd0pq451jzj&cdyaqx"
+
+// Default 返回profile的默认配置
+func Default(profile string) *Config {
+	mode := profileModes[profile]
+	if mode == "" {
+		mode = "debug"
+	}
+
 	return &Config{
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
-			Mode: getEnv("GIN_MODE", "debug"),
+			Port:            "8080",
+			Mode:            mode,
+			PublicURL:       "http://localhost:8080",
+			ShutdownTimeout: 10,
 		},
 		Database: DatabaseConfig{
-			Driver:   getEnv("DB_DRIVER", "sqlite3"),
-			Host:     getEnv("DB_HOST", ""),
-			Port:     getEnv("DB_PORT", ""),
-			Username: getEnv("DB_USERNAME", ""),
-			Password: getEnv("DB_PASSWORD", ""),
-			Database: getEnv("DB_DATABASE", "dev.sqlite3"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Driver:      "sqlite3",
+			Database:    "dev.sqlite3",
+			SSLMode:     "disable",
+			AutoMigrate: true,
 		},
 		Redis: RedisConfig{
-			Host:     getEnv("REDIS_HOST", "172.17.0.1"),
-			Port:     getEnv("REDIS_PORT", "6379"),
-			Password: getEnv("REDIS_PASSWORD", ""),
-			DB:       getEnvAsInt("REDIS_DB", 1),
+			Host: "172.17.0.1",
+			Port: "6379",
+			DB:   1,
 		},
 		JWT: JWTConfig{
-			Secret: getEnv("JWT_SECRET", "django-insecure-shbnuusqqu0+f92j+=@%w31b02o$(ulzsd0pq451jzj&cdyaqx"),
-			Expire: getEnvAsInt("JWT_EXPIRE", 24),
+			Secret:        insecureJWTSecret,
+			AccessExpire:  15,
+			RefreshExpire: 168,
+		},
+		Security: SecurityConfig{
+			PasswordMinLength:    8,
+			PasswordRequireDigit: true,
+			LoginMaxAttempts:     5,
+			LoginLockoutMinutes:  15,
+			AuthRateLimit:        10,
+			ResetTokenExpire:     30,
+			VerifyTokenExpire:    24,
+		},
+		Mail: MailConfig{
+			Port: "465",
+		},
+		OIDC: OIDCConfig{
+			Scopes:        []string{"openid", "profile", "email"},
+			UsernameClaim: "preferred_username",
+			GroupsClaim:   "groups",
+		},
+		LDAP: LDAPConfig{
+			UserFilter:    "(uid=%s)",
+			UsernameAttr:  "uid",
+			EmailAttr:     "mail",
+			FirstNameAttr: "givenName",
+			LastNameAttr:  "sn",
+			GroupAttr:     "memberOf",
+			Timeout:       10,
+		},
+		Trash: TrashConfig{
+			RetentionDays: 30,
+			PurgeInterval: 60,
+		},
+		Run: RunConfig{
+			DrainTimeout:      60,
+			HeartbeatInterval: 30,
+			Workspace:         ".",
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+		},
+		Log: LogConfig{
+			Level:   "info",
+			Format:  "console",
+			Dir:     "logs",
+			MaxSize: 100,
+			MaxAge:  30,
+		},
+		Tracing: TracingConfig{
+			ServiceName: "seldom-platform",
+			SampleRatio: 1,
+		},
+		Health: HealthConfig{
+			DiskMinFreeMB: 512,
+			Timeout:       2,
+		},
+		Middleware: MiddlewareConfig{
+			TrustedProxies: []string{"127.0.0.1/8", "::1/128"},
+			MaxBodySize:    10,
+			CORS: CORSConfig{
+				AllowedOrigins:   []string{"http://127.0.0.1:3000", "http://127.0.0.1:5173", "http://localhost:3000", "http://localhost:5173"},
+				AllowCredentials: true,
+				MaxAge:           600,
+			},
+			Groups: map[string]GroupPolicy{
+				// 认证接口，登录、注册等接口另外按用户名限流（security.auth_rate_limit）
+				"/api/auth": {RateLimit: 60},
+				"/api/user": {RateLimit: 60},
+				// 用户管理接口，通过ADMIN_IP_ALLOWLIST限制访问来源，release模式下必须设置
+				"/api/admin": {},
+			},
 		},
 	}
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Load 加载配置：默认值、配置文件、环境变量依次覆盖，加载后校验。
+// 配置文件由CONFIG_FILE指定，未指定时依次查找当前目录下的config.yaml、config.yml、config.toml，都不存在时只使用环境变量；
+// CONFIG_PROFILE为dev、test或prod时，再叠加同目录下的config.<profile>.<扩展名>
+func Load() (*Config, error) {
+	cfg, err := load(getEnv("CONFIG_FILE", ""), getEnv("CONFIG_PROFILE", ""))
+	if err != nil {
+		return nil, err
 	}
-	return defaultValue
+	cfg.reloaded = &atomic.Pointer[Config]{}
+	return cfg, nil
 }
 
-func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
+func load(file, profile string) (*Config, error) {
+	if _, ok := profileModes[profile]; profile != "" && !ok {
+		return nil, fmt.Errorf("invalid CONFIG_PROFILE %q, must be dev, test or prod", profile)
+	}
+
+	cfg := Default(profile)
+	files, err := findFiles(file, profile)
+	if err != nil {
+		return nil, err
+	}
+	var emailVerificationSet bool
+	for _, path := range files {
+		keys, err := decodeFile(path, cfg)
+		if err != nil {
+			return nil, err
 		}
-		log.Printf("Warning: Invalid integer value for %s: %s, using default: %d", key, value, defaultValue)
+		emailVerificationSet = emailVerificationSet || keys["security.email_verification"]
 	}
-	return defaultValue
-}
\ No newline at end of file
+	if len(files) > 0 {
+		cfg.file = files[0]
+	}
+	cfg.profile = profile
+
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+	emailVerificationSet = emailVerificationSet || getEnv("EMAIL_VERIFICATION", "") != ""
+
+	// 由其他配置推导的默认值
+	cfg.OIDC.Issuer = strings.TrimSuffix(cfg.OIDC.Issuer, "/")
+	if cfg.OIDC.RedirectURL == "" {
+		cfg.OIDC.RedirectURL = strings.TrimSuffix(cfg.Server.PublicURL, "/") + "/api/auth/oidc/callback"
+	}
+	if cfg.Mail.From == "" {
+		cfg.Mail.From = cfg.Mail.Username
+	}
+	if !emailVerificationSet {
+		// 没有明确配置时，配置了SMTP服务器则需要验证邮箱
+		cfg.Security.EmailVerification = cfg.Mail.Host != ""
+	}
+	if cfg.Health.DiskPath == "" {
+		cfg.Health.DiskPath = cfg.Run.Workspace
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// File 返回加载的配置文件，没有配置文件时为空
+func (c *Config) File() string {
+	return c.file
+}
+
+// Profile 返回配置profile
+func (c *Config) Profile() string {
+	return c.profile
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// envReader 读取环境变量覆盖配置，值为空的环境变量视为未设置，值无效时记录错误
+type envReader struct {
+	errs []error
+}
+
+func (e *envReader) string(key string, value *string) {
+	if v := os.Getenv(key); v != "" {
+		*value = v
+	}
+}
+
+func (e *envReader) int(key string, value *int) {
+	if v := os.Getenv(key); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("invalid integer value for %s: %q", key, v))
+			return
+		}
+		*value = n
+	}
+}
+
+func (e *envReader) float(key string, value *float64) {
+	if v := os.Getenv(key); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("invalid float value for %s: %q", key, v))
+			return
+		}
+		*value = f
+	}
+}
+
+func (e *envReader) bool(key string, value *bool) {
+	if v := os.Getenv(key); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("invalid boolean value for %s: %q", key, v))
+			return
+		}
+		*value = b
+	}
+}
+
+// slice 逗号或空格分隔的列表
+func (e *envReader) slice(key string, value *[]string) {
+	if v := os.Getenv(key); v != "" {
+		*value = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+}
+
+// applyEnv 使用环境变量覆盖配置
+func applyEnv(cfg *Config) error {
+	env := &envReader{}
+
+	env.string("SERVER_PORT", &cfg.Server.Port)
+	env.string("GIN_MODE", &cfg.Server.Mode)
+	env.string("PUBLIC_URL", &cfg.Server.PublicURL)
+	env.string("WEB_DIR", &cfg.Server.WebDir)
+	env.int("SHUTDOWN_TIMEOUT", &cfg.Server.ShutdownTimeout)
+
+	env.string("DB_DRIVER", &cfg.Database.Driver)
+	env.string("DB_HOST", &cfg.Database.Host)
+	env.string("DB_PORT", &cfg.Database.Port)
+	env.string("DB_USERNAME", &cfg.Database.Username)
+	env.string("DB_PASSWORD", &cfg.Database.Password)
+	env.string("DB_DATABASE", &cfg.Database.Database)
+	env.string("DB_SSLMODE", &cfg.Database.SSLMode)
+	env.bool("DB_AUTO_MIGRATE", &cfg.Database.AutoMigrate)
+
+	env.string("REDIS_HOST", &cfg.Redis.Host)
+	env.string("REDIS_PORT", &cfg.Redis.Port)
+	env.string("REDIS_PASSWORD", &cfg.Redis.Password)
+	env.int("REDIS_DB", &cfg.Redis.DB)
+
+	env.string("JWT_SECRET", &cfg.JWT.Secret)
+	env.int("JWT_ACCESS_EXPIRE", &cfg.JWT.AccessExpire)
+	env.int("JWT_REFRESH_EXPIRE", &cfg.JWT.RefreshExpire)
+
+	env.int("PASSWORD_MIN_LENGTH", &cfg.Security.PasswordMinLength)
+	env.bool("PASSWORD_REQUIRE_UPPER", &cfg.Security.PasswordRequireUpper)
+	env.bool("PASSWORD_REQUIRE_LOWER", &cfg.Security.PasswordRequireLower)
+	env.bool("PASSWORD_REQUIRE_DIGIT", &cfg.Security.PasswordRequireDigit)
+	env.bool("PASSWORD_REQUIRE_SYMBOL", &cfg.Security.PasswordRequireSymbol)
+	env.int("LOGIN_MAX_ATTEMPTS", &cfg.Security.LoginMaxAttempts)
+	env.int("LOGIN_LOCKOUT_MINUTES", &cfg.Security.LoginLockoutMinutes)
+	env.int("AUTH_RATE_LIMIT", &cfg.Security.AuthRateLimit)
+	env.bool("EMAIL_VERIFICATION", &cfg.Security.EmailVerification)
+	env.int("RESET_TOKEN_EXPIRE", &cfg.Security.ResetTokenExpire)
+	env.int("VERIFY_TOKEN_EXPIRE", &cfg.Security.VerifyTokenExpire)
+	env.string("SECRET_ENCRYPTION_KEY", &cfg.Security.SecretKey)
+
+	env.string("SMTP_HOST", &cfg.Mail.Host)
+	env.string("SMTP_PORT", &cfg.Mail.Port)
+	env.string("SMTP_USER", &cfg.Mail.Username)
+	env.string("SMTP_PASSWORD", &cfg.Mail.Password)
+	env.string("SMTP_FROM", &cfg.Mail.From)
+
+	env.string("OIDC_ISSUER", &cfg.OIDC.Issuer)
+	env.string("OIDC_CLIENT_ID", &cfg.OIDC.ClientID)
+	env.string("OIDC_CLIENT_SECRET", &cfg.OIDC.ClientSecret)
+	env.string("OIDC_REDIRECT_URL", &cfg.OIDC.RedirectURL)
+	env.slice("OIDC_SCOPES", &cfg.OIDC.Scopes)
+	env.string("OIDC_USERNAME_CLAIM", &cfg.OIDC.UsernameClaim)
+	env.string("OIDC_GROUPS_CLAIM", &cfg.OIDC.GroupsClaim)
+	env.string("OIDC_GROUP_ROLES", &cfg.OIDC.GroupRoles)
+
+	env.string("LDAP_URL", &cfg.LDAP.URL)
+	env.bool("LDAP_START_TLS", &cfg.LDAP.StartTLS)
+	env.bool("LDAP_INSECURE_SKIP_VERIFY", &cfg.LDAP.InsecureSkipVerify)
+	env.string("LDAP_BIND_DN", &cfg.LDAP.BindDN)
+	env.string("LDAP_BIND_PASSWORD", &cfg.LDAP.BindPassword)
+	env.string("LDAP_BASE_DN", &cfg.LDAP.BaseDN)
+	env.string("LDAP_USER_FILTER", &cfg.LDAP.UserFilter)
+	env.string("LDAP_USERNAME_ATTR", &cfg.LDAP.UsernameAttr)
+	env.string("LDAP_EMAIL_ATTR", &cfg.LDAP.EmailAttr)
+	env.string("LDAP_FIRST_NAME_ATTR", &cfg.LDAP.FirstNameAttr)
+	env.string("LDAP_LAST_NAME_ATTR", &cfg.LDAP.LastNameAttr)
+	env.string("LDAP_GROUP_ATTR", &cfg.LDAP.GroupAttr)
+	env.string("LDAP_GROUP_BASE_DN", &cfg.LDAP.GroupBaseDN)
+	env.string("LDAP_GROUP_FILTER", &cfg.LDAP.GroupFilter)
+	env.string("LDAP_GROUP_ROLES", &cfg.LDAP.GroupRoles)
+	env.int("LDAP_TIMEOUT", &cfg.LDAP.Timeout)
+
+	env.int("TRASH_RETENTION_DAYS", &cfg.Trash.RetentionDays)
+	env.int("TRASH_PURGE_INTERVAL", &cfg.Trash.PurgeInterval)
+
+	env.int("RUN_DRAIN_TIMEOUT", &cfg.Run.DrainTimeout)
+	env.int("RUN_HEARTBEAT_INTERVAL", &cfg.Run.HeartbeatInterval)
+	env.bool("RUN_REQUEUE_INTERRUPTED", &cfg.Run.RequeueInterrupted)
+	env.int("RUN_MAX_CONCURRENT", &cfg.Run.MaxConcurrent)
+	env.string("RUN_WORKSPACE", &cfg.Run.Workspace)
+
+	env.bool("METRICS_ENABLED", &cfg.Metrics.Enabled)
+	env.string("METRICS_TOKEN", &cfg.Metrics.Token)
+
+	env.string("LOG_LEVEL", &cfg.Log.Level)
+	env.string("LOG_FORMAT", &cfg.Log.Format)
+	// LOG_DIR设为空字符串时不写日志文件
+	if dir, ok := os.LookupEnv("LOG_DIR"); ok {
+		cfg.Log.Dir = dir
+	}
+	env.int("LOG_MAX_SIZE", &cfg.Log.MaxSize)
+	env.int("LOG_MAX_AGE", &cfg.Log.MaxAge)
+	env.int("LOG_MAX_BACKUPS", &cfg.Log.MaxBackups)
+	env.bool("LOG_COMPRESS", &cfg.Log.Compress)
+
+	env.bool("TRACING_ENABLED", &cfg.Tracing.Enabled)
+	env.string("OTEL_SERVICE_NAME", &cfg.Tracing.ServiceName)
+	env.float("TRACING_SAMPLE_RATIO", &cfg.Tracing.SampleRatio)
+
+	env.string("HEALTH_DISK_PATH", &cfg.Health.DiskPath)
+	env.int("HEALTH_DISK_MIN_FREE_MB", &cfg.Health.DiskMinFreeMB)
+	env.bool("HEALTH_REDIS", &cfg.Health.Redis)
+	env.int("HEALTH_TIMEOUT", &cfg.Health.Timeout)
+
+	env.bool("SECURITY_HEADERS", &cfg.Middleware.SecurityHeaders)
+	env.slice("TRUSTED_PROXIES", &cfg.Middleware.TrustedProxies)
+	env.int("MAX_BODY_SIZE", &cfg.Middleware.MaxBodySize)
+	env.int("RATE_LIMIT", &cfg.Middleware.RateLimit)
+	env.slice("CORS_ALLOWED_ORIGINS", &cfg.Middleware.CORS.AllowedOrigins)
+	env.bool("CORS_ALLOW_CREDENTIALS", &cfg.Middleware.CORS.AllowCredentials)
+	env.int("CORS_MAX_AGE", &cfg.Middleware.CORS.MaxAge)
+	if os.Getenv("ADMIN_IP_ALLOWLIST") != "" {
+		if cfg.Middleware.Groups == nil {
+			cfg.Middleware.Groups = make(map[string]GroupPolicy)
+		}
+		admin := cfg.Middleware.Groups["/api/admin"]
+		env.slice("ADMIN_IP_ALLOWLIST", &admin.IPAllowlist)
+		cfg.Middleware.Groups["/api/admin"] = admin
+	}
+
+	return errors.Join(env.errs...)
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// 未指定CONFIG_FILE时依次查找的配置文件
+var defaultFiles = []string{"config.yaml", "config.yml", "config.toml"}
+
+// findFiles 返回要加载的配置文件，profile的配置文件在后，不存在的profile配置文件忽略
+func findFiles(file, profile string) ([]string, error) {
+	var files []string
+	if file != "" {
+		if _, err := os.Stat(file); err != nil {
+			return nil, fmt.Errorf("config file: %w", err)
+		}
+		files = append(files, file)
+	} else if found := firstExisting(defaultFiles); found != "" {
+		files = append(files, found)
+	}
+	if profile == "" {
+		return files, nil
+	}
+
+	// config.yaml对应的profile配置文件为config.<profile>.yaml
+	var candidates []string
+	if len(files) > 0 {
+		ext := filepath.Ext(files[0])
+		candidates = []string{strings.TrimSuffix(files[0], ext) + "." + profile + ext}
+	} else {
+		for _, name := range defaultFiles {
+			ext := filepath.Ext(name)
+			candidates = append(candidates, strings.TrimSuffix(name, ext)+"."+profile+ext)
+		}
+	}
+	if found := firstExisting(candidates); found != "" {
+		files = append(files, found)
+	}
+	return files, nil
+}
+
+func firstExisting(paths []string) string {
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// decodeFile 把配置文件解析到cfg中，文件中没有的键保持原值，有未知的键时返回错误。
+// 返回文件中出现的键，如 security.email_verification
+func decodeFile(path string, cfg *Config) (map[string]bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(cfg); err != nil {
+			var strictErr *toml.StrictMissingError
+			if errors.As(err, &strictErr) {
+				return nil, fmt.Errorf("%s: unknown keys:\n%s", path, strictErr.String())
+			}
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("%s: unsupported config file extension %s, must be .yaml, .yml or .toml", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	keys := make(map[string]bool)
+	for section, value := range raw {
+		if values, ok := value.(map[string]interface{}); ok {
+			for key := range values {
+				keys[section+"."+key] = true
+			}
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var reloadMu sync.Mutex
+
+// ReloadResult 重新加载配置的结果，配置项为配置文件中的键
+type ReloadResult struct {
+	Config  *Config  // 重新加载后生效的配置
+	Applied []string // 已生效的配置项
+	Ignored []string // 已修改但需要重启才能生效的配置项
+}
+
+// Current 返回当前生效的配置。重新加载后返回新的配置，没有重新加载过或配置不是由Load创建时返回c本身。
+// 可热更新的配置项应在使用时通过Current读取
+func (c *Config) Current() *Config {
+	if c.reloaded != nil {
+		if latest := c.reloaded.Load(); latest != nil {
+			return latest
+		}
+	}
+	return c
+}
+
+// Reload 重新读取启动时的配置文件和环境变量，校验通过后只更新可热更新的配置项：
+// 日志、密码策略和登录锁定、令牌有效期、邮件、执行并发数和健康检查；校验失败时保持原配置
+func (c *Config) Reload() (*ReloadResult, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	loaded, err := load(c.file, c.profile)
+	if err != nil {
+		return nil, err
+	}
+
+	current := c.Current()
+	next := *current
+	next.Log = loaded.Log
+	next.Security = loaded.Security
+	// 加密密钥变化后无法解密已有的密钥变量，认证接口限流器在启动时创建
+	next.Security.SecretKey = current.Security.SecretKey
+	next.Security.AuthRateLimit = current.Security.AuthRateLimit
+	next.JWT.AccessExpire = loaded.JWT.AccessExpire
+	next.JWT.RefreshExpire = loaded.JWT.RefreshExpire
+	next.Mail = loaded.Mail
+	next.Run.MaxConcurrent = loaded.Run.MaxConcurrent
+	next.Health = loaded.Health
+	if c.reloaded != nil {
+		c.reloaded.Store(&next)
+	}
+
+	return &ReloadResult{
+		Config:  &next,
+		Applied: diff(reflect.ValueOf(*current), reflect.ValueOf(next), ""),
+		Ignored: diff(reflect.ValueOf(next), reflect.ValueOf(*loaded), ""),
+	}, nil
+}
+
+// diff 返回两个配置中值不同的配置项
+func diff(a, b reflect.Value, prefix string) []string {
+	var keys []string
+	for i := 0; i < a.NumField(); i++ {
+		field := a.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key := prefix + strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if field.Type.Kind() == reflect.Struct {
+			keys = append(keys, diff(a.Field(i), b.Field(i), key+".")...)
+		} else if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// validator 收集校验错误，错误信息以配置文件中的键开头
+type validator struct {
+	errs []error
+}
+
+func (v *validator) check(ok bool, key, format string, args ...interface{}) {
+	if !ok {
+		v.errs = append(v.errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+}
+
+func (v *validator) oneOf(key, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.check(false, key, "invalid value %q, must be one of %v", value, allowed)
+}
+
+func (v *validator) port(key, value string) {
+	port, err := strconv.Atoi(value)
+	v.check(err == nil && port > 0 && port < 65536, key, "invalid port %q", value)
+}
+
+func (v *validator) url(key, value string) {
+	u, err := url.Parse(value)
+	v.check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", key, "invalid url %q", value)
+}
+
+// ips 校验IP或CIDR列表
+func (v *validator) ips(key string, values []string) {
+	for _, value := range values {
+		_, _, err := net.ParseCIDR(value)
+		v.check(err == nil || net.ParseIP(value) != nil, key, "invalid ip or cidr %q", value)
+	}
+}
+
+// allowlist 校验IP白名单，除IP和CIDR外允许使用*表示所有IP
+func (v *validator) allowlist(key string, values []string) {
+	for _, value := range values {
+		if value != "*" {
+			v.ips(key, []string{value})
+		}
+	}
+}
+
+// Validate 校验配置，返回所有不合法的配置项。release模式下不允许使用默认的JWT密钥，
+// 开启的 /metrics 接口必须设置令牌或IP白名单，/api/admin 必须设置IP白名单
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.oneOf("server.mode", c.Server.Mode, "debug", "release", "test")
+	v.port("server.port", c.Server.Port)
+	v.url("server.public_url", c.Server.PublicURL)
+	v.check(c.Server.ShutdownTimeout >= 0, "server.shutdown_timeout", "must not be negative")
+
+	v.oneOf("database.driver", c.Database.Driver, "sqlite3", "mysql", "postgres")
+	v.check(c.Database.Database != "", "database.database", "is required")
+
+	v.check(c.JWT.Secret != "", "jwt.secret", "is required")
+	if c.Server.Mode == "release" {
+		v.check(c.JWT.Secret != insecureJWTSecret, "jwt.secret", "the default secret must not be used in release mode, set JWT_SECRET")
+		v.check(len(c.JWT.Secret) >= 32, "jwt.secret", "must be at least 32 characters in release mode")
+	}
+	v.check(c.JWT.AccessExpire > 0, "jwt.access_expire", "must be positive")
+	v.check(c.JWT.RefreshExpire > 0, "jwt.refresh_expire", "must be positive")
+
+	v.check(c.Security.PasswordMinLength > 0, "security.password_min_length", "must be positive")
+	v.check(c.Security.LoginMaxAttempts >= 0, "security.login_max_attempts", "must not be negative")
+	v.check(c.Security.LoginMaxAttempts == 0 || c.Security.LoginLockoutMinutes > 0, "security.login_lockout_minutes", "must be positive when login_max_attempts is set")
+	v.check(c.Security.AuthRateLimit > 0, "security.auth_rate_limit", "must be positive")
+	v.check(c.Security.ResetTokenExpire > 0, "security.reset_token_expire", "must be positive")
+	v.check(c.Security.VerifyTokenExpire > 0, "security.verify_token_expire", "must be positive")
+	v.check(!c.Security.EmailVerification || c.Mail.Host != "", "security.email_verification", "requires mail.host")
+
+	if c.Mail.Host != "" {
+		v.port("mail.port", c.Mail.Port)
+		v.check(c.Mail.From != "", "mail.from", "is required when mail.host is set")
+	}
+
+	if c.OIDC.Issuer != "" {
+		v.url("oidc.issuer", c.OIDC.Issuer)
+		v.check(c.OIDC.ClientID != "", "oidc.client_id", "is required when oidc.issuer is set")
+		v.url("oidc.redirect_url", c.OIDC.RedirectURL)
+	}
+
+	if c.LDAP.URL != "" {
+		u, err := url.Parse(c.LDAP.URL)
+		v.check(err == nil && (u.Scheme == "ldap" || u.Scheme == "ldaps") && u.Host != "", "ldap.url", "invalid url %q, must start with ldap:// or ldaps://", c.LDAP.URL)
+		v.check(c.LDAP.BaseDN != "", "ldap.base_dn", "is required when ldap.url is set")
+		v.check(c.LDAP.Timeout > 0, "ldap.timeout", "must be positive")
+	}
+
+	v.check(c.Trash.RetentionDays >= 0, "trash.retention_days", "must not be negative")
+	v.check(c.Trash.PurgeInterval > 0, "trash.purge_interval", "must be positive")
+
+	v.check(c.Run.DrainTimeout >= 0, "run.drain_timeout", "must not be negative")
+	v.check(c.Run.HeartbeatInterval > 0, "run.heartbeat_interval", "must be positive")
+	v.check(c.Run.MaxConcurrent >= 0, "run.max_concurrent", "must not be negative")
+	info, err := os.Stat(c.Run.Workspace)
+	v.check(err == nil && info.IsDir(), "run.workspace", "%q is not a directory", c.Run.Workspace)
+
+	v.oneOf("log.level", c.Log.Level, "debug", "info", "warn", "error")
+	v.oneOf("log.format", c.Log.Format, "console", "json")
+	v.check(c.Log.MaxSize > 0, "log.max_size", "must be positive")
+	v.check(c.Log.MaxAge >= 0, "log.max_age", "must not be negative")
+	v.check(c.Log.MaxBackups >= 0, "log.max_backups", "must not be negative")
+
+	if c.Server.Mode == "release" && c.Metrics.Enabled {
+		v.check(c.Metrics.Token != "" || c.ipRestricted("/metrics"), "metrics.token",
+			"is required in release mode when metrics are enabled, set METRICS_TOKEN or middleware.groups./metrics.ip_allowlist, or disable metrics")
+	}
+
+	v.check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio", "must be between 0 and 1")
+	v.check(!c.Tracing.Enabled || c.Tracing.ServiceName != "", "tracing.service_name", "is required when tracing is enabled")
+
+	v.check(c.Health.DiskMinFreeMB >= 0, "health.disk_min_free_mb", "must not be negative")
+	v.check(c.Health.Timeout > 0, "health.timeout", "must be positive")
+
+	v.ips("middleware.trusted_proxies", c.Middleware.TrustedProxies)
+	if c.Server.Mode == "release" {
+		v.check(c.ipRestricted("/api/admin"), "middleware.groups./api/admin.ip_allowlist",
+			"is required in release mode, set ADMIN_IP_ALLOWLIST (* allows all IPs)")
+	}
+	v.check(c.Middleware.MaxBodySize >= 0, "middleware.max_body_size", "must not be negative")
+	v.check(c.Middleware.RateLimit >= 0, "middleware.rate_limit", "must not be negative")
+	for _, origin := range c.Middleware.CORS.AllowedOrigins {
+		if origin == "*" {
+			v.check(!c.Middleware.CORS.AllowCredentials, "middleware.cors.allowed_origins", "* cannot be used with allow_credentials")
+			continue
+		}
+		u, err := url.Parse(origin)
+		v.check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.Path == "",
+			"middleware.cors.allowed_origins", "invalid origin %q, must be like https://example.com", origin)
+	}
+	v.check(c.Middleware.CORS.MaxAge >= 0, "middleware.cors.max_age", "must not be negative")
+	for prefix, policy := range c.Middleware.Groups {
+		key := "middleware.groups." + prefix
+		v.check(strings.HasPrefix(prefix, "/") && (prefix == "/" || !strings.HasSuffix(prefix, "/")), key, "must be a path prefix like /api/auth")
+		v.check(policy.RateLimit >= 0, key+".rate_limit", "must not be negative")
+		v.check(policy.MaxBodySize >= 0, key+".max_body_size", "must not be negative")
+		v.allowlist(key+".ip_allowlist", policy.IPAllowlist)
+	}
+
+	return errors.Join(v.errs...)
+}
+
+// ipRestricted 路径是否被某个路由组的IP白名单限制，前缀按路径段匹配，与路由组中间件一致
+func (c *Config) ipRestricted(path string) bool {
+	for prefix, policy := range c.Middleware.Groups {
+		if len(policy.IPAllowlist) > 0 && (prefix == "/" || path == prefix || strings.HasPrefix(path, prefix+"/")) {
+			return true
+		}
+	}
+	return false
+}
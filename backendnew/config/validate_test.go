@@ -0,0 +1,77 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// releaseConfig 返回除待测配置项外都合法的release模式配置
+func releaseConfig() *Config {
+	cfg := Default("prod")
+	cfg.JWT.Secret = strings.Repeat("s", 32)
+	cfg.Metrics.Token = "metrics-token"
+	cfg.Middleware.Groups["/api/admin"] = GroupPolicy{IPAllowlist: []string{"10.0.0.0/8"}}
+	return cfg
+}
+
+func TestValidateReleaseMetrics(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		ok     bool
+	}{
+		{"token", func(c *Config) {}, true},
+		{"no token", func(c *Config) { c.Metrics.Token = "" }, false},
+		{"disabled", func(c *Config) { c.Metrics.Token, c.Metrics.Enabled = "", false }, true},
+		{"allowlist", func(c *Config) {
+			c.Metrics.Token = ""
+			c.Middleware.Groups["/metrics"] = GroupPolicy{IPAllowlist: []string{"10.0.0.0/8"}}
+		}, true},
+		{"allowlist on another path", func(c *Config) {
+			c.Metrics.Token = ""
+			c.Middleware.Groups["/metricsx"] = GroupPolicy{IPAllowlist: []string{"10.0.0.0/8"}}
+		}, false},
+		{"debug mode", func(c *Config) { c.Metrics.Token, c.Server.Mode = "", "debug" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := releaseConfig()
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if tt.ok && err != nil {
+				t.Errorf("Validate() = %v, want nil", err)
+			}
+			if !tt.ok && (err == nil || !strings.Contains(err.Error(), "metrics.token")) {
+				t.Errorf("Validate() = %v, want a metrics.token error", err)
+			}
+		})
+	}
+}
+
+func TestValidateReleaseAdminAllowlist(t *testing.T) {
+	cfg := releaseConfig()
+	cfg.Middleware.Groups["/api/admin"] = GroupPolicy{}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "/api/admin.ip_allowlist") {
+		t.Errorf("Validate() = %v, want an admin allowlist error", err)
+	}
+
+	// *表示明确允许所有IP
+	cfg.Middleware.Groups["/api/admin"] = GroupPolicy{IPAllowlist: []string{"*"}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+
+	cfg.Server.Mode = "debug"
+	cfg.Middleware.Groups["/api/admin"] = GroupPolicy{}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() in debug mode = %v, want nil", err)
+	}
+}
+
+func TestDefaultTrustsOnlyLoopbackProxies(t *testing.T) {
+	for _, proxy := range Default("prod").Middleware.TrustedProxies {
+		if proxy != "127.0.0.1/8" && proxy != "::1/128" {
+			t.Errorf("default trusted proxy %q is not loopback", proxy)
+		}
+	}
+}
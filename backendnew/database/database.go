@@ -3,7 +3,6 @@ package database
 import (
 	"fmt"
 	"seldom-platform/config"
-	"seldom-platform/models"
 
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/sqlite"
@@ -13,7 +12,8 @@ import (
 
 var DB *gorm.DB
 
-func Init(cfg config.DatabaseConfig) (*gorm.DB, error) {
+// Open 连接数据库，不执行迁移
+func Open(cfg config.DatabaseConfig) (*gorm.DB, error) {
 	var err error
 	var dsn string
 
@@ -34,31 +34,35 @@ func Init(cfg config.DatabaseConfig) (*gorm.DB, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	registerTracing(DB, cfg.Driver)
+	return DB, nil
+}
 
-	// 自动迁移数据库表
-	err = autoMigrate()
+// Init 连接数据库并检查表结构，开启AutoMigrate时先执行未执行的迁移
+// 表结构与程序不一致时返回错误，服务拒绝启动
+func Init(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	db, err := Open(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
+		return nil, err
 	}
 
-	return DB, nil
-}
+	migrator, err := NewMigrator(db, cfg.Driver)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if cfg.AutoMigrate {
+		if _, err := migrator.Up(0); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
+	if err := migrator.Check(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%w (run `seldom-platform migrate status` for details)", err)
+	}
 
-func autoMigrate() error {
-	err := DB.AutoMigrate(
-		&models.Project{},
-		&models.Env{},
-		&models.TestCase{},
-		&models.TestCaseTemp{},
-		&models.CaseResult{},
-		&models.TestTask{},
-		&models.TaskCaseRelevance{},
-		&models.TaskReport{},
-		&models.ReportDetails{},
-		&models.Team{},
-		&models.User{},
-	).Error
-	return err
+	return db, nil
 }
 
 func Close(db *gorm.DB) {
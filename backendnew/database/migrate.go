@@ -0,0 +1,493 @@
+package database
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"seldom-platform/models"
+	"seldom-platform/utils"
+
+	"github.com/jinzhu/gorm"
+)
+
+// migrationFiles 各数据库方言的迁移文件，目录为 migrations/<driver>/<版本>_<名称>.up.sql 和 .down.sql
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migrationsTable 迁移历史表
+const migrationsTable = "schema_migrations"
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// migrationsTableDDL 各数据库方言的迁移历史表建表语句
+var migrationsTableDDL = map[string]string{
+	"sqlite3":  `CREATE TABLE IF NOT EXISTS "schema_migrations" ("version" bigint NOT NULL PRIMARY KEY,"name" varchar(255) NOT NULL,"checksum" varchar(64) NOT NULL,"applied_at" datetime NOT NULL)`,
+	"mysql":    "CREATE TABLE IF NOT EXISTS `schema_migrations` (`version` bigint unsigned NOT NULL,`name` varchar(255) NOT NULL,`checksum` varchar(64) NOT NULL,`applied_at` DATETIME NOT NULL, PRIMARY KEY (`version`))",
+	"postgres": `CREATE TABLE IF NOT EXISTS "schema_migrations" ("version" bigint NOT NULL PRIMARY KEY,"name" varchar(255) NOT NULL,"checksum" varchar(64) NOT NULL,"applied_at" timestamp with time zone NOT NULL)`,
+}
+
+// schemaModels 当前所有表对应的模型，用于检查表结构
+var schemaModels = []interface{}{
+	&models.Project{},
+	&models.Env{},
+	&models.TestCase{},
+	&models.TestCaseTemp{},
+	&models.CaseResult{},
+	&models.TestTask{},
+	&models.TaskCaseRelevance{},
+	&models.TaskEnvRelevance{},
+	&models.EnvVariable{},
+	&models.TaskReport{},
+	&models.ReportDetails{},
+	&models.Team{},
+	&models.User{},
+	&models.ProjectMember{},
+	&models.TeamMember{},
+	&models.RefreshToken{},
+	&models.RevokedToken{},
+	&models.LoginAttempt{},
+	&models.UserIdentity{},
+	&models.AuditLog{},
+	&models.Execution{},
+}
+
+var (
+	// 建表和建索引语句，标识符可能用双引号或反引号（\x60）括起
+	createTableRe = regexp.MustCompile(`(?is)^CREATE\s+TABLE\s+["\x60]?(\w+)["\x60]?\s*\((.*)\)\s*;?$`)
+	createIndexRe = regexp.MustCompile(`(?is)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+["\x60]?(\w+)["\x60]?\s+ON\s+["\x60]?(\w+)["\x60]?`)
+)
+
+// Migration 一个版本的数据库迁移
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string // 升级SQL
+	Down    string // 回滚SQL
+}
+
+// Checksum 升级SQL的摘要，用于发现已执行的迁移文件被修改
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up))
+	return hex.EncodeToString(sum[:])
+}
+
+// AppliedMigration 迁移历史记录
+type AppliedMigration struct {
+	Version   uint64    `gorm:"primary_key" json:"version"`
+	Name      string    `json:"name"`
+	Checksum  string    `json:"checksum"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// TableName 指定表名
+func (AppliedMigration) TableName() string {
+	return migrationsTable
+}
+
+// MigrationStatus 迁移状态
+type MigrationStatus struct {
+	Version   uint64
+	Name      string
+	AppliedAt *time.Time // 为空表示未执行
+	Modified  bool       // 执行后迁移文件被修改
+	Missing   bool       // 数据库中已执行但程序中不存在
+}
+
+// Migrator 数据库迁移器
+type Migrator struct {
+	db         *gorm.DB
+	driver     string
+	migrations []Migration
+}
+
+// NewMigrator 创建数据库迁移器，加载driver对应的迁移文件
+func NewMigrator(db *gorm.DB, driver string) (*Migrator, error) {
+	migrations, err := LoadMigrations(driver)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, driver: driver, migrations: migrations}, nil
+}
+
+// LoadMigrations 加载driver对应的迁移文件，按版本号升序排列，每个版本必须同时有up和down文件
+func LoadMigrations(driver string) ([]Migration, error) {
+	dir := path.Join("migrations", driver)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for database driver %s", driver)
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		match := migrationFileRe.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version: %s", entry.Name())
+		}
+		content, err := fs.ReadFile(migrationFiles, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has different names: %s, %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if strings.TrimSpace(migration.Up) == "" || strings.TrimSpace(migration.Down) == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down sql", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Status 返回所有迁移的执行状态，包括数据库中已执行但程序中不存在的版本
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.AppliedAt = &appliedAt
+			status.Modified = record.Checksum != migration.Checksum()
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range applied {
+		appliedAt := record.AppliedAt
+		statuses = append(statuses, MigrationStatus{Version: record.Version, Name: record.Name, AppliedAt: &appliedAt, Missing: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// Up 按顺序执行未执行的迁移，target为0时执行全部，否则执行到target版本（包含）
+// 数据库中已有表但没有迁移历史时（此前由AutoMigrate创建），先补齐缺少的列并把初始迁移记为已执行
+func (m *Migrator) Up(target uint64) ([]Migration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+	if err := m.adoptLegacy(); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range m.migrations {
+		if target != 0 && migration.Version > target {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err := m.run(migration, migration.Up, true); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down 按倒序回滚最近执行的steps个迁移
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if err := m.run(migration, migration.Down, false); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Check 检查数据库结构是否与程序一致：所有迁移都已执行、已执行的迁移文件没有被修改、
+// 数据库中没有程序不认识的迁移版本，并且模型对应的表和列都存在
+func (m *Migrator) Check() error {
+	statuses, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	for _, status := range statuses {
+		name := fmt.Sprintf("%04d_%s", status.Version, status.Name)
+		switch {
+		case status.Missing:
+			problems = append(problems, fmt.Sprintf("migration %s is applied but unknown to this build", name))
+		case status.AppliedAt == nil:
+			problems = append(problems, fmt.Sprintf("migration %s is pending", name))
+		case status.Modified:
+			problems = append(problems, fmt.Sprintf("migration %s was modified after it was applied", name))
+		}
+	}
+	if len(problems) == 0 {
+		problems = m.checkModels()
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("database schema drift detected: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// checkModels 检查模型对应的表和列是否存在
+func (m *Migrator) checkModels() []string {
+	var problems []string
+	dialect := m.db.Dialect()
+	for _, model := range schemaModels {
+		scope := m.db.NewScope(model)
+		table := scope.TableName()
+		if !dialect.HasTable(table) {
+			problems = append(problems, fmt.Sprintf("table %s is missing", table))
+			continue
+		}
+		for _, field := range scope.GetModelStruct().StructFields {
+			if field.IsIgnored || !field.IsNormal {
+				continue
+			}
+			if !dialect.HasColumn(table, field.DBName) {
+				problems = append(problems, fmt.Sprintf("column %s.%s is missing", table, field.DBName))
+			}
+		}
+	}
+	return problems
+}
+
+// ensureTable 创建迁移历史表
+func (m *Migrator) ensureTable() error {
+	ddl, ok := migrationsTableDDL[m.driver]
+	if !ok {
+		return fmt.Errorf("unsupported database driver: %s", m.driver)
+	}
+	return m.db.Exec(ddl).Error
+}
+
+// applied 返回已执行的迁移，迁移历史表不存在时返回空
+func (m *Migrator) applied() (map[uint64]AppliedMigration, error) {
+	result := make(map[uint64]AppliedMigration)
+	if !m.db.Dialect().HasTable(migrationsTable) {
+		return result, nil
+	}
+
+	var records []AppliedMigration
+	if err := m.db.Order("version ASC").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to read migration history: %w", err)
+	}
+	for _, record := range records {
+		result[record.Version] = record
+	}
+	return result, nil
+}
+
+// adoptLegacy 接管此前由AutoMigrate创建的数据库：按初始迁移的建表语句补齐缺少的表、列和索引，
+// 并把初始迁移记为已执行。已有的列保持不变，不会按当前的模型修改
+func (m *Migrator) adoptLegacy() error {
+	if len(m.migrations) == 0 {
+		return nil
+	}
+	applied, err := m.applied()
+	if err != nil || len(applied) > 0 {
+		return err
+	}
+
+	initial := m.migrations[0]
+	statements := splitStatements(initial.Up)
+	dialect := m.db.Dialect()
+	legacy := false
+	for _, statement := range statements {
+		if match := createTableRe.FindStringSubmatch(statement); match != nil && dialect.HasTable(match[1]) {
+			legacy = true
+			break
+		}
+	}
+	if !legacy {
+		return nil
+	}
+
+	utils.GetLogger().Info("Adopting existing database schema", "version", initial.Version, "name", initial.Name)
+	for _, statement := range statements {
+		if err := m.adoptStatement(statement); err != nil {
+			return fmt.Errorf("failed to upgrade existing tables: %w", err)
+		}
+	}
+	return m.db.Create(&AppliedMigration{
+		Version:   initial.Version,
+		Name:      initial.Name,
+		Checksum:  initial.Checksum(),
+		AppliedAt: time.Now(),
+	}).Error
+}
+
+// adoptStatement 执行初始迁移中数据库还没有的部分：表不存在时建表，表存在时添加缺少的列，索引不存在时建索引
+func (m *Migrator) adoptStatement(statement string) error {
+	dialect := m.db.Dialect()
+	if match := createTableRe.FindStringSubmatch(statement); match != nil {
+		table := match[1]
+		if !dialect.HasTable(table) {
+			return m.db.Exec(statement).Error
+		}
+		for _, definition := range splitColumns(match[2]) {
+			column := strings.Trim(strings.Fields(definition)[0], "\"`")
+			if isTableConstraint(definition) || dialect.HasColumn(table, column) {
+				continue
+			}
+			sql := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", dialect.Quote(table), definition)
+			if err := m.db.Exec(sql).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if match := createIndexRe.FindStringSubmatch(statement); match != nil {
+		if dialect.HasIndex(match[2], match[1]) {
+			return nil
+		}
+	}
+	return m.db.Exec(statement).Error
+}
+
+// splitColumns 按顶层的逗号拆分建表语句中的列定义
+func splitColumns(body string) []string {
+	var columns []string
+	depth, start := 0, 0
+	var quote rune
+	for i, r := range body {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			columns = append(columns, strings.TrimSpace(body[start:i]))
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(body[start:]); rest != "" {
+		columns = append(columns, rest)
+	}
+	return columns
+}
+
+// isTableConstraint 判断是否为表级约束，如 PRIMARY KEY (`id`)
+func isTableConstraint(definition string) bool {
+	upper := strings.ToUpper(definition)
+	for _, prefix := range []string{"PRIMARY KEY", "UNIQUE", "KEY ", "INDEX ", "CONSTRAINT "} {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// run 在事务中执行迁移SQL并更新迁移历史（MySQL的DDL语句会隐式提交，失败时需要手动处理）
+func (m *Migrator) run(migration Migration, sql string, up bool) error {
+	direction := "down"
+	if up {
+		direction = "up"
+	}
+	utils.GetLogger().Info("Migrating", "direction", direction, "version", migration.Version, "name", migration.Name)
+
+	tx := m.db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+	for _, statement := range splitStatements(sql) {
+		if err := tx.Exec(statement).Error; err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %04d_%s %s failed: %w", migration.Version, migration.Name, direction, err)
+		}
+	}
+
+	var err error
+	if up {
+		err = tx.Create(&AppliedMigration{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Checksum:  migration.Checksum(),
+			AppliedAt: time.Now(),
+		}).Error
+	} else {
+		err = tx.Where("version = ?", migration.Version).Delete(&AppliedMigration{}).Error
+	}
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to update migration history: %w", err)
+	}
+	return tx.Commit().Error
+}
+
+// splitStatements 按行尾的分号拆分SQL语句，忽略 -- 开头的注释行
+func splitStatements(sql string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
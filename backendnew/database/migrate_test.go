@@ -0,0 +1,108 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestUpCreatesSchema(t *testing.T) {
+	db := openTestDB(t)
+	migrator, err := NewMigrator(db, "sqlite3")
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+
+	done, err := migrator.Up(0)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if len(done) != len(migrator.migrations) {
+		t.Fatalf("applied %d migrations, want %d", len(done), len(migrator.migrations))
+	}
+	if err := migrator.Check(); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+}
+
+func TestUpAdoptsLegacySchema(t *testing.T) {
+	db := openTestDB(t)
+	// 此前AutoMigrate创建的表：缺少run_version列，没有后来新增的表和索引
+	legacy := []string{
+		`CREATE TABLE "app_project_project" ("id" integer primary key autoincrement,"name" varchar(50) NOT NULL,"address" varchar(200) NOT NULL,"case_dir" varchar(200) DEFAULT 'test_dir',"is_delete" bool DEFAULT false,"delete_time" datetime,"create_time" datetime,"update_time" datetime,"cover_name" varchar(64) DEFAULT '',"path_name" varchar(64) DEFAULT '',"test_num" integer DEFAULT 0,"is_clone" integer DEFAULT 0)`,
+		`CREATE TABLE "app_task_taskenvrelevance" ("id" integer primary key autoincrement,"task_id" integer NOT NULL,"env_id" integer NOT NULL,"create_time" datetime)`,
+		`INSERT INTO "app_project_project" ("name","address") VALUES ('demo','https://github.com/seldomQA/demo-tests.git')`,
+	}
+	for _, sql := range legacy {
+		if err := db.Exec(sql).Error; err != nil {
+			t.Fatalf("create legacy schema: %v", err)
+		}
+	}
+
+	migrator, err := NewMigrator(db, "sqlite3")
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	done, err := migrator.Up(0)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	// 初始迁移被接管，只执行之后的迁移
+	if len(done) != len(migrator.migrations)-1 || done[0].Version == 1 {
+		t.Fatalf("applied %v, want all but the initial migration", done)
+	}
+	if err := migrator.Check(); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	dialect := db.Dialect()
+	if !dialect.HasColumn("app_project_project", "run_version") {
+		t.Error("missing column app_project_project.run_version was not added")
+	}
+	if !dialect.HasTable("app_audit_log") {
+		t.Error("missing table app_audit_log was not created")
+	}
+	if !dialect.HasIndex("app_task_taskenvrelevance", "idx_task_env") {
+		t.Error("missing index idx_task_env was not created")
+	}
+
+	var count int
+	db.Table("app_project_project").Count(&count)
+	if count != 1 {
+		t.Errorf("existing rows = %d, want 1", count)
+	}
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if statuses[0].AppliedAt == nil || statuses[0].Modified {
+		t.Errorf("initial migration status = %+v, want applied and unmodified", statuses[0])
+	}
+}
+
+func TestSplitColumns(t *testing.T) {
+	columns := splitColumns("`id` int unsigned AUTO_INCREMENT,`app_info` varchar(1000) DEFAULT '{,}',`price` decimal(10,2), PRIMARY KEY (`id`)")
+	want := []string{"`id` int unsigned AUTO_INCREMENT", "`app_info` varchar(1000) DEFAULT '{,}'", "`price` decimal(10,2)", "PRIMARY KEY (`id`)"}
+	if len(columns) != len(want) {
+		t.Fatalf("splitColumns = %q, want %q", columns, want)
+	}
+	for i := range want {
+		if columns[i] != want[i] {
+			t.Errorf("column %d = %q, want %q", i, columns[i], want[i])
+		}
+	}
+	if !isTableConstraint(columns[3]) || isTableConstraint(columns[0]) {
+		t.Error("isTableConstraint did not tell the primary key from columns")
+	}
+}
@@ -0,0 +1,20 @@
+DROP TABLE IF EXISTS `app_audit_log`;
+DROP TABLE IF EXISTS `app_user_identity`;
+DROP TABLE IF EXISTS `app_user_loginattempt`;
+DROP TABLE IF EXISTS `app_user_revokedtoken`;
+DROP TABLE IF EXISTS `app_user_refreshtoken`;
+DROP TABLE IF EXISTS `app_team_member`;
+DROP TABLE IF EXISTS `app_project_member`;
+DROP TABLE IF EXISTS `auth_user`;
+DROP TABLE IF EXISTS `app_team_team`;
+DROP TABLE IF EXISTS `app_task_reportdetails`;
+DROP TABLE IF EXISTS `app_task_taskreport`;
+DROP TABLE IF EXISTS `app_project_envvariable`;
+DROP TABLE IF EXISTS `app_task_taskenvrelevance`;
+DROP TABLE IF EXISTS `app_task_taskcaserelevance`;
+DROP TABLE IF EXISTS `app_task_testtask`;
+DROP TABLE IF EXISTS `app_case_caseresult`;
+DROP TABLE IF EXISTS `app_case_testcasetemp`;
+DROP TABLE IF EXISTS `app_case_testcase`;
+DROP TABLE IF EXISTS `app_project_env`;
+DROP TABLE IF EXISTS `app_project_project`;
@@ -0,0 +1,61 @@
+-- 初始表结构，即引入版本化迁移时所有模型对应的表（包括此前AutoMigrate没有创建过的表）
+-- 接管此前由AutoMigrate创建的数据库时，按本文件补齐缺少的表、列和索引
+
+CREATE TABLE `app_project_project` (`id` int unsigned AUTO_INCREMENT,`name` varchar(50) NOT NULL,`address` varchar(200) NOT NULL,`case_dir` varchar(200) DEFAULT 'test_dir',`is_delete` boolean DEFAULT false,`delete_time` DATETIME NULL,`create_time` DATETIME NULL,`update_time` DATETIME NULL,`cover_name` varchar(64) DEFAULT '',`path_name` varchar(64) DEFAULT '',`test_num` int DEFAULT 0,`is_clone` int DEFAULT 0,`run_version` varchar(200) DEFAULT '', PRIMARY KEY (`id`));
+
+CREATE TABLE `app_project_env` (`id` int unsigned AUTO_INCREMENT,`name` varchar(50) NOT NULL,`project_id` int unsigned,`test_type` varchar(20) DEFAULT 'http',`env` varchar(50) DEFAULT '',`rerun` int DEFAULT 0,`is_clear_cache` boolean DEFAULT false,`browser` varchar(20) DEFAULT '',`base_url` varchar(200) DEFAULT '',`remote` varchar(200) DEFAULT '',`app_server` varchar(100) DEFAULT '',`app_info` varchar(1000) DEFAULT '{}',`is_delete` boolean DEFAULT false,`delete_time` DATETIME NULL,`create_time` DATETIME NULL,`update_time` DATETIME NULL, PRIMARY KEY (`id`));
+CREATE INDEX idx_app_project_env_project_id ON `app_project_env`(project_id);
+
+CREATE TABLE `app_case_testcase` (`id` int unsigned AUTO_INCREMENT,`project_id` int unsigned NOT NULL,`file_name` varchar(500) NOT NULL DEFAULT '',`class_name` varchar(200) NOT NULL DEFAULT '',`class_doc` text,`case_name` varchar(200) NOT NULL DEFAULT '',`case_doc` text,`label` text,`status` int DEFAULT 0,`case_hash` varchar(200) NOT NULL DEFAULT '',`create_time` DATETIME NULL,`update_time` DATETIME NULL, PRIMARY KEY (`id`));
+
+CREATE TABLE `app_case_testcasetemp` (`id` int unsigned AUTO_INCREMENT,`project_id` int unsigned NOT NULL,`file_name` varchar(500) NOT NULL DEFAULT '',`class_name` varchar(200) NOT NULL DEFAULT '',`class_doc` text,`case_name` varchar(200) NOT NULL DEFAULT '',`case_doc` text,`label` text,`case_hash` varchar(200) NOT NULL DEFAULT '',`create_time` DATETIME NULL, PRIMARY KEY (`id`));
+
+CREATE TABLE `app_case_caseresult` (`id` int unsigned AUTO_INCREMENT,`case_id` int unsigned NOT NULL,`name` varchar(100) NOT NULL DEFAULT '',`report` text,`passed` int DEFAULT 0,`error` int DEFAULT 0,`failure` int DEFAULT 0,`skipped` int DEFAULT 0,`tests` int DEFAULT 0,`system_out` text,`run_time` double DEFAULT 0,`create_time` DATETIME NULL, PRIMARY KEY (`id`));
+
+CREATE TABLE `app_task_testtask` (`id` int unsigned AUTO_INCREMENT,`project_id` int unsigned NOT NULL,`name` varchar(200) NOT NULL DEFAULT '',`status` int DEFAULT 0,`env_id` int unsigned,`team_id` int unsigned,`email` varchar(100),`timed` varchar(500) DEFAULT '',`is_scheduled` boolean DEFAULT false,`cron_expression` varchar(200) DEFAULT '',`execute_count` int DEFAULT 0,`is_delete` boolean DEFAULT false,`delete_time` DATETIME NULL,`create_time` DATETIME NULL,`update_time` DATETIME NULL, PRIMARY KEY (`id`));
+
+CREATE TABLE `app_task_taskcaserelevance` (`id` int unsigned AUTO_INCREMENT,`task_id` int unsigned NOT NULL,`case_hash` varchar(200) NOT NULL,`create_time` DATETIME NULL, PRIMARY KEY (`id`));
+
+CREATE TABLE `app_task_taskenvrelevance` (`id` int unsigned AUTO_INCREMENT,`task_id` int unsigned NOT NULL,`env_id` int unsigned NOT NULL,`create_time` DATETIME NULL, PRIMARY KEY (`id`));
+CREATE UNIQUE INDEX idx_task_env ON `app_task_taskenvrelevance`(task_id, env_id);
+
+CREATE TABLE `app_project_envvariable` (`id` int unsigned AUTO_INCREMENT,`env_id` int unsigned NOT NULL,`name` varchar(100) NOT NULL,`value` text,`is_secret` boolean DEFAULT false,`description` varchar(200) DEFAULT '',`create_time` DATETIME NULL,`update_time` DATETIME NULL, PRIMARY KEY (`id`));
+CREATE UNIQUE INDEX idx_env_name ON `app_project_envvariable`(env_id, `name`);
+
+CREATE TABLE `app_task_taskreport` (`id` int unsigned AUTO_INCREMENT,`task_id` int unsigned NOT NULL,`name` varchar(500) NOT NULL DEFAULT '',`run_id` varchar(32) DEFAULT '',`env_id` int unsigned,`env_name` varchar(50) DEFAULT '',`report` text,`passed` int DEFAULT 0,`error` int DEFAULT 0,`failure` int DEFAULT 0,`skipped` int DEFAULT 0,`tests` int DEFAULT 0,`run_time` varchar(100) DEFAULT '0',`create_time` DATETIME NULL, PRIMARY KEY (`id`));
+CREATE INDEX idx_app_task_taskreport_run_id ON `app_task_taskreport`(run_id);
+
+CREATE TABLE `app_task_reportdetails` (`id` int unsigned AUTO_INCREMENT,`result_id` int unsigned NOT NULL,`name` varchar(500) NOT NULL DEFAULT '',`class_name` varchar(200) NOT NULL DEFAULT '',`status` varchar(20) NOT NULL DEFAULT '',`time` varchar(100) NOT NULL DEFAULT '',`failure_message` text,`error_out` text,`skipped_message` text,`create_time` DATETIME NULL, PRIMARY KEY (`id`));
+
+CREATE TABLE `app_team_team` (`id` int unsigned AUTO_INCREMENT,`name` varchar(200) NOT NULL,`email` text,`is_delete` boolean DEFAULT false,`delete_time` DATETIME NULL,`create_time` DATETIME NULL,`update_time` DATETIME NULL, PRIMARY KEY (`id`));
+
+CREATE TABLE `auth_user` (`id` int unsigned AUTO_INCREMENT,`username` varchar(150) NOT NULL UNIQUE,`email` varchar(254),`first_name` varchar(150),`last_name` varchar(150),`password` varchar(128) NOT NULL,`is_staff` boolean DEFAULT false,`is_active` boolean DEFAULT true,`is_superuser` boolean DEFAULT false,`date_joined` DATETIME NULL,`last_login` DATETIME NULL, PRIMARY KEY (`id`));
+
+CREATE TABLE `app_project_member` (`id` int unsigned AUTO_INCREMENT,`project_id` int unsigned NOT NULL,`user_id` int unsigned NOT NULL,`role` varchar(20) NOT NULL DEFAULT 'viewer',`create_time` DATETIME NULL,`update_time` DATETIME NULL, PRIMARY KEY (`id`));
+CREATE UNIQUE INDEX idx_project_member ON `app_project_member`(project_id, user_id);
+
+CREATE TABLE `app_team_member` (`id` int unsigned AUTO_INCREMENT,`team_id` int unsigned NOT NULL,`user_id` int unsigned NOT NULL,`role` varchar(20) NOT NULL DEFAULT 'viewer',`create_time` DATETIME NULL,`update_time` DATETIME NULL, PRIMARY KEY (`id`));
+CREATE UNIQUE INDEX idx_team_member ON `app_team_member`(team_id, user_id);
+
+CREATE TABLE `app_user_refreshtoken` (`id` int unsigned AUTO_INCREMENT,`user_id` int unsigned NOT NULL,`session_id` varchar(64) NOT NULL,`token_hash` varchar(64) NOT NULL,`expires_at` DATETIME NOT NULL,`revoked_at` DATETIME NULL,`user_agent` varchar(255) DEFAULT '',`ip` varchar(64) DEFAULT '',`create_time` DATETIME NULL, PRIMARY KEY (`id`));
+CREATE INDEX idx_app_user_refreshtoken_session_id ON `app_user_refreshtoken`(session_id);
+CREATE INDEX idx_app_user_refreshtoken_user_id ON `app_user_refreshtoken`(user_id);
+CREATE UNIQUE INDEX uix_app_user_refreshtoken_token_hash ON `app_user_refreshtoken`(token_hash);
+
+CREATE TABLE `app_user_revokedtoken` (`id` int unsigned AUTO_INCREMENT,`jti` varchar(64) NOT NULL,`user_id` int unsigned NOT NULL,`expires_at` DATETIME NOT NULL, PRIMARY KEY (`id`));
+CREATE INDEX idx_app_user_revokedtoken_expires_at ON `app_user_revokedtoken`(expires_at);
+CREATE UNIQUE INDEX uix_app_user_revokedtoken_jti ON `app_user_revokedtoken`(`jti`);
+
+CREATE TABLE `app_user_loginattempt` (`id` int unsigned AUTO_INCREMENT,`user_id` int unsigned NOT NULL,`failed_count` int DEFAULT 0,`last_failed_at` DATETIME NULL,`locked_until` DATETIME NULL, PRIMARY KEY (`id`));
+CREATE UNIQUE INDEX uix_app_user_loginattempt_user_id ON `app_user_loginattempt`(user_id);
+
+CREATE TABLE `app_user_identity` (`id` int unsigned AUTO_INCREMENT,`user_id` int unsigned NOT NULL,`provider` varchar(20) NOT NULL,`subject` varchar(255) NOT NULL,`last_login` DATETIME NULL,`create_time` DATETIME NULL, PRIMARY KEY (`id`));
+CREATE INDEX idx_app_user_identity_user_id ON `app_user_identity`(user_id);
+CREATE UNIQUE INDEX idx_user_identity ON `app_user_identity`(`provider`, `subject`);
+
+CREATE TABLE `app_audit_log` (`id` int unsigned AUTO_INCREMENT,`actor_id` int unsigned,`actor_name` varchar(150) DEFAULT '',`action` varchar(32) NOT NULL,`resource_type` varchar(32) NOT NULL,`resource_id` int unsigned,`before` text,`after` text,`ip` varchar(64) DEFAULT '',`request_id` varchar(64) DEFAULT '',`create_time` DATETIME NULL, PRIMARY KEY (`id`));
+CREATE INDEX idx_app_audit_log_create_time ON `app_audit_log`(create_time);
+CREATE INDEX idx_app_audit_log_actor_id ON `app_audit_log`(actor_id);
+CREATE INDEX idx_app_audit_log_action ON `app_audit_log`(`action`);
+CREATE INDEX idx_audit_resource ON `app_audit_log`(resource_type, resource_id);
+CREATE INDEX idx_app_audit_log_request_id ON `app_audit_log`(request_id);
@@ -0,0 +1,5 @@
+-- 执行记录表，用于优雅退出和恢复异常中断的执行
+
+CREATE TABLE "app_task_execution" ("id" serial,"kind" varchar(10) NOT NULL,"target_id" integer NOT NULL,"run_id" varchar(32) DEFAULT '',"env_id" integer,"owner" varchar(100) NOT NULL DEFAULT '',"status" varchar(20) NOT NULL DEFAULT 'running',"requeued" boolean DEFAULT false,"heartbeat_at" timestamp with time zone,"start_time" timestamp with time zone,"end_time" timestamp with time zone, PRIMARY KEY ("id"));
+CREATE INDEX idx_execution_target ON "app_task_execution"("kind", target_id);
+CREATE INDEX idx_app_task_execution_status ON "app_task_execution"("status");
@@ -0,0 +1,3 @@
+-- 邮箱验证令牌版本，验证邮箱和管理员启用、禁用账号时递增，使旧的验证链接失效
+
+ALTER TABLE "auth_user" ADD COLUMN "verify_version" integer NOT NULL DEFAULT 0;
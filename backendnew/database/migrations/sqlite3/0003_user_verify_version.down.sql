@@ -0,0 +1,6 @@
+-- SQLite不支持删除列，按初始表结构重建auth_user表
+
+CREATE TABLE "auth_user_new" ("id" integer primary key autoincrement,"username" varchar(150) NOT NULL UNIQUE,"email" varchar(254),"first_name" varchar(150),"last_name" varchar(150),"password" varchar(128) NOT NULL,"is_staff" bool DEFAULT false,"is_active" bool DEFAULT true,"is_superuser" bool DEFAULT false,"date_joined" datetime,"last_login" datetime);
+INSERT INTO "auth_user_new" ("id","username","email","first_name","last_name","password","is_staff","is_active","is_superuser","date_joined","last_login") SELECT "id","username","email","first_name","last_name","password","is_staff","is_active","is_superuser","date_joined","last_login" FROM "auth_user";
+DROP TABLE "auth_user";
+ALTER TABLE "auth_user_new" RENAME TO "auth_user";
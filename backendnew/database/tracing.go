@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+
+	"seldom-platform/tracing"
+
+	"github.com/jinzhu/gorm"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracingContextKey = "tracing:context"
+	tracingSpanKey    = "tracing:span"
+)
+
+// WithContext 返回携带追踪上下文的数据库连接，通过它执行的查询记录为ctx中span的子span
+func WithContext(ctx context.Context) *gorm.DB {
+	return GetDB().Set(tracingContextKey, ctx)
+}
+
+// registerTracing 注册记录查询span的回调，只记录通过WithContext传入了span的查询
+func registerTracing(db *gorm.DB, driver string) {
+	// gorm注册回调时会逐条打印日志，注册期间关闭，之后恢复为gorm的默认日志
+	db.SetLogger(gorm.Logger{LogWriter: log.New(io.Discard, "", 0)})
+	defer db.SetLogger(gorm.Logger{LogWriter: log.New(os.Stdout, "\r\n", 0)})
+
+	callbacks := db.Callback()
+	callbacks.Create().Before("gorm:create").Register("tracing:before_create", startSpan("create", driver))
+	callbacks.Create().After("gorm:create").Register("tracing:after_create", endSpan)
+	callbacks.Query().Before("gorm:query").Register("tracing:before_query", startSpan("query", driver))
+	callbacks.Query().After("gorm:query").Register("tracing:after_query", endSpan)
+	callbacks.Update().Before("gorm:update").Register("tracing:before_update", startSpan("update", driver))
+	callbacks.Update().After("gorm:update").Register("tracing:after_update", endSpan)
+	callbacks.Delete().Before("gorm:delete").Register("tracing:before_delete", startSpan("delete", driver))
+	callbacks.Delete().After("gorm:delete").Register("tracing:after_delete", endSpan)
+	callbacks.RowQuery().Before("gorm:row_query").Register("tracing:before_row_query", startSpan("row_query", driver))
+	callbacks.RowQuery().After("gorm:row_query").Register("tracing:after_row_query", endSpan)
+}
+
+func startSpan(operation, driver string) func(scope *gorm.Scope) {
+	return func(scope *gorm.Scope) {
+		value, ok := scope.Get(tracingContextKey)
+		if !ok {
+			return
+		}
+		ctx, ok := value.(context.Context)
+		if !ok || !trace.SpanContextFromContext(ctx).IsValid() {
+			return
+		}
+
+		table := scope.TableName()
+		_, span := tracing.Tracer().Start(ctx, "gorm."+operation+" "+table,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", driver),
+				attribute.String("db.operation", operation),
+				attribute.String("db.sql.table", table),
+			))
+		scope.InstanceSet(tracingSpanKey, span)
+	}
+}
+
+func endSpan(scope *gorm.Scope) {
+	value, ok := scope.InstanceGet(tracingSpanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("db.statement", scope.SQL),
+		attribute.Int64("db.rows_affected", scope.DB().RowsAffected),
+	)
+	if err := scope.DB().Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"errors"
+	"seldom-platform/config"
+	"seldom-platform/database"
+	"seldom-platform/middleware"
+	"seldom-platform/models"
+	"seldom-platform/services"
+	"seldom-platform/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler 用户管理处理器，仅超级用户可用
+type AdminHandler struct {
+	userService  *services.UserService
+	auditService *services.AuditService
+}
+
+// NewAdminHandler 创建用户管理处理器
+func NewAdminHandler(cfg *config.Config) *AdminHandler {
+	return &AdminHandler{
+		userService:  services.NewUserService(cfg),
+		auditService: services.NewAuditService(),
+	}
+}
+
+// AdminCreateUserRequest 创建用户请求结构
+type AdminCreateUserRequest struct {
+	Username    string `json:"username" binding:"required"`
+	Password    string `json:"password" binding:"required"`
+	Email       string `json:"email"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	IsStaff     bool   `json:"is_staff"`
+	IsSuperuser bool   `json:"is_superuser"`
+}
+
+// SetStaffRequest 设置员工身份请求结构
+type SetStaffRequest struct {
+	IsStaff bool `json:"is_staff"`
+}
+
+// AdminResetPasswordRequest 重置用户密码请求结构
+type AdminResetPasswordRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// GetUsers 获取用户列表
+// @Summary 获取用户列表
+// @Description 获取用户列表，支持按用户名、邮箱、姓名搜索和按状态筛选
+// @Tags 用户管理
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param size query int false "每页数量" default(10)
+// @Param search query string false "搜索关键字"
+// @Param is_active query bool false "是否激活"
+// @Param is_staff query bool false "是否为员工"
+// @Success 200 {object} utils.PageResponse{data=[]models.User}
+// @Failure 403 {object} utils.Response
+// @Router /api/admin/users [get]
+func (h *AdminHandler) GetUsers(c *gin.Context) {
+	db := database.GetDB()
+
+	// 获取分页参数
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "10"))
+	search := c.Query("search")
+
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 10
+	}
+
+	offset := (page - 1) * size
+
+	// 构建查询
+	query := db.Model(&models.User{})
+	if search != "" {
+		keyword := "%" + search + "%"
+		query = query.Where("username LIKE ? OR email LIKE ? OR first_name LIKE ? OR last_name LIKE ?",
+			keyword, keyword, keyword, keyword)
+	}
+	if value, err := strconv.ParseBool(c.Query("is_active")); err == nil {
+		query = query.Where("is_active = ?", value)
+	}
+	if value, err := strconv.ParseBool(c.Query("is_staff")); err == nil {
+		query = query.Where("is_staff = ?", value)
+	}
+
+	// 获取总数
+	var total int64
+	query.Count(&total)
+
+	// 获取数据
+	var users []models.User
+	if err := query.Order("id ASC").Offset(offset).Limit(size).Find(&users).Error; err != nil {
+		utils.InternalServerError(c, "Failed to fetch users")
+		return
+	}
+
+	utils.PageSuccess(c, users, total, page, size)
+}
+
+// GetUser 获取用户详情
+// @Summary 获取用户详情
+// @Description 根据ID获取用户详情
+// @Tags 用户管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Success 200 {object} utils.Response{data=models.User}
+// @Failure 404 {object} utils.Response
+// @Router /api/admin/users/{id} [get]
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	user, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+
+	utils.Success(c, user)
+}
+
+// CreateUser 创建用户
+// @Summary 创建用户
+// @Description 创建已激活的用户，可同时设置员工和超级用户身份
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user body AdminCreateUserRequest true "用户信息"
+// @Success 200 {object} utils.Response{data=models.User}
+// @Failure 400 {object} utils.Response
+// @Router /api/admin/users [post]
+func (h *AdminHandler) CreateUser(c *gin.Context) {
+	var req AdminCreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request format")
+		return
+	}
+
+	user, err := h.userService.CreateUser(services.CreateUserInput{
+		Username:    req.Username,
+		Password:    req.Password,
+		Email:       req.Email,
+		FirstName:   req.FirstName,
+		LastName:    req.LastName,
+		IsStaff:     req.IsStaff,
+		IsSuperuser: req.IsSuperuser,
+	})
+	if err != nil {
+		respondUserError(c, err, "Failed to create user")
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionCreate, models.AuditResourceUser, user.ID), nil, user)
+	utils.SuccessWithMessage(c, "User created successfully", user)
+}
+
+// ActivateUser 启用用户
+// @Summary 启用用户
+// @Description 重新启用被禁用的用户
+// @Tags 用户管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Success 200 {object} utils.Response{data=models.User}
+// @Failure 404 {object} utils.Response
+// @Router /api/admin/users/{id}/activate [post]
+func (h *AdminHandler) ActivateUser(c *gin.Context) {
+	user, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+
+	before := *user
+	if err := h.userService.SetActive(user, true); err != nil {
+		utils.InternalServerError(c, "Failed to activate user")
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionUpdate, models.AuditResourceUser, user.ID), before, user)
+	utils.SuccessWithMessage(c, "User activated successfully", user)
+}
+
+// DeactivateUser 禁用用户
+// @Summary 禁用用户
+// @Description 禁用用户，用户的所有会话立即失效，不能禁用自己
+// @Tags 用户管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Success 200 {object} utils.Response{data=models.User}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/admin/users/{id}/deactivate [post]
+func (h *AdminHandler) DeactivateUser(c *gin.Context) {
+	user, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+
+	if currentID, _ := middleware.CurrentUserID(c); currentID == user.ID {
+		utils.BadRequest(c, "Cannot deactivate yourself")
+		return
+	}
+
+	before := *user
+	if err := h.userService.SetActive(user, false); err != nil {
+		utils.InternalServerError(c, "Failed to deactivate user")
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionUpdate, models.AuditResourceUser, user.ID), before, user)
+	utils.SuccessWithMessage(c, "User deactivated successfully", user)
+}
+
+// SetStaff 设置员工身份
+// @Summary 设置员工身份
+// @Description 将用户设为员工或取消员工身份，员工可以创建项目、团队和环境
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Param staff body SetStaffRequest true "员工身份"
+// @Success 200 {object} utils.Response{data=models.User}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/admin/users/{id}/staff [put]
+func (h *AdminHandler) SetStaff(c *gin.Context) {
+	var req SetStaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request format")
+		return
+	}
+
+	user, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+
+	before := *user
+	if err := h.userService.SetStaff(user, req.IsStaff); err != nil {
+		utils.InternalServerError(c, "Failed to update user")
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionUpdate, models.AuditResourceUser, user.ID), before, user)
+	utils.SuccessWithMessage(c, "User updated successfully", user)
+}
+
+// ResetUserPassword 重置用户密码
+// @Summary 重置用户密码
+// @Description 为用户设置新密码，并吊销该用户的所有会话
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Param password body AdminResetPasswordRequest true "新密码"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/admin/users/{id}/password [post]
+func (h *AdminHandler) ResetUserPassword(c *gin.Context) {
+	var req AdminResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request format")
+		return
+	}
+
+	user, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.ResetPassword(user, req.Password); err != nil {
+		respondUserError(c, err, "Failed to reset password")
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionResetPassword, models.AuditResourceUser, user.ID), nil, nil)
+	utils.GetLogger().Ctx(c.Request.Context()).Auth("admin_password_reset", user.Username, c.ClientIP(), true)
+	utils.SuccessWithMessage(c, "Password reset successfully", nil)
+}
+
+// ForceLogout 强制用户下线
+// @Summary 强制用户下线
+// @Description 吊销用户的所有会话
+// @Tags 用户管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Success 200 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/admin/users/{id}/logout [post]
+func (h *AdminHandler) ForceLogout(c *gin.Context) {
+	user, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.ForceLogout(user); err != nil {
+		utils.InternalServerError(c, "Failed to logout user")
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionLogout, models.AuditResourceUser, user.ID), nil, nil)
+	utils.GetLogger().Ctx(c.Request.Context()).Auth("admin_logout", user.Username, c.ClientIP(), true)
+	utils.SuccessWithMessage(c, "User logged out successfully", nil)
+}
+
+// findUser 根据路径参数查找用户，不存在时返回404
+func (h *AdminHandler) findUser(c *gin.Context) (*models.User, bool) {
+	var user models.User
+	if err := database.GetDB().First(&user, c.Param("id")).Error; err != nil {
+		utils.NotFound(c, "User not found")
+		return nil, false
+	}
+	return &user, true
+}
+
+// respondUserError 校验错误返回400，其他错误返回500
+func respondUserError(c *gin.Context, err error, message string) {
+	var validationErr *services.ValidationError
+	if errors.As(err, &validationErr) {
+		utils.BadRequest(c, validationErr.Message)
+		return
+	}
+	utils.InternalServerError(c, message)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"seldom-platform/database"
+	"seldom-platform/middleware"
+	"seldom-platform/models"
+	"seldom-platform/services"
+	"seldom-platform/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditHandler 审计日志处理器
+type AuditHandler struct{}
+
+// NewAuditHandler 创建审计日志处理器
+func NewAuditHandler() *AuditHandler {
+	return &AuditHandler{}
+}
+
+// GetAuditLogs 查询审计日志
+// @Summary 查询审计日志
+// @Description 按操作人、操作类型、资源、请求ID和时间范围查询审计日志，按时间倒序排列
+// @Tags 审计日志
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param size query int false "每页数量" default(20)
+// @Param actor query string false "操作人用户名"
+// @Param actor_id query int false "操作人ID"
+// @Param action query string false "操作类型，如create、update、delete"
+// @Param resource_type query string false "资源类型，如project、env、task"
+// @Param resource_id query int false "资源ID"
+// @Param request_id query string false "请求ID"
+// @Param start query string false "开始时间，RFC3339或YYYY-MM-DD"
+// @Param end query string false "结束时间，RFC3339或YYYY-MM-DD（包含当天）"
+// @Success 200 {object} utils.PageResponse{data=[]models.AuditLog}
+// @Failure 400 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Router /api/admin/audit [get]
+func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
+	db := database.GetDB()
+
+	// 获取分页参数
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+
+	offset := (page - 1) * size
+
+	// 构建查询
+	query := db.Model(&models.AuditLog{})
+	if actor := c.Query("actor"); actor != "" {
+		query = query.Where("actor_name = ?", actor)
+	}
+	if actorID := c.Query("actor_id"); actorID != "" {
+		query = query.Where("actor_id = ?", actorID)
+	}
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if resourceType := c.Query("resource_type"); resourceType != "" {
+		query = query.Where("resource_type = ?", resourceType)
+	}
+	if resourceID := c.Query("resource_id"); resourceID != "" {
+		query = query.Where("resource_id = ?", resourceID)
+	}
+	if requestID := c.Query("request_id"); requestID != "" {
+		query = query.Where("request_id = ?", requestID)
+	}
+	if value := c.Query("start"); value != "" {
+		start, _, err := parseAuditTime(value)
+		if err != nil {
+			utils.BadRequest(c, "Invalid start time")
+			return
+		}
+		query = query.Where("create_time >= ?", start)
+	}
+	if value := c.Query("end"); value != "" {
+		end, dateOnly, err := parseAuditTime(value)
+		if err != nil {
+			utils.BadRequest(c, "Invalid end time")
+			return
+		}
+		if dateOnly {
+			end = end.AddDate(0, 0, 1)
+			query = query.Where("create_time < ?", end)
+		} else {
+			query = query.Where("create_time <= ?", end)
+		}
+	}
+
+	// 获取总数
+	var total int64
+	query.Count(&total)
+
+	// 获取数据
+	var logs []models.AuditLog
+	if err := query.Order("id DESC").Offset(offset).Limit(size).Find(&logs).Error; err != nil {
+		utils.InternalServerError(c, "Failed to fetch audit logs")
+		return
+	}
+
+	utils.PageSuccess(c, logs, total, page, size)
+}
+
+// parseAuditTime 解析RFC3339时间或YYYY-MM-DD日期，第二个返回值表示是否只有日期
+func parseAuditTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	return t, true, err
+}
+
+// auditEntry 根据当前请求生成审计记录的操作人、IP和请求ID
+func auditEntry(c *gin.Context, action, resourceType string, resourceID uint) services.AuditEntry {
+	entry := services.AuditEntry{
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		IP:           c.ClientIP(),
+		RequestID:    c.GetString(middleware.RequestIDKey),
+	}
+	if claims := middleware.CurrentClaims(c); claims != nil {
+		entry.ActorID = claims.UserID
+		entry.ActorName = claims.Username
+	}
+	return entry
+}
@@ -0,0 +1,57 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"seldom-platform/config"
+	"seldom-platform/models"
+	"seldom-platform/routes"
+)
+
+// TestAuditLogsFollowAdminAllowlist 审计日志和用户管理一样受/api/admin的IP白名单限制
+func TestAuditLogsFollowAdminAllowlist(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Middleware.Groups["/api/admin"] = config.GroupPolicy{IPAllowlist: []string{"10.0.0.0/8"}}
+	db := setupTestDB(t, cfg)
+	engine := routes.Setup(cfg)
+
+	admin := models.User{Username: "root", IsActive: true, IsStaff: true, IsSuperuser: true}
+	if err := admin.SetPassword("root-pass-123"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&admin).Error; err != nil {
+		t.Fatal(err)
+	}
+	w := postJSON(t, engine, "/api/auth/login", "", map[string]string{"username": "root", "password": "root-pass-123"})
+	var login struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &login); err != nil || login.Data.Token == "" {
+		t.Fatalf("login: status = %d, body = %s", w.Code, w.Body)
+	}
+
+	tests := []struct {
+		path       string
+		remoteAddr string
+		want       int
+	}{
+		{"/api/admin/audit", "10.1.2.3:40000", http.StatusOK},
+		{"/api/admin/audit", "192.0.2.1:40000", http.StatusForbidden},
+		{"/api/audit", "10.1.2.3:40000", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.RemoteAddr = tt.remoteAddr
+		req.Header.Set("Authorization", "Bearer "+login.Data.Token)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("GET %s from %s: status = %d, body = %s, want %d", tt.path, tt.remoteAddr, w.Code, w.Body, tt.want)
+		}
+	}
+}
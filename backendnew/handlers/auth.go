@@ -1,23 +1,37 @@
 package handlers
 
 import (
+	"errors"
+	"net/http"
 	"seldom-platform/config"
 	"seldom-platform/database"
+	"seldom-platform/middleware"
 	"seldom-platform/models"
+	"seldom-platform/services"
 	"seldom-platform/utils"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
 )
 
 // AuthHandler 认证处理器
 type AuthHandler struct {
-	config *config.Config
+	config       *config.Config
+	authService  *services.AuthService
+	ldapService  *services.LDAPService
+	auditService *services.AuditService
 }
 
 // NewAuthHandler 创建认证处理器
 func NewAuthHandler(cfg *config.Config) *AuthHandler {
-	return &AuthHandler{config: cfg}
+	return &AuthHandler{
+		config:       cfg,
+		authService:  services.NewAuthService(cfg),
+		ldapService:  services.NewLDAPService(cfg),
+		auditService: services.NewAuditService(),
+	}
 }
 
 // LoginRequest 登录请求结构
@@ -35,15 +49,49 @@ type RegisterRequest struct {
 	LastName  string `json:"last_name"`
 }
 
+// UpdateProfileRequest 更新用户信息请求结构
+type UpdateProfileRequest struct {
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+// ForgotPasswordRequest 忘记密码请求结构
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// ResetPasswordRequest 重置密码请求结构
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// VerifyEmailRequest 邮箱验证请求结构
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ChangePasswordRequest 修改密码请求结构
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// RefreshRequest 刷新令牌请求结构
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
 // LoginResponse 登录响应结构
 type LoginResponse struct {
-	Token string      `json:"token"`
-	User  models.User `json:"user"`
+	services.TokenPair
+	User models.User `json:"user"`
 }
 
 // Login 用户登录
 // @Summary 用户登录
-// @Description 用户登录接口
+// @Description 用户登录接口，配置LDAP后目录服务账号通过LDAP校验，本地账号仍使用本地密码；连续登录失败达到上限后账号会被临时锁定
 // @Tags 认证
 // @Accept json
 // @Produce json
@@ -51,6 +99,10 @@ type LoginResponse struct {
 // @Success 200 {object} utils.Response{data=LoginResponse}
 // @Failure 400 {object} utils.Response
 // @Failure 401 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 423 {object} utils.Response
+// @Failure 429 {object} utils.Response
+// @Failure 503 {object} utils.Response
 // @Router /api/auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
@@ -59,25 +111,89 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	resp, loginErr := h.authenticate(c, req)
+	if loginErr != nil {
+		if loginErr.lockedUntil != nil {
+			accountLocked(c, *loginErr.lockedUntil)
+			return
+		}
+		utils.Error(c, loginErr.status, loginErr.message)
+		return
+	}
+
+	utils.Success(c, resp)
+}
+
+// authError 登录或注册失败原因，lockedUntil不为空表示账号被临时锁定
+type authError struct {
+	status      int
+	message     string
+	lockedUntil *time.Time
+}
+
+// authenticate 校验用户名密码并签发令牌，供REST和Django兼容登录接口共用
+func (h *AuthHandler) authenticate(c *gin.Context, req LoginRequest) (*LoginResponse, *authError) {
 	db := database.GetDB()
 	var user models.User
+	ip := c.ClientIP()
+	invalid := &authError{status: http.StatusUnauthorized, message: "Invalid username or password"}
+
+	// 查找用户，启用目录服务时，不存在的用户和目录服务账号通过LDAP校验，其余本地账号使用本地密码
+	found := db.Where("username = ?", req.Username).First(&user).Error == nil
+	useLDAP := h.ldapService.Enabled() && (!found || h.ldapService.IsDirectoryUser(&user))
+	if !found && !useLDAP {
+		utils.GetLogger().Ctx(c.Request.Context()).Auth("login", req.Username, ip, false)
+		return nil, invalid
+	}
 
-	// 查找用户
-	if err := db.Where("username = ?", req.Username).First(&user).Error; err != nil {
-		utils.Unauthorized(c, "Invalid username or password")
-		return
+	// 检查账号是否被锁定
+	if found {
+		if lockedUntil := h.authService.LockedUntil(&user, ip); lockedUntil != nil {
+			utils.GetLogger().Ctx(c.Request.Context()).Auth("login", user.Username, ip, false)
+			return nil, &authError{lockedUntil: lockedUntil}
+		}
 	}
 
-	// 验证密码
-	if !user.CheckPassword(req.Password) {
-		utils.Unauthorized(c, "Invalid username or password")
-		return
+	// 验证密码，连续失败达到上限时锁定账号
+	authenticated := false
+	if useLDAP {
+		ldapUser, err := h.ldapService.Authenticate(req.Username, req.Password)
+		switch {
+		case err == nil:
+			user, found, authenticated = *ldapUser, true, true
+		case errors.Is(err, services.ErrIdentityConflict):
+			utils.GetLogger().Ctx(c.Request.Context()).Auth("login", req.Username, ip, false)
+			return nil, &authError{status: http.StatusConflict, message: "Username is already used by a local account"}
+		case !errors.Is(err, services.ErrInvalidCredentials):
+			utils.GetLogger().Ctx(c.Request.Context()).Error("LDAP login failed", "username", req.Username, "error", err)
+			utils.GetLogger().Ctx(c.Request.Context()).Auth("login", req.Username, ip, false)
+			return nil, &authError{status: http.StatusServiceUnavailable, message: "Directory service is unavailable"}
+		}
+	} else {
+		authenticated = user.CheckPassword(req.Password)
+	}
+	if !authenticated {
+		utils.GetLogger().Ctx(c.Request.Context()).Auth("login", req.Username, ip, false)
+		if found {
+			if lockedUntil := h.authService.RecordLoginFailure(&user, ip); lockedUntil != nil {
+				return nil, &authError{lockedUntil: lockedUntil}
+			}
+		}
+		return nil, invalid
 	}
+	h.authService.ResetLoginFailures(&user)
 
 	// 检查用户是否激活
 	if !user.IsActive {
-		utils.Unauthorized(c, "User account is disabled")
-		return
+		utils.GetLogger().Ctx(c.Request.Context()).Auth("login", user.Username, ip, false)
+		return nil, &authError{status: http.StatusUnauthorized, message: "User account is disabled"}
+	}
+
+	// 密码算法或参数过时时重新加密
+	if !useLDAP && user.PasswordNeedsUpgrade() {
+		if err := user.SetPassword(req.Password); err != nil {
+			utils.GetLogger().Ctx(c.Request.Context()).Error("Failed to upgrade password hash", "username", user.Username, "error", err)
+		}
 	}
 
 	// 更新最后登录时间
@@ -85,28 +201,90 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	user.LastLogin = &now
 	db.Save(&user)
 
-	// 生成JWT token
-	token, err := utils.GenerateJWT(user.ID, user.Username, h.config.JWT.Secret, h.config.JWT.Expire)
+	// 签发access token和refresh token
+	tokens, err := h.authService.IssueTokens(&user, clientInfo(c))
 	if err != nil {
-		utils.InternalServerError(c, "Failed to generate token")
+		return nil, &authError{status: http.StatusInternalServerError, message: "Failed to generate token"}
+	}
+
+	utils.GetLogger().Ctx(c.Request.Context()).Auth("login", user.Username, ip, true)
+	return &LoginResponse{
+		TokenPair: *tokens,
+		User:      user,
+	}, nil
+}
+
+// Refresh 刷新令牌
+// @Summary 刷新令牌
+// @Description 使用refresh token换取新的access token和refresh token，旧的refresh token随即失效
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param refresh body RefreshRequest true "刷新令牌"
+// @Success 200 {object} utils.Response{data=LoginResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Router /api/auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request format")
+		return
+	}
+
+	tokens, user, err := h.authService.RefreshTokens(req.RefreshToken, clientInfo(c))
+	if err != nil {
+		switch err {
+		case services.ErrUserDisabled:
+			utils.Unauthorized(c, "User account is disabled")
+		case services.ErrInvalidRefreshToken, services.ErrRefreshTokenReused:
+			utils.Unauthorized(c, "Invalid refresh token")
+		default:
+			utils.InternalServerError(c, "Failed to refresh token")
+		}
 		return
 	}
 
 	utils.Success(c, LoginResponse{
-		Token: token,
-		User:  user,
+		TokenPair: *tokens,
+		User:      *user,
 	})
 }
 
+// Logout 退出登录
+// @Summary 退出登录
+// @Description 吊销当前access token及其会话下的refresh token
+// @Tags 认证
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Router /api/auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	claims := middleware.CurrentClaims(c)
+	if claims == nil {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.authService.Logout(claims); err != nil {
+		utils.InternalServerError(c, "Failed to logout")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Logged out successfully", nil)
+}
+
 // Register 用户注册
 // @Summary 用户注册
-// @Description 用户注册接口
+// @Description 用户注册接口，密码需符合密码策略；开启邮箱验证时账号在验证邮箱后才会激活
 // @Tags 认证
 // @Accept json
 // @Produce json
 // @Param register body RegisterRequest true "注册信息"
 // @Success 200 {object} utils.Response{data=models.User}
 // @Failure 400 {object} utils.Response
+// @Failure 429 {object} utils.Response
 // @Router /api/auth/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
@@ -115,37 +293,75 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	db := database.GetDB()
+	user, authErr := h.register(c, req)
+	if authErr != nil {
+		utils.Error(c, authErr.status, authErr.message)
+		return
+	}
 
-	// 检查用户名是否已存在
-	var existingUser models.User
-	if err := db.Where("username = ?", req.Username).First(&existingUser).Error; err == nil {
-		utils.BadRequest(c, "Username already exists")
+	// 验证邮箱后才激活账号
+	if !user.IsActive {
+		utils.SuccessWithMessage(c, "User registered successfully, please check your email to activate the account", user)
 		return
 	}
 
-	// 创建新用户
+	utils.SuccessWithMessage(c, "User registered successfully", user)
+}
+
+// register 校验密码策略并创建用户，开启邮箱验证时用户在验证邮箱后才激活
+func (h *AuthHandler) register(c *gin.Context, req RegisterRequest) (*models.User, *authError) {
+	db := database.GetDB()
+
+	// 校验密码策略
+	if err := h.authService.ValidatePassword(req.Password, req.Username); err != nil {
+		return nil, &authError{status: http.StatusBadRequest, message: err.Error()}
+	}
+
+	// 需要验证邮箱时邮箱必填
+	verify := h.config.Current().Security.EmailVerification
+	if verify && (req.Email == "" || !utils.IsValidEmail(req.Email)) {
+		return nil, &authError{status: http.StatusBadRequest, message: "A valid email is required"}
+	}
+
+	// 创建新用户，需要验证邮箱时直接创建为未激活状态
 	user := models.User{
 		Username:  req.Username,
 		Email:     req.Email,
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
-		IsActive:  true,
+		IsActive:  !verify,
 	}
 
 	// 设置密码
 	if err := user.SetPassword(req.Password); err != nil {
-		utils.InternalServerError(c, "Failed to encrypt password")
-		return
+		return nil, &authError{status: http.StatusInternalServerError, message: "Failed to encrypt password"}
 	}
 
-	// 保存用户
-	if err := db.Create(&user).Error; err != nil {
-		utils.InternalServerError(c, "Failed to create user")
-		return
+	// 检查用户名是否已存在并保存用户
+	exists := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var count int
+		if err := tx.Model(&models.User{}).Where("username = ?", req.Username).Count(&count).Error; err != nil {
+			return err
+		}
+		if exists = count > 0; exists {
+			return nil
+		}
+		return tx.Create(&user).Error
+	})
+	if exists {
+		return nil, &authError{status: http.StatusBadRequest, message: "Username already exists"}
+	}
+	if err != nil {
+		return nil, &authError{status: http.StatusInternalServerError, message: "Failed to create user"}
 	}
 
-	utils.SuccessWithMessage(c, "User registered successfully", user)
+	utils.GetLogger().Ctx(c.Request.Context()).Auth("register", user.Username, c.ClientIP(), true)
+
+	if verify {
+		h.authService.SendVerificationMail(&user)
+	}
+	return &user, nil
 }
 
 // GetProfile 获取用户信息
@@ -177,12 +393,12 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 
 // UpdateProfile 更新用户信息
 // @Summary 更新用户信息
-// @Description 更新当前登录用户的信息
+// @Description 更新当前登录用户的信息，修改密码请使用修改密码接口
 // @Tags 认证
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param profile body RegisterRequest true "用户信息"
+// @Param profile body UpdateProfileRequest true "用户信息"
 // @Success 200 {object} utils.Response{data=models.User}
 // @Failure 400 {object} utils.Response
 // @Failure 401 {object} utils.Response
@@ -194,7 +410,7 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	var req RegisterRequest
+	var req UpdateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.BadRequest(c, "Invalid request format")
 		return
@@ -207,24 +423,196 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 		utils.NotFound(c, "User not found")
 		return
 	}
+	before := user
 
 	// 更新用户信息
 	user.Email = req.Email
 	user.FirstName = req.FirstName
 	user.LastName = req.LastName
 
-	// 如果提供了新密码，则更新密码
-	if req.Password != "" {
-		if err := user.SetPassword(req.Password); err != nil {
-			utils.InternalServerError(c, "Failed to encrypt password")
-			return
-		}
-	}
-
 	if err := db.Save(&user).Error; err != nil {
 		utils.InternalServerError(c, "Failed to update user")
 		return
 	}
 
+	h.auditService.Record(auditEntry(c, models.AuditActionUpdate, models.AuditResourceUser, user.ID), before, user)
 	utils.SuccessWithMessage(c, "Profile updated successfully", user)
-}
\ No newline at end of file
+}
+
+// ChangePassword 修改密码
+// @Summary 修改密码
+// @Description 校验旧密码后修改当前用户密码，并吊销其他会话
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param password body ChangePasswordRequest true "密码信息"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Router /api/auth/password/change [post]
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request format")
+		return
+	}
+
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if !user.CheckPassword(req.OldPassword) {
+		utils.GetLogger().Ctx(c.Request.Context()).Auth("password_change", user.Username, c.ClientIP(), false)
+		utils.BadRequest(c, "Old password is incorrect")
+		return
+	}
+	if err := h.authService.ValidatePassword(req.NewPassword, user.Username); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	// 保留当前会话，吊销其他会话
+	sessionID := ""
+	if claims := middleware.CurrentClaims(c); claims != nil {
+		sessionID = claims.SessionID
+	}
+	if err := h.authService.ChangePassword(user, req.NewPassword, sessionID); err != nil {
+		utils.InternalServerError(c, "Failed to change password")
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionChangePassword, models.AuditResourceUser, user.ID), nil, nil)
+	utils.GetLogger().Ctx(c.Request.Context()).Auth("password_change", user.Username, c.ClientIP(), true)
+	utils.SuccessWithMessage(c, "Password changed successfully", nil)
+}
+
+// ForgotPassword 忘记密码
+// @Summary 忘记密码
+// @Description 向邮箱对应的已激活本地账号发送密码重置邮件，单点登录创建的账号不会收到邮件，无论邮箱是否存在都返回成功
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param email body ForgotPasswordRequest true "邮箱"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 503 {object} utils.Response
+// @Router /api/auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request format")
+		return
+	}
+
+	if !h.authService.MailEnabled() {
+		utils.Error(c, http.StatusServiceUnavailable, "Mail service is not configured")
+		return
+	}
+
+	var users []models.User
+	database.GetDB().Where("LOWER(email) = LOWER(?) AND is_active = ?", req.Email, true).Find(&users)
+	for i := range users {
+		// 单点登录和目录服务创建的账号没有本地密码，不能通过邮件设置
+		if !users[i].HasUsablePassword() {
+			continue
+		}
+		h.authService.SendPasswordResetMail(&users[i])
+		utils.GetLogger().Ctx(c.Request.Context()).Auth("password_forgot", users[i].Username, c.ClientIP(), true)
+	}
+
+	utils.SuccessWithMessage(c, "If the email is registered, a password reset link has been sent", nil)
+}
+
+// ResetPassword 重置密码
+// @Summary 重置密码
+// @Description 使用密码重置邮件中的令牌设置新密码，令牌只能使用一次，重置后吊销该用户所有会话
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param password body ResetPasswordRequest true "重置信息"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Router /api/auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request format")
+		return
+	}
+
+	user, err := h.authService.CheckPasswordResetToken(req.Token)
+	if err != nil || !user.IsActive {
+		utils.BadRequest(c, "Invalid or expired token")
+		return
+	}
+	if err := h.authService.ValidatePassword(req.Password, user.Username); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.authService.ChangePassword(user, req.Password, ""); err != nil {
+		utils.InternalServerError(c, "Failed to reset password")
+		return
+	}
+
+	// 通过邮件令牌重置时没有登录用户，操作人即为该用户
+	entry := auditEntry(c, models.AuditActionResetPassword, models.AuditResourceUser, user.ID)
+	entry.ActorID, entry.ActorName = user.ID, user.Username
+	h.auditService.Record(entry, nil, nil)
+	utils.GetLogger().Ctx(c.Request.Context()).Auth("password_reset", user.Username, c.ClientIP(), true)
+	utils.SuccessWithMessage(c, "Password reset successfully", nil)
+}
+
+// VerifyEmail 验证邮箱
+// @Summary 验证邮箱
+// @Description 使用注册邮件中的令牌验证邮箱并激活账号
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param token body VerifyEmailRequest true "验证令牌"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Router /api/auth/email/verify [post]
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request format")
+		return
+	}
+
+	user, err := h.authService.CheckEmailVerificationToken(req.Token)
+	if err != nil {
+		utils.BadRequest(c, "Invalid or expired token")
+		return
+	}
+
+	if err := h.authService.VerifyEmail(user); err != nil {
+		if errors.Is(err, services.ErrInvalidLinkToken) {
+			utils.BadRequest(c, "Invalid or expired token")
+			return
+		}
+		utils.InternalServerError(c, "Failed to verify email")
+		return
+	}
+
+	utils.GetLogger().Ctx(c.Request.Context()).Auth("verify_email", user.Username, c.ClientIP(), true)
+	utils.SuccessWithMessage(c, "Email verified successfully", nil)
+}
+
+// clientInfo 获取签发令牌时记录的客户端信息
+func clientInfo(c *gin.Context) services.ClientInfo {
+	return services.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
+}
+
+// accountLocked 返回账号锁定响应
+func accountLocked(c *gin.Context, lockedUntil time.Time) {
+	retryAfter := int(time.Until(lockedUntil).Seconds()) + 1
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	utils.Error(c, http.StatusLocked, "Account is temporarily locked due to too many failed login attempts")
+}
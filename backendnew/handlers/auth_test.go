@@ -0,0 +1,116 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"seldom-platform/models"
+	"seldom-platform/routes"
+)
+
+// postJSON 发送JSON请求，token不为空时带上Authorization头
+func postJSON(t *testing.T, handler http.Handler, path, token string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestRegisterWithEmailVerificationCreatesInactiveUser(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Security.EmailVerification = true
+	db := setupTestDB(t, cfg)
+	engine := routes.Setup(cfg)
+
+	w := postJSON(t, engine, "/api/auth/register", "", map[string]string{
+		"username": "alice",
+		"password": "alice-pass-123",
+		"email":    "alice@example.com",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("register: status = %d, body = %s", w.Code, w.Body)
+	}
+
+	var user models.User
+	if err := db.Where("username = ?", "alice").First(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if user.IsActive {
+		t.Error("user registered with email verification is active")
+	}
+	if w := postJSON(t, engine, "/api/auth/login", "", map[string]string{"username": "alice", "password": "alice-pass-123"}); w.Code == http.StatusOK {
+		t.Errorf("unverified user logged in: %s", w.Body)
+	}
+
+	if w := postJSON(t, engine, "/api/auth/register", "", map[string]string{
+		"username": "alice",
+		"password": "alice-pass-123",
+		"email":    "alice@example.com",
+	}); w.Code != http.StatusBadRequest {
+		t.Errorf("duplicate register: status = %d, want 400", w.Code)
+	}
+}
+
+func TestRegisterWithoutEmailVerificationCreatesActiveUser(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Security.EmailVerification = false
+	db := setupTestDB(t, cfg)
+	engine := routes.Setup(cfg)
+
+	if w := postJSON(t, engine, "/api/auth/register", "", map[string]string{"username": "bob", "password": "bob-pass-1234"}); w.Code != http.StatusOK {
+		t.Fatalf("register: status = %d, body = %s", w.Code, w.Body)
+	}
+	var user models.User
+	if err := db.Where("username = ?", "bob").First(&user).Error; err != nil || !user.IsActive {
+		t.Errorf("user = %+v, %v, want an active user", user, err)
+	}
+}
+
+func TestChangePasswordIsAuditedSeparately(t *testing.T) {
+	cfg := newTestConfig(t)
+	db := setupTestDB(t, cfg)
+	engine := routes.Setup(cfg)
+
+	user := models.User{Username: "carol", IsActive: true}
+	if err := user.SetPassword("carol-pass-123"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	w := postJSON(t, engine, "/api/auth/login", "", map[string]string{"username": "carol", "password": "carol-pass-123"})
+	var login struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &login); err != nil || login.Data.Token == "" {
+		t.Fatalf("login: status = %d, body = %s", w.Code, w.Body)
+	}
+
+	w = postJSON(t, engine, "/api/auth/password/change", login.Data.Token, map[string]string{
+		"old_password": "carol-pass-123",
+		"new_password": "carol-pass-456",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("change password: status = %d, body = %s", w.Code, w.Body)
+	}
+
+	var logs []models.AuditLog
+	db.Where("resource_type = ? AND resource_id = ?", models.AuditResourceUser, user.ID).Find(&logs)
+	if len(logs) != 1 || logs[0].Action != models.AuditActionChangePassword || logs[0].ActorID != user.ID {
+		t.Errorf("audit logs = %+v, want one change_password entry by the user", logs)
+	}
+}
@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"seldom-platform/database"
+	"seldom-platform/middleware"
 	"seldom-platform/models"
+	"seldom-platform/services"
 	"seldom-platform/utils"
 	"strconv"
 
@@ -10,11 +12,17 @@ import (
 )
 
 // CaseHandler 测试用例处理器
-type CaseHandler struct{}
+type CaseHandler struct {
+	permissionService *services.PermissionService
+	auditService      *services.AuditService
+}
 
 // NewCaseHandler 创建测试用例处理器
 func NewCaseHandler() *CaseHandler {
-	return &CaseHandler{}
+	return &CaseHandler{
+		permissionService: services.NewPermissionService(),
+		auditService:      services.NewAuditService(),
+	}
 }
 
 // CreateCaseRequest 创建测试用例请求结构
@@ -56,7 +64,7 @@ type UpdateCaseRequest struct {
 // @Param page query int false "页码" default(1)
 // @Param size query int false "每页数量" default(10)
 // @Param project query int false "项目ID"
-// @Param search query string false "搜索关键词"
+// @Param search query string false "搜索关键词，匹配用例名和文件名"
 // @Success 200 {object} utils.PageResponse{data=[]models.TestCase}
 // @Failure 401 {object} utils.Response
 // @Router /api/cases [get]
@@ -78,15 +86,15 @@ func (h *CaseHandler) GetCases(c *gin.Context) {
 
 	offset := (page - 1) * size
 
-	// 构建查询
-	query := db.Model(&models.TestCase{})
+	// 构建查询，只返回当前用户有权限的项目下的用例
+	query := h.permissionService.FilterProjects(db.Model(&models.TestCase{}), middleware.CurrentUser(c), "project_id")
 	
 	if projectID != "" {
-		query = query.Where("project = ?", projectID)
+		query = query.Where("project_id = ?", projectID)
 	}
 	
 	if search != "" {
-		query = query.Where("name LIKE ? OR info LIKE ?", "%"+search+"%", "%"+search+"%")
+		query = query.Where("case_name LIKE ? OR file_name LIKE ?", "%"+search+"%", "%"+search+"%")
 	}
 
 	// 获取总数
@@ -164,6 +172,7 @@ func (h *CaseHandler) CreateCase(c *gin.Context) {
 		return
 	}
 
+	h.auditService.Record(auditEntry(c, models.AuditActionCreate, models.AuditResourceCase, testCase.ID), nil, testCase)
 	utils.SuccessWithMessage(c, "Test case created successfully", testCase)
 }
 
@@ -195,6 +204,7 @@ func (h *CaseHandler) UpdateCase(c *gin.Context) {
 		utils.NotFound(c, "Test case not found")
 		return
 	}
+	before := testCase
 
 	// 更新测试用例信息
 	if req.Name != "" {
@@ -226,6 +236,7 @@ func (h *CaseHandler) UpdateCase(c *gin.Context) {
 		return
 	}
 
+	h.auditService.Record(auditEntry(c, models.AuditActionUpdate, models.AuditResourceCase, testCase.ID), before, testCase)
 	utils.SuccessWithMessage(c, "Test case updated successfully", testCase)
 }
 
@@ -254,6 +265,7 @@ func (h *CaseHandler) DeleteCase(c *gin.Context) {
 		return
 	}
 
+	h.auditService.Record(auditEntry(c, models.AuditActionDelete, models.AuditResourceCase, testCase.ID), testCase, nil)
 	utils.SuccessWithMessage(c, "Test case deleted successfully", nil)
 }
 
@@ -295,5 +307,6 @@ func (h *CaseHandler) CopyCase(c *gin.Context) {
 		return
 	}
 
+	h.auditService.Record(auditEntry(c, models.AuditActionCreate, models.AuditResourceCase, newCase.ID), nil, newCase)
 	utils.SuccessWithMessage(c, "Test case copied successfully", newCase)
 }
\ No newline at end of file
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"seldom-platform/database"
+	"seldom-platform/models"
+	"seldom-platform/services"
+	"seldom-platform/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DashboardHandler 质量看板处理器
+type DashboardHandler struct {
+	dashboardService *services.DashboardService
+}
+
+// NewDashboardHandler 创建质量看板处理器
+func NewDashboardHandler() *DashboardHandler {
+	return &DashboardHandler{
+		dashboardService: services.NewDashboardService(),
+	}
+}
+
+// GetProjectDashboard 获取项目质量看板
+// @Summary 获取项目质量看板
+// @Description 统计项目下任务的通过率趋势、每日执行次数、平均及P95耗时、失败最多的用例和按标签的用例数
+// @Tags 质量看板
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "项目ID"
+// @Param days query int false "统计天数（1-365）" default(30)
+// @Param top query int false "失败用例TopN（1-100）" default(10)
+// @Success 200 {object} utils.Response{data=services.Dashboard}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/dashboard/projects/{id} [get]
+func (h *DashboardHandler) GetProjectDashboard(c *gin.Context) {
+	id := c.Param("id")
+	db := database.GetDB()
+
+	var project models.Project
+	if err := db.First(&project, id).Error; err != nil {
+		utils.NotFound(c, "Project not found")
+		return
+	}
+
+	h.respond(c, services.DashboardScope{ProjectID: project.ID})
+}
+
+// GetTeamDashboard 获取团队质量看板
+// @Summary 获取团队质量看板
+// @Description 统计团队任务的通过率趋势、每日执行次数、平均及P95耗时、失败最多的用例和按标签的用例数
+// @Tags 质量看板
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "团队ID"
+// @Param days query int false "统计天数（1-365）" default(30)
+// @Param top query int false "失败用例TopN（1-100）" default(10)
+// @Success 200 {object} utils.Response{data=services.Dashboard}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/dashboard/teams/{id} [get]
+func (h *DashboardHandler) GetTeamDashboard(c *gin.Context) {
+	id := c.Param("id")
+	db := database.GetDB()
+
+	var team models.Team
+	if err := db.First(&team, id).Error; err != nil {
+		utils.NotFound(c, "Team not found")
+		return
+	}
+
+	h.respond(c, services.DashboardScope{TeamID: team.ID})
+}
+
+// respond 解析统计参数并返回看板数据
+func (h *DashboardHandler) respond(c *gin.Context, scope services.DashboardScope) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 365 {
+		utils.BadRequest(c, "Invalid days, must be between 1 and 365")
+		return
+	}
+	top, err := strconv.Atoi(c.DefaultQuery("top", "10"))
+	if err != nil || top < 1 || top > 100 {
+		utils.BadRequest(c, "Invalid top, must be between 1 and 100")
+		return
+	}
+
+	dashboard, err := h.dashboardService.GetDashboard(scope, services.DashboardOptions{
+		Days: days,
+		Top:  top,
+	})
+	if err != nil {
+		utils.InternalServerError(c, "Failed to build dashboard")
+		return
+	}
+
+	utils.Success(c, dashboard)
+}
@@ -0,0 +1,770 @@
+package handlers
+
+import (
+	"strings"
+
+	"seldom-platform/config"
+	"seldom-platform/database"
+	"seldom-platform/middleware"
+	"seldom-platform/models"
+	"seldom-platform/services"
+	"seldom-platform/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DjangoHandler Django兼容接口处理器，按原后端（Django Ninja）的请求和响应结构提供接口，供frontendv3使用
+type DjangoHandler struct {
+	config            *config.Config
+	authHandler       *AuthHandler
+	authService       *services.AuthService
+	permissionService *services.PermissionService
+	auditService      *services.AuditService
+	trashService      *services.TrashService
+	taskService       *services.TaskService
+}
+
+// NewDjangoHandler 创建Django兼容接口处理器
+func NewDjangoHandler(cfg *config.Config) *DjangoHandler {
+	return &DjangoHandler{
+		config:            cfg,
+		authHandler:       NewAuthHandler(cfg),
+		authService:       services.NewAuthService(cfg),
+		permissionService: services.NewPermissionService(),
+		auditService:      services.NewAuditService(),
+		trashService:      services.NewTrashService(),
+		taskService:       services.NewTaskService(cfg),
+	}
+}
+
+// DjangoLoginRequest 登录请求结构
+type DjangoLoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// DjangoRegisterRequest 注册请求结构
+type DjangoRegisterRequest struct {
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	Password2 string `json:"password2"`
+}
+
+// DjangoLogoutRequest 退出登录请求结构
+type DjangoLogoutRequest struct {
+	Token string `json:"token"`
+}
+
+// DjangoProjectRequest 项目请求结构
+type DjangoProjectRequest struct {
+	Name      string `json:"name" binding:"required"`
+	Address   string `json:"address"`
+	CaseDir   string `json:"case_dir"`
+	CoverName string `json:"cover_name"`
+	PathName  string `json:"path_name"`
+}
+
+// DjangoEnvRequest 环境请求结构，更新时全部字段都会被覆盖
+type DjangoEnvRequest struct {
+	Name         string `json:"name" binding:"required"`
+	Project      uint   `json:"project"`
+	TestType     string `json:"test_type"`
+	Env          string `json:"env"`
+	Rerun        int    `json:"rerun"`
+	IsClearCache bool   `json:"is_clear_cache"`
+	Browser      string `json:"browser"`
+	BaseURL      string `json:"base_url"`
+	Remote       string `json:"remote"`
+	AppServer    string `json:"app_server"`
+	AppInfo      string `json:"app_info"`
+}
+
+// DjangoTeamRequest 团队请求结构
+type DjangoTeamRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email"`
+}
+
+// 项目默认封面，与原后端一致
+const (
+	djangoDefaultCoverName = "seldom_logo.png"
+	djangoDefaultPathName  = "2d82cb919cf05116adf720f8f7437ac9.png"
+)
+
+// Login 用户登录
+// @Summary 用户登录（Django兼容）
+// @Description 与REST登录接口共用账号锁定和LDAP校验，返回原后端的登录结果结构
+// @Tags Django兼容
+// @Accept json
+// @Produce json
+// @Param login body DjangoLoginRequest true "登录信息"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/user/login [post]
+func (h *DjangoHandler) Login(c *gin.Context) {
+	var req DjangoLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrJSONType)
+		return
+	}
+	if req.Username == "" || req.Password == "" {
+		utils.DjangoFail(c, utils.DjangoErrUserOrPawdNull)
+		return
+	}
+
+	resp, authErr := h.authHandler.authenticate(c, LoginRequest{Username: req.Username, Password: req.Password})
+	if authErr != nil {
+		switch {
+		case authErr.lockedUntil != nil:
+			utils.DjangoFail(c, utils.DjangoErrUserOrPawdError.WithMessage("登录失败次数过多，账号已被临时锁定"))
+		case authErr.status >= 500:
+			utils.DjangoFail(c, utils.DjangoErrSystem)
+		default:
+			utils.DjangoFail(c, utils.DjangoErrUserOrPawdError)
+		}
+		return
+	}
+
+	utils.DjangoSuccess(c, gin.H{
+		"token":       resp.AccessToken,
+		"user_id":     resp.User.ID,
+		"username":    resp.User.Username,
+		"permissions": []string{},
+	})
+}
+
+// Logout 退出登录
+// @Summary 退出登录（Django兼容）
+// @Description 吊销请求体中的token，token无效时同样返回成功
+// @Tags Django兼容
+// @Accept json
+// @Produce json
+// @Param logout body DjangoLogoutRequest true "登录令牌"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/user/logout [post]
+func (h *DjangoHandler) Logout(c *gin.Context) {
+	var req DjangoLogoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrJSONType)
+		return
+	}
+
+	if claims, err := utils.ParseJWT(req.Token, h.config.JWT.Secret); err == nil {
+		if err := h.authService.Logout(claims); err != nil {
+			utils.DjangoFail(c, utils.DjangoErrSystem)
+			return
+		}
+	}
+	utils.DjangoSuccess(c, nil)
+}
+
+// Register 用户注册
+// @Summary 用户注册（Django兼容）
+// @Description 与REST注册接口共用密码策略和邮箱验证设置
+// @Tags Django兼容
+// @Accept json
+// @Produce json
+// @Param register body DjangoRegisterRequest true "注册信息"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/user/register [post]
+func (h *DjangoHandler) Register(c *gin.Context) {
+	var req DjangoRegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrJSONType)
+		return
+	}
+	if req.Username == "" || req.Password == "" {
+		utils.DjangoFail(c, utils.DjangoErrUserOrPawdNull)
+		return
+	}
+	// 原后端注册时不填写邮箱，开启邮箱验证时无法完成注册
+	if h.config.Current().Security.EmailVerification {
+		utils.DjangoFail(c, utils.DjangoErrRegisterRestrict)
+		return
+	}
+	if req.Password != req.Password2 {
+		utils.DjangoFail(c, utils.DjangoErrPawdError)
+		return
+	}
+
+	var existingUser models.User
+	if err := database.GetDB().Where("username = ?", req.Username).First(&existingUser).Error; err == nil {
+		utils.DjangoFail(c, utils.DjangoErrUserHasRegistered)
+		return
+	}
+
+	user, authErr := h.authHandler.register(c, RegisterRequest{Username: req.Username, Password: req.Password})
+	if authErr != nil {
+		if authErr.status >= 500 {
+			utils.DjangoFail(c, utils.DjangoErrSystem)
+			return
+		}
+		utils.DjangoFail(c, utils.DjangoErrParamsType.WithMessage(authErr.message))
+		return
+	}
+
+	utils.DjangoSuccess(c, gin.H{
+		"id":          user.ID,
+		"username":    user.Username,
+		"permissions": []string{},
+	})
+}
+
+// CreateProject 创建项目
+// @Summary 创建项目（Django兼容）
+// @Description 创建项目，创建者成为项目owner
+// @Tags Django兼容
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param project body DjangoProjectRequest true "项目信息"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/project/create [post]
+func (h *DjangoHandler) CreateProject(c *gin.Context) {
+	var req DjangoProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrJSONType)
+		return
+	}
+
+	db := database.GetDB()
+
+	var existingProject models.Project
+	if err := db.Where("name = ? AND is_delete = ?", req.Name, false).First(&existingProject).Error; err == nil {
+		utils.DjangoFail(c, utils.DjangoErrParamsType.WithMessage("项目名称已存在"))
+		return
+	}
+
+	project := models.Project{
+		Name:      req.Name,
+		Address:   req.Address,
+		CaseDir:   req.CaseDir,
+		CoverName: req.CoverName,
+		PathName:  req.PathName,
+	}
+	if project.CoverName == "" && project.PathName == "" {
+		project.CoverName = djangoDefaultCoverName
+		project.PathName = djangoDefaultPathName
+	}
+	if project.CaseDir == "" {
+		project.CaseDir = "test_dir"
+	}
+
+	if err := db.Create(&project).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	// 创建者成为项目owner
+	if _, err := h.permissionService.AddProjectMember(project.ID, middleware.CurrentUser(c).ID, models.RoleOwner); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionCreate, models.AuditResourceProject, project.ID), nil, project)
+	utils.DjangoSuccess(c, djangoProject(project))
+}
+
+// GetProjects 获取项目列表
+// @Summary 获取项目列表（Django兼容）
+// @Description 获取当前用户有权限的全部项目，不分页
+// @Tags Django兼容
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/project/list [get]
+func (h *DjangoHandler) GetProjects(c *gin.Context) {
+	var projects []models.Project
+	query := h.permissionService.FilterProjects(database.GetDB().Model(&models.Project{}), middleware.CurrentUser(c), "id")
+	if err := query.Where("is_delete = ?", false).Order("id ASC").Find(&projects).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	result := make([]gin.H, 0, len(projects))
+	for _, project := range projects {
+		result = append(result, djangoProject(project))
+	}
+	utils.DjangoSuccess(c, result)
+}
+
+// GetProject 获取项目详情
+// @Summary 获取项目详情（Django兼容）
+// @Tags Django兼容
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "项目ID"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/project/{id}/ [get]
+func (h *DjangoHandler) GetProject(c *gin.Context) {
+	var project models.Project
+	if err := database.GetDB().Where("is_delete = ?", false).First(&project, c.Param("id")).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrProjectObjectNull)
+		return
+	}
+
+	utils.DjangoSuccess(c, djangoProject(project))
+}
+
+// UpdateProject 更新项目
+// @Summary 更新项目（Django兼容）
+// @Description 使用请求中的全部字段覆盖项目信息
+// @Tags Django兼容
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "项目ID"
+// @Param project body DjangoProjectRequest true "项目信息"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/project/{id}/ [put]
+func (h *DjangoHandler) UpdateProject(c *gin.Context) {
+	var req DjangoProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrJSONType)
+		return
+	}
+
+	db := database.GetDB()
+	var project models.Project
+	if err := db.Where("is_delete = ?", false).First(&project, c.Param("id")).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrProjectObjectNull)
+		return
+	}
+	before := project
+
+	var existingProject models.Project
+	if err := db.Where("name = ? AND is_delete = ? AND id <> ?", req.Name, false, project.ID).First(&existingProject).Error; err == nil {
+		utils.DjangoFail(c, utils.DjangoErrParamsType.WithMessage("项目名称已存在"))
+		return
+	}
+
+	project.Name = req.Name
+	project.Address = req.Address
+	project.CaseDir = req.CaseDir
+	project.CoverName = req.CoverName
+	project.PathName = req.PathName
+	if err := db.Save(&project).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionUpdate, models.AuditResourceProject, project.ID), before, project)
+	utils.DjangoSuccess(c, djangoProject(project))
+}
+
+// DeleteProject 删除项目
+// @Summary 删除项目（Django兼容）
+// @Description 将项目及其任务移入回收站
+// @Tags Django兼容
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "项目ID"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/project/{id}/ [delete]
+func (h *DjangoHandler) DeleteProject(c *gin.Context) {
+	var project models.Project
+	if err := database.GetDB().Where("is_delete = ?", false).First(&project, c.Param("id")).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrProjectObjectNull)
+		return
+	}
+
+	if err := h.trashService.DeleteProject(&project); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrProjectDelete)
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionDelete, models.AuditResourceProject, project.ID), project, nil)
+	utils.DjangoSuccess(c, nil)
+}
+
+// NotSupported 原后端基于本地git仓库的接口（代码同步、克隆等），Go后端暂不提供
+// @Summary 暂不支持的接口（Django兼容）
+// @Tags Django兼容
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/project/{id}/sync_code [get]
+func (h *DjangoHandler) NotSupported(c *gin.Context) {
+	utils.DjangoFail(c, utils.DjangoErrSystem.WithMessage("该接口暂不支持"))
+}
+
+// CreateEnv 创建环境
+// @Summary 创建环境（Django兼容）
+// @Description 未指定项目时创建全局环境，只有员工可以创建
+// @Tags Django兼容
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param env body DjangoEnvRequest true "环境信息"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/project/env [post]
+func (h *DjangoHandler) CreateEnv(c *gin.Context) {
+	var req DjangoEnvRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrJSONType)
+		return
+	}
+
+	var projectID *uint
+	if req.Project != 0 {
+		if !projectActive(req.Project) {
+			utils.DjangoFail(c, utils.DjangoErrProjectObjectNull)
+			return
+		}
+		projectID = &req.Project
+	}
+	if envNameExists(req.Name, projectID, 0) {
+		utils.DjangoFail(c, utils.DjangoErrParamsType.WithMessage("环境名称已存在"))
+		return
+	}
+
+	env := models.Env{ProjectID: projectID}
+	req.apply(&env)
+	if err := database.GetDB().Create(&env).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionCreate, models.AuditResourceEnv, env.ID), nil, env)
+	utils.DjangoSuccess(c, djangoEnv(env))
+}
+
+// GetEnvs 获取环境列表
+// @Summary 获取环境列表（Django兼容）
+// @Description 获取当前用户可用的全部环境，不分页
+// @Tags Django兼容
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/project/env/list [get]
+func (h *DjangoHandler) GetEnvs(c *gin.Context) {
+	var envs []models.Env
+	query := h.permissionService.FilterEnvs(database.GetDB().Model(&models.Env{}), middleware.CurrentUser(c))
+	if err := query.Where("is_delete = ?", false).Order("id ASC").Find(&envs).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	result := make([]gin.H, 0, len(envs))
+	for _, env := range envs {
+		result = append(result, djangoEnv(env))
+	}
+	utils.DjangoSuccess(c, result)
+}
+
+// GetEnv 获取环境详情
+// @Summary 获取环境详情（Django兼容）
+// @Tags Django兼容
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "环境ID"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/project/env/{id}/ [get]
+func (h *DjangoHandler) GetEnv(c *gin.Context) {
+	var env models.Env
+	if err := database.GetDB().Where("is_delete = ?", false).First(&env, c.Param("id")).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrEnvIsNull)
+		return
+	}
+
+	utils.DjangoSuccess(c, djangoEnv(env))
+}
+
+// UpdateEnv 更新环境
+// @Summary 更新环境（Django兼容）
+// @Description 使用请求中的全部字段覆盖环境信息，环境所属项目不变
+// @Tags Django兼容
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "环境ID"
+// @Param env body DjangoEnvRequest true "环境信息"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/project/env/{id}/ [put]
+func (h *DjangoHandler) UpdateEnv(c *gin.Context) {
+	var req DjangoEnvRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrJSONType)
+		return
+	}
+
+	db := database.GetDB()
+	var env models.Env
+	if err := db.Where("is_delete = ?", false).First(&env, c.Param("id")).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrEnvIsNull)
+		return
+	}
+	before := env
+
+	if envNameExists(req.Name, env.ProjectID, env.ID) {
+		utils.DjangoFail(c, utils.DjangoErrParamsType.WithMessage("环境名称已存在"))
+		return
+	}
+	req.apply(&env)
+	if err := db.Save(&env).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionUpdate, models.AuditResourceEnv, env.ID), before, env)
+	utils.DjangoSuccess(c, nil)
+}
+
+// DeleteEnv 删除环境
+// @Summary 删除环境（Django兼容）
+// @Description 将环境移入回收站，环境被未删除的任务使用时不能删除
+// @Tags Django兼容
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "环境ID"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/project/env/{id}/ [delete]
+func (h *DjangoHandler) DeleteEnv(c *gin.Context) {
+	db := database.GetDB()
+	var env models.Env
+	if err := db.Where("is_delete = ?", false).First(&env, c.Param("id")).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrEnvIsNull)
+		return
+	}
+
+	// 被任务使用的环境不能删除
+	var count int
+	db.Model(&models.TestTask{}).
+		Where("is_delete = ?", false).
+		Where("env_id = ? OR id IN (?)", env.ID,
+			db.Model(&models.TaskEnvRelevance{}).Select("task_id").Where("env_id = ?", env.ID).SubQuery()).
+		Count(&count)
+	if count > 0 {
+		utils.DjangoFail(c, utils.DjangoErrEnvInUse)
+		return
+	}
+
+	if err := h.trashService.DeleteEnv(&env); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionDelete, models.AuditResourceEnv, env.ID), env, nil)
+	utils.DjangoSuccess(c, nil)
+}
+
+// CreateTeam 创建团队
+// @Summary 创建团队（Django兼容）
+// @Description 创建团队，创建者成为团队owner
+// @Tags Django兼容
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param team body DjangoTeamRequest true "团队信息"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/team/create [post]
+func (h *DjangoHandler) CreateTeam(c *gin.Context) {
+	var req DjangoTeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrJSONType)
+		return
+	}
+	if !utils.IsValidEmail(req.Email) {
+		utils.DjangoFail(c, utils.DjangoErrTeamEmail)
+		return
+	}
+
+	db := database.GetDB()
+
+	var existingTeam models.Team
+	if err := db.Where("name = ? AND email = ? AND is_delete = ?", req.Name, req.Email, false).First(&existingTeam).Error; err == nil {
+		utils.DjangoFail(c, utils.DjangoErrTeamExist)
+		return
+	}
+
+	team := models.Team{Name: req.Name, Email: req.Email}
+	if err := db.Create(&team).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	// 创建者成为团队owner
+	if _, err := h.permissionService.AddTeamMember(team.ID, middleware.CurrentUser(c).ID, models.RoleOwner); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionCreate, models.AuditResourceTeam, team.ID), nil, team)
+	utils.DjangoSuccess(c, djangoTeam(team))
+}
+
+// GetTeams 获取团队列表
+// @Summary 获取团队列表（Django兼容）
+// @Description 获取当前用户有权限的全部团队，不分页
+// @Tags Django兼容
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/team/list [get]
+func (h *DjangoHandler) GetTeams(c *gin.Context) {
+	var teams []models.Team
+	query := h.permissionService.FilterTeams(database.GetDB().Model(&models.Team{}), middleware.CurrentUser(c), "id")
+	if err := query.Where("is_delete = ?", false).Order("id ASC").Find(&teams).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	result := make([]gin.H, 0, len(teams))
+	for _, team := range teams {
+		result = append(result, djangoTeam(team))
+	}
+	utils.DjangoSuccess(c, result)
+}
+
+// GetTeam 获取团队详情
+// @Summary 获取团队详情（Django兼容）
+// @Tags Django兼容
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "团队ID"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/team/{id}/ [get]
+func (h *DjangoHandler) GetTeam(c *gin.Context) {
+	var team models.Team
+	if err := database.GetDB().Where("is_delete = ?", false).First(&team, c.Param("id")).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrParamsType.WithMessage("团队不存在"))
+		return
+	}
+
+	utils.DjangoSuccess(c, djangoTeam(team))
+}
+
+// UpdateTeam 更新团队
+// @Summary 更新团队（Django兼容）
+// @Tags Django兼容
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "团队ID"
+// @Param team body DjangoTeamRequest true "团队信息"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/team/{id}/ [put]
+func (h *DjangoHandler) UpdateTeam(c *gin.Context) {
+	var req DjangoTeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrJSONType)
+		return
+	}
+	if !utils.IsValidEmail(req.Email) {
+		utils.DjangoFail(c, utils.DjangoErrTeamEmail)
+		return
+	}
+
+	db := database.GetDB()
+	var team models.Team
+	if err := db.Where("is_delete = ?", false).First(&team, c.Param("id")).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrParamsType.WithMessage("团队不存在"))
+		return
+	}
+	before := team
+
+	team.Name = req.Name
+	team.Email = req.Email
+	if err := db.Save(&team).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionUpdate, models.AuditResourceTeam, team.ID), before, team)
+	utils.DjangoSuccess(c, djangoTeam(team))
+}
+
+// DeleteTeam 删除团队
+// @Summary 删除团队（Django兼容）
+// @Description 将团队移入回收站
+// @Tags Django兼容
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "团队ID"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/team/{id}/ [delete]
+func (h *DjangoHandler) DeleteTeam(c *gin.Context) {
+	var team models.Team
+	if err := database.GetDB().Where("is_delete = ?", false).First(&team, c.Param("id")).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrParamsType.WithMessage("团队不存在"))
+		return
+	}
+
+	if err := h.trashService.DeleteTeam(&team); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionDelete, models.AuditResourceTeam, team.ID), team, nil)
+	utils.DjangoSuccess(c, nil)
+}
+
+// apply 将请求中的环境字段写入环境，未填写的字段使用模型默认值
+func (req *DjangoEnvRequest) apply(env *models.Env) {
+	env.Name = req.Name
+	env.TestType = req.TestType
+	if env.TestType == "" {
+		env.TestType = "http"
+	}
+	env.Env = req.Env
+	env.Rerun = req.Rerun
+	env.IsClearCache = req.IsClearCache
+	env.Browser = req.Browser
+	env.BaseURL = req.BaseURL
+	env.Remote = req.Remote
+	env.AppServer = req.AppServer
+	env.AppInfo = strings.TrimSpace(req.AppInfo)
+	if env.AppInfo == "" {
+		env.AppInfo = "{}"
+	}
+}
+
+// djangoProject 按原后端model_to_dict的字段输出项目
+func djangoProject(project models.Project) gin.H {
+	return gin.H{
+		"id":          project.ID,
+		"name":        project.Name,
+		"address":     project.Address,
+		"case_dir":    project.CaseDir,
+		"is_delete":   project.IsDelete,
+		"create_time": utils.DjangoTime(project.CreateTime),
+		"update_time": utils.DjangoTime(project.UpdateTime),
+		"cover_name":  project.CoverName,
+		"path_name":   project.PathName,
+		"test_num":    project.TestNum,
+		"is_clone":    project.IsClone,
+		"run_version": project.RunVersion,
+	}
+}
+
+// djangoEnv 按原后端model_to_dict的字段输出环境，project_id为Go后端新增字段
+func djangoEnv(env models.Env) gin.H {
+	return gin.H{
+		"id":             env.ID,
+		"name":           env.Name,
+		"project_id":     env.ProjectID,
+		"test_type":      env.TestType,
+		"env":            env.Env,
+		"rerun":          env.Rerun,
+		"is_clear_cache": env.IsClearCache,
+		"browser":        env.Browser,
+		"base_url":       env.BaseURL,
+		"remote":         env.Remote,
+		"app_server":     env.AppServer,
+		"app_info":       env.AppInfo,
+		"is_delete":      env.IsDelete,
+		"create_time":    utils.DjangoTime(env.CreateTime),
+		"update_time":    utils.DjangoTime(env.UpdateTime),
+	}
+}
+
+// djangoTeam 按原后端model_to_dict的字段输出团队
+func djangoTeam(team models.Team) gin.H {
+	return gin.H{
+		"id":          team.ID,
+		"name":        team.Name,
+		"email":       team.Email,
+		"is_delete":   team.IsDelete,
+		"create_time": utils.DjangoTime(team.CreateTime),
+		"update_time": utils.DjangoTime(team.UpdateTime),
+	}
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"strings"
+
+	"seldom-platform/database"
+	"seldom-platform/models"
+	"seldom-platform/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DjangoRunCaseRequest 执行用例请求结构
+type DjangoRunCaseRequest struct {
+	Env uint `json:"env"`
+}
+
+// GetProjectFiles 获取项目的用例文件列表
+// @Summary 获取项目的用例文件列表（Django兼容）
+// @Description 根据已同步的用例生成第一层文件树
+// @Tags Django兼容
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "项目ID"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/project/{id}/files [get]
+func (h *DjangoHandler) GetProjectFiles(c *gin.Context) {
+	var cases []models.TestCase
+	if err := database.GetDB().Select("file_name").Where("project_id = ?", c.Param("id")).Order("id ASC").Find(&cases).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	files := make([]gin.H, 0)
+	seen := make(map[string]bool)
+	for _, testCase := range cases {
+		name := strings.SplitN(testCase.FileName, ".", 2)[0]
+		if !strings.Contains(testCase.FileName, ".") {
+			name = testCase.FileName + ".py"
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		files = append(files, djangoFileNode(name, name))
+	}
+
+	utils.DjangoSuccess(c, gin.H{"case_number": len(cases), "files": files})
+}
+
+// GetProjectSubdirectory 获取用例目录的下一层文件
+// @Summary 获取用例目录的下一层文件（Django兼容）
+// @Tags Django兼容
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "项目ID"
+// @Param file_name query string true "目录名，以.分隔"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/project/{id}/subdirectory [get]
+func (h *DjangoHandler) GetProjectSubdirectory(c *gin.Context) {
+	dir := c.Query("file_name")
+	var cases []models.TestCase
+	if err := database.GetDB().Select("file_name").Where("project_id = ? AND file_name LIKE ?", c.Param("id"), dir+".%").Order("id ASC").Find(&cases).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	nodes := make([]gin.H, 0)
+	seen := make(map[string]bool)
+	for _, testCase := range cases {
+		rest := strings.TrimPrefix(testCase.FileName, dir+".")
+		name := strings.SplitN(rest, ".", 2)[0]
+		if !strings.Contains(rest, ".") {
+			name = rest + ".py"
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		nodes = append(nodes, djangoFileNode(name, dir+"."+name))
+	}
+
+	utils.DjangoSuccess(c, nodes)
+}
+
+// GetProjectCases 获取用例文件中的用例
+// @Summary 获取用例文件中的用例（Django兼容）
+// @Tags Django兼容
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "项目ID"
+// @Param file_name query string true "用例文件名，以.py结尾"
+// @Param label_name query string false "用例标签"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/project/{id}/cases [get]
+func (h *DjangoHandler) GetProjectCases(c *gin.Context) {
+	fileName := c.Query("file_name")
+	if !strings.HasSuffix(fileName, ".py") {
+		utils.DjangoFail(c, utils.DjangoErrParamsType.WithMessage("文件名错误"))
+		return
+	}
+
+	query := database.GetDB().Where("project_id = ? AND file_name = ?", c.Param("id"), strings.TrimSuffix(fileName, ".py"))
+	if label := c.Query("label_name"); label != "" {
+		query = query.Where("label LIKE ?", "%"+label+"%")
+	}
+	var cases []models.TestCase
+	if err := query.Order("id ASC").Find(&cases).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	result := make([]gin.H, 0, len(cases))
+	for _, testCase := range cases {
+		result = append(result, djangoCase(testCase))
+	}
+	utils.DjangoSuccess(c, result)
+}
+
+// RunCase 执行单个用例
+// @Summary 执行单个用例（Django兼容）
+// @Description 在指定环境中异步执行用例，用例正在执行时返回错误
+// @Tags Django兼容
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用例ID"
+// @Param env body DjangoRunCaseRequest true "执行环境"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/case/{id}/running [post]
+func (h *DjangoHandler) RunCase(c *gin.Context) {
+	var req DjangoRunCaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrJSONType)
+		return
+	}
+
+	var testCase models.TestCase
+	if err := database.GetDB().First(&testCase, c.Param("id")).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrParamsType.WithMessage("用例不存在"))
+		return
+	}
+	if testCase.Status == 1 {
+		utils.DjangoFail(c, utils.DjangoErrCaseRunning)
+		return
+	}
+
+	if err := h.taskService.StartCaseRun(c.Request.Context(), testCase.ID, req.Env); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem.WithMessage(err.Error()))
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionRun, models.AuditResourceCase, testCase.ID), nil, nil)
+	utils.DjangoSuccess(c, nil)
+}
+
+// GetCaseResult 获取用例最近一次执行结果
+// @Summary 获取用例最近一次执行结果（Django兼容）
+// @Description 用例没有执行结果时返回空列表
+// @Tags Django兼容
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用例ID"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/case/{id}/result [get]
+func (h *DjangoHandler) GetCaseResult(c *gin.Context) {
+	var result models.CaseResult
+	if err := database.GetDB().Where("case_id = ?", c.Param("id")).Order("create_time DESC, id DESC").First(&result).Error; err != nil {
+		utils.DjangoSuccess(c, []interface{}{})
+		return
+	}
+
+	utils.DjangoSuccess(c, gin.H{
+		"id":          result.ID,
+		"case":        result.CaseID,
+		"name":        result.Name,
+		"report":      result.Report,
+		"passed":      result.Passed,
+		"error":       result.Error,
+		"failure":     result.Failure,
+		"skipped":     result.Skipped,
+		"tests":       result.Tests,
+		"system_out":  result.SystemOut,
+		"run_time":    result.RunTime,
+		"create_time": utils.DjangoTime(result.CreateTime),
+	})
+}
+
+// djangoFileNode 用例文件树节点，.py文件为叶子节点
+func djangoFileNode(label, fullName string) gin.H {
+	if strings.HasSuffix(label, ".py") {
+		return gin.H{"label": label, "full_name": fullName, "is_leaf": 1, "leaf": true}
+	}
+	return gin.H{"label": label, "full_name": fullName, "is_leaf": 0, "children": []interface{}{}}
+}
+
+// djangoCase 按原后端model_to_dict的字段输出用例
+func djangoCase(testCase models.TestCase) gin.H {
+	return gin.H{
+		"id":          testCase.ID,
+		"project":     testCase.ProjectID,
+		"file_name":   testCase.FileName,
+		"class_name":  testCase.ClassName,
+		"class_doc":   testCase.ClassDoc,
+		"case_name":   testCase.CaseName,
+		"case_doc":    testCase.CaseDoc,
+		"label":       testCase.Label,
+		"status":      testCase.Status,
+		"case_hash":   testCase.CaseHash,
+		"create_time": utils.DjangoTime(testCase.CreateTime),
+		"update_time": utils.DjangoTime(testCase.UpdateTime),
+	}
+}
@@ -0,0 +1,296 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"seldom-platform/models"
+	"seldom-platform/routes"
+	"seldom-platform/services"
+)
+
+// frontendRequest frontendv3中一次接口调用的请求样例。
+// path和字符串中的{name}替换为已保存的值，值恰好为"$name"时替换为数字
+type frontendRequest struct {
+	API    string            `json:"api"`    // 前端方法，如ProjectApi.getProject
+	Method string            `json:"method"` // HTTP方法
+	Path   string            `json:"path"`
+	Query  map[string]string `json:"query"`
+	Body   interface{}       `json:"body"`
+	Public bool              `json:"public"` // 不需要登录
+	Expect string            `json:"expect"` // 为空时期望成功，page为分页结果，unsupported为暂不支持，missing为未实现
+	Save   map[string]string `json:"save"`   // 保存result中的字段，键为占位符名
+}
+
+func loadFrontendRequests(t *testing.T) []frontendRequest {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "frontend_requests.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var requests []frontendRequest
+	if err := json.Unmarshal(data, &requests); err != nil {
+		t.Fatal(err)
+	}
+	return requests
+}
+
+var (
+	frontendCallRe  = regexp.MustCompile(`(?m)^\s*(\w+)\([^)]*\)\s*\{\s*return request\.(get|post|put|del)\(\s*([^,)]+)`)
+	frontendClassRe = regexp.MustCompile(`class (\w+)`)
+	concatParamRe   = regexp.MustCompile(`"\s*\+\s*\w+\s*(\+\s*")?`)
+	templateParamRe = regexp.MustCompile(`\$\{\w+\}`)
+	placeholderRe   = regexp.MustCompile(`\{\w+\}`)
+)
+
+// normalizePath 把路径参数统一为:id，去掉查询参数
+func normalizePath(path string) string {
+	path = strings.SplitN(path, "?", 2)[0]
+	return strings.Trim(path, "\"`")
+}
+
+// TestFrontendRequestsCoverFrontend 样例需要覆盖frontendv3/src/request中的每个接口，方法和路径一致
+func TestFrontendRequestsCoverFrontend(t *testing.T) {
+	files, _ := filepath.Glob(filepath.Join("..", "..", "frontendv3", "src", "request", "*.ts"))
+	if len(files) == 0 {
+		t.Skip("frontendv3 not found")
+	}
+
+	fixtures := make(map[string]frontendRequest)
+	for _, request := range loadFrontendRequests(t) {
+		fixtures[request.API] = request
+	}
+	methods := map[string]string{"get": http.MethodGet, "post": http.MethodPost, "put": http.MethodPut, "del": http.MethodDelete}
+
+	for _, file := range files {
+		source, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		class := frontendClassRe.FindSubmatch(source)
+		if class == nil {
+			continue
+		}
+		for _, call := range frontendCallRe.FindAllSubmatch(source, -1) {
+			api := string(class[1]) + "." + string(call[1])
+			fixture, ok := fixtures[api]
+			if !ok {
+				t.Errorf("%s has no request fixture", api)
+				continue
+			}
+			path := concatParamRe.ReplaceAllString(strings.TrimSpace(string(call[3])), ":id")
+			path = normalizePath(templateParamRe.ReplaceAllString(path, ":id"))
+			if want := normalizePath(placeholderRe.ReplaceAllString(fixture.Path, ":id")); path != want || methods[string(call[2])] != fixture.Method {
+				t.Errorf("%s: frontend calls %s %s, fixture has %s %s", api, methods[string(call[2])], path, fixture.Method, want)
+			}
+		}
+	}
+}
+
+// contractServer 使用临时数据库的完整路由，预置管理员和同步过的用例、报告
+type contractServer struct {
+	engine *gin.Engine
+	values map[string]string
+}
+
+func newContractServer(t *testing.T) *contractServer {
+	t.Helper()
+	// seldom和git都不可用，执行接口只会在后台失败
+	t.Setenv("PATH", t.TempDir())
+	cfg := newTestConfig(t)
+	db := setupTestDB(t, cfg)
+	// 关闭数据库前等待后台执行结束
+	t.Cleanup(func() { services.NewExecutionService(cfg).Drain(30 * time.Second) })
+
+	admin := models.User{Username: "admin", IsActive: true, IsStaff: true, IsSuperuser: true}
+	if err := admin.SetPassword("admin-pass-123"); err != nil {
+		t.Fatal(err)
+	}
+	project := models.Project{Name: "seed", Address: "https://github.com/SeldomQA/seldom-api-testing.git", CaseDir: "test_dir"}
+	seed := []interface{}{&admin, &project}
+	for _, record := range seed {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	testCase := models.TestCase{ProjectID: project.ID, FileName: "test_dir.test_sample", ClassName: "TestSample", CaseName: "test_get", Label: "smoke", CaseHash: "seed-case-hash"}
+	task := models.TestTask{ProjectID: project.ID, Name: "seed"}
+	for _, record := range []interface{}{&testCase, &task} {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	report := models.TaskReport{TaskID: task.ID, Name: "seed", Passed: 1, Tests: 1}
+	result := models.CaseResult{CaseID: testCase.ID, Name: "seed", Passed: 1, Tests: 1}
+	for _, record := range []interface{}{&report, &result} {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return &contractServer{
+		engine: routes.Setup(cfg),
+		values: map[string]string{
+			"case_project": strconv.Itoa(int(project.ID)),
+			"case":         strconv.Itoa(int(testCase.ID)),
+			"report_task":  strconv.Itoa(int(task.ID)),
+			"report":       strconv.Itoa(int(report.ID)),
+		},
+	}
+}
+
+// expand 替换占位符
+func (s *contractServer) expand(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if strings.HasPrefix(v, "$") {
+			if n, err := strconv.Atoi(s.values[v[1:]]); err == nil {
+				return n
+			}
+		}
+		return placeholderRe.ReplaceAllStringFunc(v, func(name string) string {
+			return s.values[name[1:len(name)-1]]
+		})
+	case map[string]interface{}:
+		expanded := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			expanded[key] = s.expand(item)
+		}
+		return expanded
+	case []interface{}:
+		expanded := make([]interface{}, len(v))
+		for i, item := range v {
+			expanded[i] = s.expand(item)
+		}
+		return expanded
+	}
+	return value
+}
+
+// do 按前端的方式发送请求，token为空时不带Authorization头
+func (s *contractServer) do(t *testing.T, request frontendRequest, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	target := s.expand(request.Path).(string)
+	if len(request.Query) > 0 {
+		query := url.Values{}
+		for key, value := range request.Query {
+			query.Set(key, s.expand(value).(string))
+		}
+		target += "?" + query.Encode()
+	}
+	var body bytes.Buffer
+	if request.Body != nil {
+		if err := json.NewEncoder(&body).Encode(s.expand(request.Body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(request.Method, target, &body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Requested-With", "XMLHttpRequest")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, req)
+	return w
+}
+
+// TestDjangoContract 按前端的调用顺序发送请求样例，检查响应结构与原后端一致
+func TestDjangoContract(t *testing.T) {
+	server := newContractServer(t)
+
+	for _, request := range loadFrontendRequests(t) {
+		if request.Expect == "missing" {
+			if w := server.do(t, request, server.values["token"]); w.Code != http.StatusNotFound {
+				t.Errorf("%s: status = %d, want 404 for an endpoint the frontend never calls", request.API, w.Code)
+			}
+			continue
+		}
+
+		if !request.Public {
+			w := server.do(t, request, "")
+			var resp struct {
+				Success bool `json:"success"`
+				Error   struct {
+					Code int `json:"code"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); w.Code != http.StatusUnauthorized || err != nil || resp.Success || resp.Error.Code != 40001 {
+				t.Errorf("%s without token: status = %d, body = %s, want 401 with code 40001", request.API, w.Code, w.Body)
+			}
+		}
+
+		w := server.do(t, request, server.values["token"])
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: status = %d, body = %s, want 200", request.API, w.Code, w.Body)
+			continue
+		}
+		var resp map[string]json.RawMessage
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Errorf("%s: body %s is not a JSON object", request.API, w.Body)
+			continue
+		}
+
+		switch request.Expect {
+		case "page":
+			var page struct {
+				Success bool `json:"success"`
+				Code    *struct {
+					Code    string `json:"code"`
+					Message string `json:"message"`
+				} `json:"code"`
+				Total  *int64        `json:"total"`
+				Page   *int          `json:"page"`
+				Size   *int          `json:"size"`
+				Result []interface{} `json:"result"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil || !page.Success || page.Code == nil ||
+				page.Total == nil || page.Page == nil || page.Size == nil || page.Result == nil {
+				t.Errorf("%s: body = %s, want a page with success, code, total, page, size and result", request.API, w.Body)
+			}
+		default:
+			var envelope struct {
+				Success bool `json:"success"`
+				Error   *struct {
+					Code    int     `json:"code"`
+					Message *string `json:"message"`
+				} `json:"error"`
+				Result interface{} `json:"result"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil || envelope.Error == nil || envelope.Error.Message == nil || resp["result"] == nil {
+				t.Errorf("%s: body = %s, want success, error.code, error.message and result", request.API, w.Body)
+				continue
+			}
+			wantSuccess, wantCode := true, 20000
+			if request.Expect == "unsupported" {
+				wantSuccess, wantCode = false, 50000
+			}
+			if envelope.Success != wantSuccess || envelope.Error.Code != wantCode {
+				t.Errorf("%s: success = %v, code = %d (%s), want %v, %d", request.API, envelope.Success, envelope.Error.Code, *envelope.Error.Message, wantSuccess, wantCode)
+				continue
+			}
+			for name, field := range request.Save {
+				result, _ := envelope.Result.(map[string]interface{})
+				switch value := result[field].(type) {
+				case string:
+					server.values[name] = value
+				case float64:
+					server.values[name] = strconv.Itoa(int(value))
+				default:
+					t.Fatalf("%s: result has no %s to save as %s: %s", request.API, field, name, w.Body)
+				}
+			}
+		}
+	}
+}
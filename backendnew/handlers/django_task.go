@@ -0,0 +1,675 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"seldom-platform/database"
+	"seldom-platform/middleware"
+	"seldom-platform/models"
+	"seldom-platform/services"
+	"seldom-platform/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DjangoTaskRequest 任务请求结构，project与原后端一致以字符串传递
+type DjangoTaskRequest struct {
+	Project string   `json:"project" binding:"required"`
+	Name    string   `json:"name" binding:"required"`
+	EnvID   uint     `json:"env_id"`
+	TeamID  uint     `json:"team_id"`
+	Cases   []string `json:"cases"`
+}
+
+// DjangoReportResultRequest 报告详情查询请求结构，type为error、failure、skipped、success，为空时返回全部
+type DjangoReportResultRequest struct {
+	Type string `json:"type"`
+}
+
+// DjangoTimedRequest 定时任务请求结构，字段与APScheduler的cron触发器一致
+type DjangoTimedRequest struct {
+	TaskID    uint   `json:"task_id" binding:"required"`
+	Second    string `json:"second"`
+	Minute    string `json:"minute"`
+	Hour      string `json:"hour"`
+	Day       string `json:"day"`
+	Month     string `json:"month"`
+	DayOfWeek string `json:"day_of_week"`
+}
+
+// djangoCronJob 原后端保存在任务timed字段中的定时配置
+type djangoCronJob struct {
+	JobID     string `json:"job_id"`
+	URL       string `json:"url"`
+	Second    string `json:"second"`
+	Minute    string `json:"minute"`
+	Hour      string `json:"hour"`
+	Day       string `json:"day"`
+	Month     string `json:"month"`
+	DayOfWeek string `json:"day_of_week"`
+}
+
+// djangoTimed 任务timed字段的结构，status为running或pause
+type djangoTimed struct {
+	Status  string        `json:"status"`
+	CronJob djangoCronJob `json:"cron_job"`
+}
+
+// 定时任务状态
+const (
+	djangoTimedRunning = "running"
+	djangoTimedPause   = "pause"
+)
+
+// djangoWeekdays APScheduler中星期从周一开始计数，转换为名称后再交给cron解析
+var djangoWeekdays = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+
+// djangoWeekdayPattern 匹配星期字段中的数字，步长中的数字不转换
+var djangoWeekdayPattern = regexp.MustCompile(`(^|[,-])[0-6]\b`)
+
+// CreateTask 创建任务
+// @Summary 创建任务（Django兼容）
+// @Description 创建任务并关联用例和执行环境
+// @Tags Django兼容
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param task body DjangoTaskRequest true "任务信息"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/task/create [post]
+func (h *DjangoHandler) CreateTask(c *gin.Context) {
+	var req DjangoTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrJSONType)
+		return
+	}
+
+	projectID, err := strconv.ParseUint(req.Project, 10, 32)
+	if err != nil || !projectActive(uint(projectID)) {
+		utils.DjangoFail(c, utils.DjangoErrProjectIDNull)
+		return
+	}
+	envIDs := djangoEnvIDs(req.EnvID)
+	if err := h.taskService.ValidateTaskEnvs(uint(projectID), envIDs); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrEnvIsNull)
+		return
+	}
+
+	task := models.TestTask{
+		Name:      req.Name,
+		ProjectID: uint(projectID),
+		TeamID:    djangoTeamID(req.TeamID),
+	}
+	if err := database.GetDB().Create(&task).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+	if err := h.taskService.SetTaskEnvs(&task, envIDs); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+	if err := h.taskService.SetTaskCases(task.ID, req.Cases); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionCreate, models.AuditResourceTask, task.ID), nil, task)
+	result := djangoTask(task)
+	result["cases"] = h.taskService.TaskCaseHashes(task.ID)
+	utils.DjangoSuccess(c, result)
+}
+
+// GetTasks 获取任务列表
+// @Summary 获取任务列表（Django兼容）
+// @Description 获取当前用户有权限的任务，按创建时间倒序分页
+// @Tags Django兼容
+// @Produce json
+// @Security BearerAuth
+// @Param project_id query int false "项目ID"
+// @Param team_id query int false "团队ID"
+// @Param name query string false "任务名称"
+// @Param page query int false "页码" default(1)
+// @Param size query int false "每页数量" default(6)
+// @Success 200 {object} utils.DjangoPageResponse
+// @Router /api/task/list [get]
+func (h *DjangoHandler) GetTasks(c *gin.Context) {
+	page, size, ok := djangoPagination(c)
+	if !ok {
+		return
+	}
+
+	db := database.GetDB()
+	query := h.permissionService.FilterTasks(db.Model(&models.TestTask{}), middleware.CurrentUser(c)).
+		Where("is_delete = ?", false)
+	if projectID := c.Query("project_id"); projectID != "" {
+		query = query.Where("project_id = ?", projectID)
+	}
+	if teamID := c.Query("team_id"); teamID != "" {
+		query = query.Where("team_id = ?", teamID)
+	}
+	if name := c.Query("name"); name != "" {
+		query = query.Where("name LIKE ?", "%"+name+"%")
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var tasks []models.TestTask
+	if err := query.Order("create_time DESC").Offset((page - 1) * size).Limit(size).Find(&tasks).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	result := make([]gin.H, 0, len(tasks))
+	for _, task := range tasks {
+		result = append(result, djangoTaskOut(task))
+	}
+	utils.DjangoPage(c, result, total, page, size)
+}
+
+// GetTask 获取任务详情
+// @Summary 获取任务详情（Django兼容）
+// @Description 返回任务及其关联的用例，case_list用于前端穿梭框
+// @Tags Django兼容
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "任务ID"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/task/{id}/ [get]
+func (h *DjangoHandler) GetTask(c *gin.Context) {
+	db := database.GetDB()
+	var task models.TestTask
+	if err := db.Where("is_delete = ?", false).First(&task, c.Param("id")).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrTaskIDNull)
+		return
+	}
+
+	hashes := h.taskService.TaskCaseHashes(task.ID)
+	caseList := make([]gin.H, 0, len(hashes))
+	for _, hash := range hashes {
+		var testCase models.TestCase
+		if err := db.Where("project_id = ? AND case_hash = ?", task.ProjectID, hash).First(&testCase).Error; err != nil {
+			continue
+		}
+		caseList = append(caseList, gin.H{
+			"key":   testCase.CaseHash,
+			"label": testCase.ClassName + "." + testCase.CaseName,
+		})
+	}
+
+	result := djangoTask(task)
+	result["env"] = task.EnvID
+	result["cases"] = hashes
+	result["case_list"] = caseList
+	utils.DjangoSuccess(c, result)
+}
+
+// UpdateTask 更新任务
+// @Summary 更新任务（Django兼容）
+// @Description 更新任务名称、执行环境和团队，并替换关联的用例，任务所属项目不变
+// @Tags Django兼容
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "任务ID"
+// @Param task body DjangoTaskRequest true "任务信息"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/task/{id}/ [put]
+func (h *DjangoHandler) UpdateTask(c *gin.Context) {
+	var req DjangoTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrJSONType)
+		return
+	}
+
+	db := database.GetDB()
+	var task models.TestTask
+	if err := db.Where("is_delete = ?", false).First(&task, c.Param("id")).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrTaskIDNull)
+		return
+	}
+	before := task
+	before.EnvIDs = h.taskService.TaskEnvIDs(&task)
+
+	envIDs := djangoEnvIDs(req.EnvID)
+	if err := h.taskService.ValidateTaskEnvs(task.ProjectID, envIDs); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrEnvIsNull)
+		return
+	}
+
+	task.Name = req.Name
+	task.TeamID = djangoTeamID(req.TeamID)
+	if err := db.Save(&task).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+	if err := h.taskService.SetTaskEnvs(&task, envIDs); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+	if err := h.taskService.SetTaskCases(task.ID, req.Cases); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionUpdate, models.AuditResourceTask, task.ID), before, task)
+	result := djangoTask(task)
+	result["cases"] = h.taskService.TaskCaseHashes(task.ID)
+	utils.DjangoSuccess(c, result)
+}
+
+// DeleteTask 删除任务
+// @Summary 删除任务（Django兼容）
+// @Description 将任务移入回收站，并从定时调度中移除
+// @Tags Django兼容
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "任务ID"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/task/{id}/ [delete]
+func (h *DjangoHandler) DeleteTask(c *gin.Context) {
+	var task models.TestTask
+	if err := database.GetDB().Where("is_delete = ?", false).First(&task, c.Param("id")).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrTaskIDNull)
+		return
+	}
+
+	if err := h.trashService.DeleteTask(&task); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionDelete, models.AuditResourceTask, task.ID), task, nil)
+	utils.DjangoSuccess(c, nil)
+}
+
+// RunTask 执行任务
+// @Summary 执行任务（Django兼容）
+// @Description 异步执行任务，任务正在执行时返回错误
+// @Tags Django兼容
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "任务ID"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/task/{id}/running [get]
+func (h *DjangoHandler) RunTask(c *gin.Context) {
+	var task models.TestTask
+	if err := database.GetDB().Where("is_delete = ?", false).First(&task, c.Param("id")).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrTaskIDNull)
+		return
+	}
+	if task.Status == 1 {
+		utils.DjangoFail(c, utils.DjangoErrTaskRunning)
+		return
+	}
+
+	runID := services.NewRunID()
+	if err := h.taskService.StartTaskRun(c.Request.Context(), task.ID, runID); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem.WithMessage(err.Error()))
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionRun, models.AuditResourceTask, task.ID), nil, nil)
+	utils.DjangoSuccess(c, gin.H{"run_id": runID})
+}
+
+// GetTaskReports 获取任务报告列表
+// @Summary 获取任务报告列表（Django兼容）
+// @Description 按创建时间倒序分页返回任务的报告
+// @Tags Django兼容
+// @Produce json
+// @Security BearerAuth
+// @Param task_id query int true "任务ID"
+// @Param page query int false "页码" default(1)
+// @Param size query int false "每页数量" default(6)
+// @Success 200 {object} utils.DjangoPageResponse
+// @Router /api/task/reports [get]
+func (h *DjangoHandler) GetTaskReports(c *gin.Context) {
+	page, size, ok := djangoPagination(c)
+	if !ok {
+		return
+	}
+
+	query := database.GetDB().Model(&models.TaskReport{}).Where("task_id = ?", c.Query("task_id"))
+
+	var total int64
+	query.Count(&total)
+
+	var reports []models.TaskReport
+	if err := query.Order("create_time DESC").Offset((page - 1) * size).Limit(size).Find(&reports).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	result := make([]gin.H, 0, len(reports))
+	for _, report := range reports {
+		result = append(result, gin.H{
+			"id":          report.ID,
+			"name":        report.Name,
+			"passed":      report.Passed,
+			"error":       report.Error,
+			"failure":     report.Failure,
+			"skipped":     report.Skipped,
+			"tests":       report.Tests,
+			"run_time":    report.RunTime,
+			"create_time": utils.DjangoTime(report.CreateTime),
+		})
+	}
+	utils.DjangoPage(c, result, total, page, size)
+}
+
+// GetReportResults 获取报告的用例结果
+// @Summary 获取报告的用例结果（Django兼容）
+// @Description 按结果类型筛选报告中的用例
+// @Tags Django兼容
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "报告ID"
+// @Param result body DjangoReportResultRequest true "结果类型"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/task/report/{id}/results [post]
+func (h *DjangoHandler) GetReportResults(c *gin.Context) {
+	var req DjangoReportResultRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrJSONType)
+		return
+	}
+
+	var details []models.ReportDetails
+	if err := database.GetDB().Where("result_id = ?", c.Param("id")).Order("create_time DESC").Find(&details).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrSystem)
+		return
+	}
+
+	result := make([]gin.H, 0, len(details))
+	for _, detail := range details {
+		var matched bool
+		switch req.Type {
+		case "error":
+			matched = detail.ErrorOut != ""
+		case "failure":
+			matched = detail.FailureMessage != ""
+		case "skipped":
+			matched = detail.SkippedMessage != ""
+		case "success":
+			matched = detail.ErrorOut == "" && detail.FailureMessage == "" && detail.SkippedMessage == ""
+		default:
+			matched = true
+		}
+		if matched {
+			result = append(result, djangoReportDetail(detail))
+		}
+	}
+	utils.DjangoSuccess(c, result)
+}
+
+// CreateTimed 创建定时任务
+// @Summary 创建定时任务（Django兼容）
+// @Description 按APScheduler的cron字段设置任务的定时执行，并加入调度
+// @Tags Django兼容
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param timed body DjangoTimedRequest true "定时配置"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/task/timed/create [post]
+func (h *DjangoHandler) CreateTimed(c *gin.Context) {
+	var req DjangoTimedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrJSONType)
+		return
+	}
+
+	db := database.GetDB()
+	var task models.TestTask
+	if err := db.Where("is_delete = ?", false).First(&task, req.TaskID).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrTaskIDNull)
+		return
+	}
+
+	job := djangoCronJob{
+		JobID:     fmt.Sprintf("cron_task_%d", task.ID),
+		URL:       fmt.Sprintf("/api/task/%d/running", task.ID),
+		Second:    djangoCronField(req.Second, "0"),
+		Minute:    djangoCronField(req.Minute, "*"),
+		Hour:      djangoCronField(req.Hour, "*"),
+		Day:       djangoCronField(req.Day, "*"),
+		Month:     djangoCronField(req.Month, "*"),
+		DayOfWeek: djangoCronField(req.DayOfWeek, "*"),
+	}
+	expression := job.expression()
+	if err := services.ValidateCron(expression); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrTimedAdd.WithMessage(fmt.Sprintf("定时任务添加失败: %v", err)))
+		return
+	}
+
+	h.updateTimed(c, &task, djangoTimed{Status: djangoTimedRunning, CronJob: job}, utils.DjangoErrTimedAdd)
+}
+
+// SwitchTimed 暂停或恢复定时任务
+// @Summary 暂停或恢复定时任务（Django兼容）
+// @Tags Django兼容
+// @Produce json
+// @Security BearerAuth
+// @Param task_id query int true "任务ID"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/task/timed/switch [put]
+func (h *DjangoHandler) SwitchTimed(c *gin.Context) {
+	db := database.GetDB()
+	var task models.TestTask
+	if err := db.Where("is_delete = ?", false).First(&task, c.Query("task_id")).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrTaskIDNull)
+		return
+	}
+
+	var timed djangoTimed
+	if task.Timed == "" || json.Unmarshal([]byte(task.Timed), &timed) != nil {
+		utils.DjangoFail(c, utils.DjangoErrTimedUpdate)
+		return
+	}
+	if timed.Status == djangoTimedRunning {
+		timed.Status = djangoTimedPause
+	} else {
+		timed.Status = djangoTimedRunning
+	}
+
+	h.updateTimed(c, &task, timed, utils.DjangoErrTimedUpdate)
+}
+
+// DeleteTimed 删除定时任务
+// @Summary 删除定时任务（Django兼容）
+// @Tags Django兼容
+// @Produce json
+// @Security BearerAuth
+// @Param task_id query int true "任务ID"
+// @Success 200 {object} utils.DjangoResponse
+// @Router /api/task/timed/delete [delete]
+func (h *DjangoHandler) DeleteTimed(c *gin.Context) {
+	db := database.GetDB()
+	var task models.TestTask
+	if err := db.Where("is_delete = ?", false).First(&task, c.Query("task_id")).Error; err != nil {
+		utils.DjangoFail(c, utils.DjangoErrTaskIDNull)
+		return
+	}
+	before := task
+
+	err := db.Model(&task).Updates(map[string]interface{}{
+		"timed":           "",
+		"is_scheduled":    false,
+		"cron_expression": "",
+	}).Error
+	if err != nil {
+		utils.DjangoFail(c, utils.DjangoErrTimedDelete)
+		return
+	}
+	if err := reloadScheduler(); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrTimedTask)
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionUpdate, models.AuditResourceTask, task.ID), before, task)
+	utils.DjangoSuccess(c, nil)
+}
+
+// updateTimed 保存任务的定时配置并重新加载调度，暂停的定时任务不参与调度
+func (h *DjangoHandler) updateTimed(c *gin.Context, task *models.TestTask, timed djangoTimed, failure utils.DjangoError) {
+	before := *task
+	data, err := json.Marshal(timed)
+	if err != nil {
+		utils.DjangoFail(c, failure)
+		return
+	}
+
+	err = database.GetDB().Model(task).Updates(map[string]interface{}{
+		"timed":           string(data),
+		"is_scheduled":    timed.Status == djangoTimedRunning,
+		"cron_expression": timed.CronJob.expression(),
+	}).Error
+	if err != nil {
+		utils.DjangoFail(c, failure)
+		return
+	}
+	if err := reloadScheduler(); err != nil {
+		utils.DjangoFail(c, utils.DjangoErrTimedTask)
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionUpdate, models.AuditResourceTask, task.ID), before, *task)
+	utils.DjangoSuccess(c, nil)
+}
+
+// expression 将APScheduler的cron字段转换为调度器使用的6字段cron表达式
+func (job djangoCronJob) expression() string {
+	dayOfWeek := djangoWeekdayPattern.ReplaceAllStringFunc(job.DayOfWeek, func(match string) string {
+		prefix, day := match[:len(match)-1], match[len(match)-1]-'0'
+		return prefix + djangoWeekdays[day]
+	})
+	return fmt.Sprintf("%s %s %s %s %s %s", job.Second, job.Minute, job.Hour, job.Day, job.Month, dayOfWeek)
+}
+
+// reloadScheduler 重新加载定时任务，调度服务未启动时跳过
+func reloadScheduler() error {
+	if services.GlobalScheduler == nil {
+		return nil
+	}
+	return services.GlobalScheduler.Reload()
+}
+
+// djangoCronField 获取cron字段，未填写时使用默认值
+func djangoCronField(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// djangoPagination 解析分页参数，默认每页6条，参数错误时返回错误响应
+func djangoPagination(c *gin.Context) (int, int, bool) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		utils.DjangoFail(c, utils.DjangoErrParamsType)
+		return 0, 0, false
+	}
+	size, err := strconv.Atoi(c.DefaultQuery("size", "6"))
+	if err != nil || size < 1 || size > 100 {
+		utils.DjangoFail(c, utils.DjangoErrParamsType)
+		return 0, 0, false
+	}
+	return page, size, true
+}
+
+// djangoEnvIDs 原后端任务只有一个执行环境
+func djangoEnvIDs(envID uint) []uint {
+	if envID == 0 {
+		return []uint{}
+	}
+	return []uint{envID}
+}
+
+// djangoTeamID 团队ID为0表示不分配团队
+func djangoTeamID(teamID uint) *uint {
+	if teamID == 0 {
+		return nil
+	}
+	return &teamID
+}
+
+// djangoTask 按原后端model_to_dict的字段输出任务
+func djangoTask(task models.TestTask) gin.H {
+	return gin.H{
+		"id":            task.ID,
+		"project":       task.ProjectID,
+		"name":          task.Name,
+		"status":        task.Status,
+		"env_id":        task.EnvID,
+		"team_id":       task.TeamID,
+		"email":         task.Email,
+		"timed":         task.Timed,
+		"execute_count": task.ExecuteCount,
+		"is_delete":     task.IsDelete,
+		"create_time":   utils.DjangoTime(task.CreateTime),
+		"update_time":   utils.DjangoTime(task.UpdateTime),
+	}
+}
+
+// djangoTaskOut 按原后端任务列表的字段输出任务，env和team为名称
+func djangoTaskOut(task models.TestTask) gin.H {
+	db := database.GetDB()
+	result := gin.H{
+		"id":            task.ID,
+		"name":          task.Name,
+		"status":        task.Status,
+		"env_id":        task.EnvID,
+		"env":           "",
+		"team_id":       task.TeamID,
+		"team":          "",
+		"timed_status":  "",
+		"timed_conf":    gin.H{},
+		"email":         task.Email,
+		"execute_count": task.ExecuteCount,
+		"create_time":   utils.DjangoTime(task.CreateTime),
+		"update_time":   utils.DjangoTime(task.UpdateTime),
+		"project_id":    task.ProjectID,
+	}
+
+	if task.EnvID != nil {
+		var env models.Env
+		if db.Select("name").First(&env, *task.EnvID).Error == nil {
+			result["env"] = env.Name
+		}
+	}
+	if task.TeamID != nil {
+		var team models.Team
+		if db.Select("name").First(&team, *task.TeamID).Error == nil {
+			result["team"] = team.Name
+		}
+	}
+	var timed djangoTimed
+	if task.Timed != "" && json.Unmarshal([]byte(task.Timed), &timed) == nil {
+		result["timed_status"] = timed.Status
+		result["timed_conf"] = timed.CronJob
+	}
+	return result
+}
+
+// djangoReportDetail 按原后端ReportDetails的字段输出用例结果
+func djangoReportDetail(detail models.ReportDetails) gin.H {
+	return gin.H{
+		"id":              detail.ID,
+		"result":          detail.ResultID,
+		"class_name":      detail.ClassName,
+		"name":            detail.Name,
+		"run_time":        detail.Time,
+		"doc":             "",
+		"system_out":      "",
+		"system_err":      "",
+		"failure_out":     detail.FailureMessage,
+		"error_out":       detail.ErrorOut,
+		"skipped_message": detail.SkippedMessage,
+		"create_time":     utils.DjangoTime(detail.CreateTime),
+	}
+}
@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"seldom-platform/config"
 	"seldom-platform/database"
+	"seldom-platform/middleware"
 	"seldom-platform/models"
+	"seldom-platform/services"
 	"seldom-platform/utils"
 	"strconv"
 
@@ -10,11 +14,21 @@ import (
 )
 
 // EnvHandler 环境处理器
-type EnvHandler struct{}
+type EnvHandler struct {
+	permissionService *services.PermissionService
+	auditService      *services.AuditService
+	trashService      *services.TrashService
+	variableService   *services.EnvVariableService
+}
 
 // NewEnvHandler 创建环境处理器
-func NewEnvHandler() *EnvHandler {
-	return &EnvHandler{}
+func NewEnvHandler(cfg *config.Config) *EnvHandler {
+	return &EnvHandler{
+		permissionService: services.NewPermissionService(),
+		auditService:      services.NewAuditService(),
+		trashService:      services.NewTrashService(),
+		variableService:   services.NewEnvVariableService(cfg),
+	}
 }
 
 // CreateEnvRequest 创建环境请求结构
@@ -24,7 +38,7 @@ type CreateEnvRequest struct {
 	Port        int    `json:"port"`
 	Protocol    string `json:"protocol"`
 	Description string `json:"description"`
-	Project     uint   `json:"project" binding:"required"`
+	Project     uint   `json:"project"` // 所属项目，不传表示全局环境（仅员工可创建）
 }
 
 // UpdateEnvRequest 更新环境请求结构
@@ -34,18 +48,24 @@ type UpdateEnvRequest struct {
 	Port        int    `json:"port"`
 	Protocol    string `json:"protocol"`
 	Description string `json:"description"`
-	Project     uint   `json:"project"`
+	Project     uint   `json:"project"` // 不传表示不修改所属项目
+}
+
+// SetEnvVariablesRequest 设置环境变量请求结构
+type SetEnvVariablesRequest struct {
+	Variables []services.EnvVariableInput `json:"variables"`
 }
 
 // GetEnvs 获取环境列表
 // @Summary 获取环境列表
-// @Description 获取环境列表，支持分页和筛选
+// @Description 获取环境列表，支持分页和筛选；指定项目时返回该项目的环境和全局环境
 // @Tags 环境管理
 // @Produce json
 // @Security BearerAuth
 // @Param page query int false "页码" default(1)
 // @Param size query int false "每页数量" default(10)
 // @Param project query int false "项目ID"
+// @Param global query bool false "只返回全局环境"
 // @Success 200 {object} utils.PageResponse{data=[]models.Env}
 // @Failure 401 {object} utils.Response
 // @Router /api/envs [get]
@@ -66,10 +86,14 @@ func (h *EnvHandler) GetEnvs(c *gin.Context) {
 
 	offset := (page - 1) * size
 
-	// 构建查询
-	query := db.Model(&models.Env{})
+	// 构建查询，只返回全局环境和当前用户有权限的项目的环境
+	query := h.permissionService.FilterEnvs(db.Model(&models.Env{}), middleware.CurrentUser(c)).
+		Where("is_delete = ?", false)
 	if projectID != "" {
-		query = query.Where("project = ?", projectID)
+		query = query.Where("(project_id = ? OR project_id IS NULL)", projectID)
+	}
+	if c.Query("global") == "true" {
+		query = query.Where("project_id IS NULL")
 	}
 
 	// 获取总数
@@ -101,7 +125,7 @@ func (h *EnvHandler) GetEnv(c *gin.Context) {
 	db := database.GetDB()
 
 	var env models.Env
-	if err := db.First(&env, id).Error; err != nil {
+	if err := db.Where("is_delete = ?", false).First(&env, id).Error; err != nil {
 		utils.NotFound(c, "Environment not found")
 		return
 	}
@@ -111,7 +135,7 @@ func (h *EnvHandler) GetEnv(c *gin.Context) {
 
 // CreateEnv 创建环境
 // @Summary 创建环境
-// @Description 创建新的环境
+// @Description 创建项目环境或全局环境，同一项目（或全局）内环境名不能重复
 // @Tags 环境管理
 // @Accept json
 // @Produce json
@@ -129,9 +153,17 @@ func (h *EnvHandler) CreateEnv(c *gin.Context) {
 
 	db := database.GetDB()
 
+	var projectID *uint
+	if req.Project != 0 {
+		if !projectActive(req.Project) {
+			utils.BadRequest(c, "Project not found")
+			return
+		}
+		projectID = &req.Project
+	}
+
 	// 检查环境名在同一项目下是否已存在
-	var existingEnv models.Env
-	if err := db.Where("name = ?", req.Name).First(&existingEnv).Error; err == nil {
+	if envNameExists(req.Name, projectID, 0) {
 		utils.BadRequest(c, "Environment name already exists")
 		return
 	}
@@ -139,6 +171,7 @@ func (h *EnvHandler) CreateEnv(c *gin.Context) {
 	// 创建环境
 	env := models.Env{
 		Name:         req.Name,
+		ProjectID:    projectID,
 		TestType:     "http",
 		Env:          req.Protocol + "://" + req.Host,
 		BaseURL:      req.Protocol + "://" + req.Host,
@@ -150,6 +183,7 @@ func (h *EnvHandler) CreateEnv(c *gin.Context) {
 		return
 	}
 
+	h.auditService.Record(auditEntry(c, models.AuditActionCreate, models.AuditResourceEnv, env.ID), nil, env)
 	utils.SuccessWithMessage(c, "Environment created successfully", env)
 }
 
@@ -177,15 +211,27 @@ func (h *EnvHandler) UpdateEnv(c *gin.Context) {
 	db := database.GetDB()
 	var env models.Env
 
-	if err := db.First(&env, id).Error; err != nil {
+	if err := db.Where("is_delete = ?", false).First(&env, id).Error; err != nil {
 		utils.NotFound(c, "Environment not found")
 		return
 	}
+	before := env
 
 	// 更新环境信息
+	if req.Project != 0 && (env.ProjectID == nil || *env.ProjectID != req.Project) {
+		if !projectActive(req.Project) {
+			utils.BadRequest(c, "Project not found")
+			return
+		}
+		env.ProjectID = &req.Project
+	}
 	if req.Name != "" {
 		env.Name = req.Name
 	}
+	if envNameExists(env.Name, env.ProjectID, env.ID) {
+		utils.BadRequest(c, "Environment name already exists")
+		return
+	}
 	if req.Host != "" || req.Protocol != "" {
 		// 更新BaseURL和Env字段
 		protocol := req.Protocol
@@ -221,12 +267,13 @@ func (h *EnvHandler) UpdateEnv(c *gin.Context) {
 		return
 	}
 
+	h.auditService.Record(auditEntry(c, models.AuditActionUpdate, models.AuditResourceEnv, env.ID), before, env)
 	utils.SuccessWithMessage(c, "Environment updated successfully", env)
 }
 
 // DeleteEnv 删除环境
 // @Summary 删除环境
-// @Description 删除环境
+// @Description 将环境移入回收站
 // @Tags 环境管理
 // @Produce json
 // @Security BearerAuth
@@ -239,15 +286,132 @@ func (h *EnvHandler) DeleteEnv(c *gin.Context) {
 	db := database.GetDB()
 
 	var env models.Env
-	if err := db.First(&env, id).Error; err != nil {
+	if err := db.Where("is_delete = ?", false).First(&env, id).Error; err != nil {
 		utils.NotFound(c, "Environment not found")
 		return
 	}
 
-	if err := db.Delete(&env).Error; err != nil {
+	if err := h.trashService.DeleteEnv(&env); err != nil {
 		utils.InternalServerError(c, "Failed to delete environment")
 		return
 	}
 
+	h.auditService.Record(auditEntry(c, models.AuditActionDelete, models.AuditResourceEnv, env.ID), env, nil)
 	utils.SuccessWithMessage(c, "Environment deleted successfully", nil)
-}
\ No newline at end of file
+}
+// RestoreEnv 恢复环境
+// @Summary 恢复环境
+// @Description 从回收站恢复环境
+// @Tags 环境管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "环境ID"
+// @Success 200 {object} utils.Response{data=models.Env}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/envs/{id}/restore [post]
+func (h *EnvHandler) RestoreEnv(c *gin.Context) {
+	id := c.Param("id")
+	db := database.GetDB()
+
+	var env models.Env
+	if err := db.Where("is_delete = ?", true).First(&env, id).Error; err != nil {
+		utils.NotFound(c, "Environment not found in trash")
+		return
+	}
+	before := env
+
+	if err := h.trashService.RestoreEnv(&env); err != nil {
+		if errors.Is(err, services.ErrNameConflict) {
+			utils.BadRequest(c, "Environment name already exists")
+			return
+		}
+		if errors.Is(err, services.ErrParentDeleted) {
+			utils.BadRequest(c, "Project is deleted, restore the project first")
+			return
+		}
+		utils.InternalServerError(c, "Failed to restore environment")
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionRestore, models.AuditResourceEnv, env.ID), before, env)
+	utils.SuccessWithMessage(c, "Environment restored successfully", env)
+}
+
+// envNameExists 检查同一项目（projectID为nil时为全局）内是否已有同名的未删除环境
+func envNameExists(name string, projectID *uint, excludeID uint) bool {
+	query := database.GetDB().Model(&models.Env{}).Where("name = ? AND is_delete = ? AND id <> ?", name, false, excludeID)
+	if projectID == nil {
+		query = query.Where("project_id IS NULL")
+	} else {
+		query = query.Where("project_id = ?", *projectID)
+	}
+
+	var count int
+	query.Count(&count)
+	return count > 0
+}
+
+// GetEnvVariables 获取环境变量
+// @Summary 获取环境变量
+// @Description 获取环境的变量列表，密钥变量的值以******代替
+// @Tags 环境管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "环境ID"
+// @Success 200 {object} utils.Response{data=[]models.EnvVariable}
+// @Failure 404 {object} utils.Response
+// @Router /api/envs/{id}/variables [get]
+func (h *EnvHandler) GetEnvVariables(c *gin.Context) {
+	var env models.Env
+	if err := database.GetDB().Where("is_delete = ?", false).First(&env, c.Param("id")).Error; err != nil {
+		utils.NotFound(c, "Environment not found")
+		return
+	}
+
+	variables, err := h.variableService.ListVariables(env.ID)
+	if err != nil {
+		utils.InternalServerError(c, "Failed to fetch environment variables")
+		return
+	}
+
+	utils.Success(c, services.MaskVariables(variables))
+}
+
+// SetEnvVariables 设置环境变量
+// @Summary 设置环境变量
+// @Description 以请求中的变量替换环境的全部变量；密钥变量加密存储，值为空或为******时保留原值
+// @Tags 环境管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "环境ID"
+// @Param variables body SetEnvVariablesRequest true "环境变量"
+// @Success 200 {object} utils.Response{data=[]models.EnvVariable}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/envs/{id}/variables [put]
+func (h *EnvHandler) SetEnvVariables(c *gin.Context) {
+	var req SetEnvVariablesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request format")
+		return
+	}
+
+	var env models.Env
+	if err := database.GetDB().Where("is_delete = ?", false).First(&env, c.Param("id")).Error; err != nil {
+		utils.NotFound(c, "Environment not found")
+		return
+	}
+
+	before, after, err := h.variableService.SetVariables(env.ID, req.Variables)
+	if err != nil {
+		respondUserError(c, err, "Failed to update environment variables")
+		return
+	}
+
+	variables := services.MaskVariables(after)
+	h.auditService.Record(auditEntry(c, models.AuditActionUpdate, models.AuditResourceEnv, env.ID),
+		gin.H{"variables": services.MaskVariables(before)}, gin.H{"variables": variables})
+	utils.SuccessWithMessage(c, "Environment variables updated successfully", variables)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"seldom-platform/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 预压缩文件的后缀，按优先级排列
+var frontendEncodings = []struct {
+	name   string
+	suffix string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// FrontendHandler 前端页面处理器，提供单页应用的静态文件
+type FrontendHandler struct {
+	files fs.FS
+}
+
+// NewFrontendHandler 创建前端页面处理器，files为前端构建产物目录
+func NewFrontendHandler(files fs.FS) *FrontendHandler {
+	return &FrontendHandler{files: files}
+}
+
+// Serve 返回前端静态文件
+// 不存在且没有扩展名的路径返回index.html，交给前端路由（history模式）处理；
+// assets目录下的文件名带有内容哈希，设置长期缓存；浏览器支持时优先返回预压缩的.br/.gz文件
+func (h *FrontendHandler) Serve(c *gin.Context) {
+	urlPath := c.Request.URL.Path
+	if urlPath == "/api" || strings.HasPrefix(urlPath, "/api/") || strings.HasPrefix(urlPath, "/swagger/") {
+		utils.NotFound(c, "接口不存在")
+		return
+	}
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		c.Status(http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	if name == "" || !h.isFile(name) {
+		if path.Ext(name) != "" {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		name = "index.html"
+	}
+
+	if strings.HasPrefix(name, "assets/") {
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		c.Header("Cache-Control", "no-cache")
+	}
+	h.serveFile(c, name)
+}
+
+// serveFile 返回文件内容，存在预压缩文件且浏览器支持对应编码时返回压缩后的内容
+func (h *FrontendHandler) serveFile(c *gin.Context, name string) {
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	fileName := name
+	for _, encoding := range frontendEncodings {
+		if !h.isFile(name + encoding.suffix) {
+			continue
+		}
+		c.Header("Vary", "Accept-Encoding")
+		if acceptsEncoding(c.GetHeader("Accept-Encoding"), encoding.name) {
+			c.Header("Content-Encoding", encoding.name)
+			fileName = name + encoding.suffix
+			break
+		}
+	}
+
+	file, err := h.files.Open(fileName)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+
+	var modTime time.Time
+	if info, err := file.Stat(); err == nil {
+		modTime = info.ModTime()
+	}
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+
+	c.Header("Content-Type", contentType)
+	http.ServeContent(c.Writer, c.Request, name, modTime, content)
+}
+
+// isFile 判断文件是否存在且不是目录
+func (h *FrontendHandler) isFile(name string) bool {
+	info, err := fs.Stat(h.files, name)
+	return err == nil && !info.IsDir()
+}
+
+// acceptsEncoding 判断Accept-Encoding是否接受指定编码，q=0表示不接受
+func acceptsEncoding(header, encoding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(fields[0]), encoding) {
+			continue
+		}
+		for _, param := range fields[1:] {
+			param = strings.ReplaceAll(param, " ", "")
+			if param == "q=0" || strings.HasPrefix(param, "q=0.") && strings.Trim(param[4:], "0") == "" {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"seldom-platform/config"
+	"seldom-platform/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler 健康检查处理器，供Kubernetes等探针使用
+type HealthHandler struct {
+	healthService *services.HealthService
+}
+
+// NewHealthHandler 创建健康检查处理器
+func NewHealthHandler(cfg *config.Config) *HealthHandler {
+	return &HealthHandler{
+		healthService: services.NewHealthService(cfg),
+	}
+}
+
+// Livez 存活检查
+// @Summary 存活检查
+// @Description 检查进程是否存活（调度循环能否响应），不检查数据库等外部依赖；失败时返回503，应重启实例
+// @Tags 健康检查
+// @Produce json
+// @Success 200 {object} services.HealthReport
+// @Failure 503 {object} services.HealthReport
+// @Router /livez [get]
+func (h *HealthHandler) Livez(c *gin.Context) {
+	writeHealthReport(c, h.healthService.Liveness(c.Request.Context()))
+}
+
+// Readyz 就绪检查
+// @Summary 就绪检查
+// @Description 检查数据库连接和迁移版本、调度器、执行器并发、工作目录磁盘空间和Redis（HEALTH_REDIS开启时），返回各组件状态；有检查项失败时返回503，应摘除流量
+// @Tags 健康检查
+// @Produce json
+// @Success 200 {object} services.HealthReport
+// @Failure 503 {object} services.HealthReport
+// @Router /readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	writeHealthReport(c, h.healthService.Readiness(c.Request.Context()))
+}
+
+// writeHealthReport 返回检查结果，有检查项失败时状态码为503
+func writeHealthReport(c *gin.Context, report *services.HealthReport) {
+	if !report.OK() {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,39 @@
+package handlers_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"seldom-platform/config"
+	"seldom-platform/database"
+)
+
+// newTestConfig 返回test profile的默认配置，数据库为临时目录中的SQLite文件
+func newTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default("test")
+	cfg.JWT.Secret = "test-secret-key-with-at-least-32-chars"
+	cfg.Database = config.DatabaseConfig{
+		Driver:      "sqlite3",
+		Database:    filepath.Join(t.TempDir(), "test.sqlite3"),
+		AutoMigrate: true,
+	}
+	cfg.Security.SecretKey = "test-encryption-key"
+	cfg.Log.Dir = ""
+	cfg.Run.Workspace = t.TempDir()
+	return cfg
+}
+
+// setupTestDB 初始化临时数据库并执行全部迁移，测试结束时关闭
+func setupTestDB(t *testing.T, cfg *config.Config) *gorm.DB {
+	t.Helper()
+	db, err := database.Init(cfg.Database)
+	if err != nil {
+		t.Fatalf("init database: %v", err)
+	}
+	t.Cleanup(func() { database.Close(db) })
+	return db
+}
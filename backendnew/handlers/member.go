@@ -0,0 +1,354 @@
+package handlers
+
+import (
+	"seldom-platform/database"
+	"seldom-platform/models"
+	"seldom-platform/services"
+	"seldom-platform/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MemberHandler 成员管理处理器
+type MemberHandler struct {
+	permissionService *services.PermissionService
+	auditService      *services.AuditService
+}
+
+// NewMemberHandler 创建成员管理处理器
+func NewMemberHandler() *MemberHandler {
+	return &MemberHandler{
+		permissionService: services.NewPermissionService(),
+		auditService:      services.NewAuditService(),
+	}
+}
+
+// AddMemberRequest 添加成员请求结构
+type AddMemberRequest struct {
+	UserID uint   `json:"user_id" binding:"required"`
+	Role   string `json:"role" binding:"required"`
+}
+
+// UpdateMemberRequest 更新成员请求结构
+type UpdateMemberRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// GetProjectMembers 获取项目成员列表
+// @Summary 获取项目成员列表
+// @Description 获取项目成员及其角色
+// @Tags 成员管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "项目ID"
+// @Success 200 {object} utils.Response{data=[]models.ProjectMember}
+// @Failure 403 {object} utils.Response
+// @Router /api/projects/{id}/members [get]
+func (h *MemberHandler) GetProjectMembers(c *gin.Context) {
+	id := c.Param("id")
+	db := database.GetDB()
+
+	var members []models.ProjectMember
+	if err := db.Preload("User").Where("project_id = ?", id).Order("id ASC").Find(&members).Error; err != nil {
+		utils.InternalServerError(c, "Failed to fetch project members")
+		return
+	}
+
+	utils.Success(c, members)
+}
+
+// AddProjectMember 添加项目成员
+// @Summary 添加项目成员
+// @Description 添加项目成员，成员已存在时更新角色，角色可选 owner/maintainer/runner/viewer
+// @Tags 成员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "项目ID"
+// @Param member body AddMemberRequest true "成员信息"
+// @Success 200 {object} utils.Response{data=models.ProjectMember}
+// @Failure 400 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Router /api/projects/{id}/members [post]
+func (h *MemberHandler) AddProjectMember(c *gin.Context) {
+	projectID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req AddMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request format")
+		return
+	}
+	if !models.IsValidRole(req.Role) {
+		utils.BadRequest(c, "Invalid role")
+		return
+	}
+	if !userExists(req.UserID) {
+		utils.NotFound(c, "User not found")
+		return
+	}
+
+	scope := services.PermissionScope{ProjectID: uint(projectID)}
+	if h.isLastOwner(scope, h.permissionService.ProjectRole(req.UserID, uint(projectID)), req.Role) {
+		utils.BadRequest(c, "Project must have at least one owner")
+		return
+	}
+
+	member, err := h.permissionService.AddProjectMember(uint(projectID), req.UserID, req.Role)
+	if err != nil {
+		utils.InternalServerError(c, "Failed to add project member")
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionCreate, models.AuditResourceProjectMember, member.ID), nil, member)
+	utils.SuccessWithMessage(c, "Project member added successfully", member)
+}
+
+// UpdateProjectMember 更新项目成员角色
+// @Summary 更新项目成员角色
+// @Description 更新项目成员角色
+// @Tags 成员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "项目ID"
+// @Param user_id path int true "用户ID"
+// @Param member body UpdateMemberRequest true "角色信息"
+// @Success 200 {object} utils.Response{data=models.ProjectMember}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/projects/{id}/members/{user_id} [put]
+func (h *MemberHandler) UpdateProjectMember(c *gin.Context) {
+	var req UpdateMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request format")
+		return
+	}
+	if !models.IsValidRole(req.Role) {
+		utils.BadRequest(c, "Invalid role")
+		return
+	}
+
+	db := database.GetDB()
+	var member models.ProjectMember
+	if err := db.Where("project_id = ? AND user_id = ?", c.Param("id"), c.Param("user_id")).First(&member).Error; err != nil {
+		utils.NotFound(c, "Project member not found")
+		return
+	}
+
+	if h.isLastOwner(services.PermissionScope{ProjectID: member.ProjectID}, member.Role, req.Role) {
+		utils.BadRequest(c, "Project must have at least one owner")
+		return
+	}
+
+	before := member
+	member.Role = req.Role
+	if err := db.Save(&member).Error; err != nil {
+		utils.InternalServerError(c, "Failed to update project member")
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionUpdate, models.AuditResourceProjectMember, member.ID), before, member)
+	utils.SuccessWithMessage(c, "Project member updated successfully", member)
+}
+
+// RemoveProjectMember 移除项目成员
+// @Summary 移除项目成员
+// @Description 移除项目成员，不能移除最后一个owner
+// @Tags 成员管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "项目ID"
+// @Param user_id path int true "用户ID"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/projects/{id}/members/{user_id} [delete]
+func (h *MemberHandler) RemoveProjectMember(c *gin.Context) {
+	db := database.GetDB()
+
+	var member models.ProjectMember
+	if err := db.Where("project_id = ? AND user_id = ?", c.Param("id"), c.Param("user_id")).First(&member).Error; err != nil {
+		utils.NotFound(c, "Project member not found")
+		return
+	}
+
+	if h.isLastOwner(services.PermissionScope{ProjectID: member.ProjectID}, member.Role, "") {
+		utils.BadRequest(c, "Project must have at least one owner")
+		return
+	}
+
+	if err := db.Delete(&member).Error; err != nil {
+		utils.InternalServerError(c, "Failed to remove project member")
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionDelete, models.AuditResourceProjectMember, member.ID), member, nil)
+	utils.SuccessWithMessage(c, "Project member removed successfully", nil)
+}
+
+// GetTeamMembers 获取团队成员列表
+// @Summary 获取团队成员列表
+// @Description 获取团队成员及其角色
+// @Tags 成员管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "团队ID"
+// @Success 200 {object} utils.Response{data=[]models.TeamMember}
+// @Failure 403 {object} utils.Response
+// @Router /api/teams/{id}/members [get]
+func (h *MemberHandler) GetTeamMembers(c *gin.Context) {
+	id := c.Param("id")
+	db := database.GetDB()
+
+	var members []models.TeamMember
+	if err := db.Preload("User").Where("team_id = ?", id).Order("id ASC").Find(&members).Error; err != nil {
+		utils.InternalServerError(c, "Failed to fetch team members")
+		return
+	}
+
+	utils.Success(c, members)
+}
+
+// AddTeamMember 添加团队成员
+// @Summary 添加团队成员
+// @Description 添加团队成员，成员已存在时更新角色，角色可选 owner/maintainer/runner/viewer
+// @Tags 成员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "团队ID"
+// @Param member body AddMemberRequest true "成员信息"
+// @Success 200 {object} utils.Response{data=models.TeamMember}
+// @Failure 400 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Router /api/teams/{id}/members [post]
+func (h *MemberHandler) AddTeamMember(c *gin.Context) {
+	teamID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req AddMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request format")
+		return
+	}
+	if !models.IsValidRole(req.Role) {
+		utils.BadRequest(c, "Invalid role")
+		return
+	}
+	if !userExists(req.UserID) {
+		utils.NotFound(c, "User not found")
+		return
+	}
+
+	scope := services.PermissionScope{TeamID: uint(teamID)}
+	if h.isLastOwner(scope, h.permissionService.TeamRole(req.UserID, uint(teamID)), req.Role) {
+		utils.BadRequest(c, "Team must have at least one owner")
+		return
+	}
+
+	member, err := h.permissionService.AddTeamMember(uint(teamID), req.UserID, req.Role)
+	if err != nil {
+		utils.InternalServerError(c, "Failed to add team member")
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionCreate, models.AuditResourceTeamMember, member.ID), nil, member)
+	utils.SuccessWithMessage(c, "Team member added successfully", member)
+}
+
+// UpdateTeamMember 更新团队成员角色
+// @Summary 更新团队成员角色
+// @Description 更新团队成员角色
+// @Tags 成员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "团队ID"
+// @Param user_id path int true "用户ID"
+// @Param member body UpdateMemberRequest true "角色信息"
+// @Success 200 {object} utils.Response{data=models.TeamMember}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/teams/{id}/members/{user_id} [put]
+func (h *MemberHandler) UpdateTeamMember(c *gin.Context) {
+	var req UpdateMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "Invalid request format")
+		return
+	}
+	if !models.IsValidRole(req.Role) {
+		utils.BadRequest(c, "Invalid role")
+		return
+	}
+
+	db := database.GetDB()
+	var member models.TeamMember
+	if err := db.Where("team_id = ? AND user_id = ?", c.Param("id"), c.Param("user_id")).First(&member).Error; err != nil {
+		utils.NotFound(c, "Team member not found")
+		return
+	}
+
+	if h.isLastOwner(services.PermissionScope{TeamID: member.TeamID}, member.Role, req.Role) {
+		utils.BadRequest(c, "Team must have at least one owner")
+		return
+	}
+
+	before := member
+	member.Role = req.Role
+	if err := db.Save(&member).Error; err != nil {
+		utils.InternalServerError(c, "Failed to update team member")
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionUpdate, models.AuditResourceTeamMember, member.ID), before, member)
+	utils.SuccessWithMessage(c, "Team member updated successfully", member)
+}
+
+// RemoveTeamMember 移除团队成员
+// @Summary 移除团队成员
+// @Description 移除团队成员，不能移除最后一个owner
+// @Tags 成员管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "团队ID"
+// @Param user_id path int true "用户ID"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/teams/{id}/members/{user_id} [delete]
+func (h *MemberHandler) RemoveTeamMember(c *gin.Context) {
+	db := database.GetDB()
+
+	var member models.TeamMember
+	if err := db.Where("team_id = ? AND user_id = ?", c.Param("id"), c.Param("user_id")).First(&member).Error; err != nil {
+		utils.NotFound(c, "Team member not found")
+		return
+	}
+
+	if h.isLastOwner(services.PermissionScope{TeamID: member.TeamID}, member.Role, "") {
+		utils.BadRequest(c, "Team must have at least one owner")
+		return
+	}
+
+	if err := db.Delete(&member).Error; err != nil {
+		utils.InternalServerError(c, "Failed to remove team member")
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionDelete, models.AuditResourceTeamMember, member.ID), member, nil)
+	utils.SuccessWithMessage(c, "Team member removed successfully", nil)
+}
+
+// isLastOwner 判断将当前角色改为新角色（空表示移除）是否会导致没有owner
+func (h *MemberHandler) isLastOwner(scope services.PermissionScope, currentRole, newRole string) bool {
+	if currentRole != models.RoleOwner || newRole == models.RoleOwner {
+		return false
+	}
+	return h.permissionService.CountOwners(scope) <= 1
+}
+
+// userExists 检查用户是否存在
+func userExists(userID uint) bool {
+	var user models.User
+	return database.GetDB().Select("id").First(&user, userID).Error == nil
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"seldom-platform/config"
+	"seldom-platform/database"
+	"seldom-platform/services"
+	"seldom-platform/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OIDC登录流程状态，保存在签名Cookie中，回调时校验
+const (
+	oidcStateCookie  = "seldom_oidc_state"
+	oidcStatePurpose = "oidc_state"
+	oidcStateExpire  = 10 * time.Minute
+)
+
+// oidcFlowState 发起登录时生成的state、nonce和PKCE code_verifier
+type oidcFlowState struct {
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	Next         string `json:"next"`
+}
+
+// OIDCHandler OIDC单点登录处理器
+type OIDCHandler struct {
+	config      *config.Config
+	authService *services.AuthService
+	oidcService *services.OIDCService
+}
+
+// NewOIDCHandler 创建OIDC单点登录处理器
+func NewOIDCHandler(cfg *config.Config) *OIDCHandler {
+	return &OIDCHandler{
+		config:      cfg,
+		authService: services.NewAuthService(cfg),
+		oidcService: services.NewOIDCService(cfg),
+	}
+}
+
+// Login 发起单点登录
+// @Summary 发起单点登录
+// @Description 生成state、nonce和PKCE参数后重定向到身份提供方的授权页面
+// @Tags 认证
+// @Param next query string false "登录成功后跳转的前端路径" default(/)
+// @Success 302
+// @Failure 404 {object} utils.Response
+// @Failure 502 {object} utils.Response
+// @Router /api/auth/oidc/login [get]
+func (h *OIDCHandler) Login(c *gin.Context) {
+	if !h.oidcService.Enabled() {
+		utils.NotFound(c, "SSO login is not enabled")
+		return
+	}
+
+	flow := oidcFlowState{Next: safeRedirectPath(c.Query("next"))}
+	for _, value := range []*string{&flow.State, &flow.Nonce, &flow.CodeVerifier} {
+		random, err := utils.GenerateTokenID()
+		if err != nil {
+			utils.InternalServerError(c, "Failed to start SSO login")
+			return
+		}
+		*value = random
+	}
+
+	authURL, err := h.oidcService.AuthorizationURL(c.Request.Context(), flow.State, flow.Nonce, flow.CodeVerifier)
+	if err != nil {
+		utils.GetLogger().Ctx(c.Request.Context()).Error("获取身份提供方配置失败", "category", "oidc", "error", err)
+		utils.Error(c, http.StatusBadGateway, "SSO provider is unavailable")
+		return
+	}
+
+	payload, _ := json.Marshal(flow)
+	h.setStateCookie(c, utils.SignValue(h.config.JWT.Secret, oidcStatePurpose, string(payload), oidcStateExpire), int(oidcStateExpire.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback 单点登录回调
+// @Summary 单点登录回调
+// @Description 校验state后使用授权码和code_verifier换取ID token，自动创建或同步用户，
+// @Description 签发平台令牌并重定向到前端，令牌放在URL片段中（#token=...&refresh_token=...）
+// @Tags 认证
+// @Param code query string true "授权码"
+// @Param state query string true "state"
+// @Success 302
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Router /api/auth/oidc/callback [get]
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	if !h.oidcService.Enabled() {
+		utils.NotFound(c, "SSO login is not enabled")
+		return
+	}
+
+	// state Cookie只能使用一次
+	cookie, _ := c.Cookie(oidcStateCookie)
+	h.setStateCookie(c, "", -1)
+
+	var flow oidcFlowState
+	value, err := utils.VerifySignedValue(h.config.JWT.Secret, oidcStatePurpose, cookie)
+	if err != nil || json.Unmarshal([]byte(value), &flow) != nil || flow.State == "" || c.Query("state") != flow.State {
+		utils.BadRequest(c, "Invalid or expired SSO login state")
+		return
+	}
+
+	if providerError := c.Query("error"); providerError != "" {
+		utils.GetLogger().Ctx(c.Request.Context()).Auth("oidc_login", "", c.ClientIP(), false)
+		utils.Unauthorized(c, "SSO login failed: "+providerError)
+		return
+	}
+	code := c.Query("code")
+	if code == "" {
+		utils.BadRequest(c, "Missing authorization code")
+		return
+	}
+
+	user, err := h.oidcService.Authenticate(c.Request.Context(), code, flow.CodeVerifier, flow.Nonce)
+	if err != nil {
+		utils.GetLogger().Ctx(c.Request.Context()).Error("单点登录失败", "category", "oidc", "error", err)
+		utils.GetLogger().Ctx(c.Request.Context()).Auth("oidc_login", "", c.ClientIP(), false)
+		if errors.Is(err, services.ErrIdentityConflict) {
+			utils.Error(c, http.StatusConflict, "Username is already used by a local account")
+			return
+		}
+		utils.Unauthorized(c, "SSO login failed")
+		return
+	}
+
+	if !user.IsActive {
+		utils.GetLogger().Ctx(c.Request.Context()).Auth("oidc_login", user.Username, c.ClientIP(), false)
+		utils.Unauthorized(c, "User account is disabled")
+		return
+	}
+
+	// 更新最后登录时间
+	database.GetDB().Model(user).Update("last_login", time.Now())
+
+	tokens, err := h.authService.IssueTokens(user, clientInfo(c))
+	if err != nil {
+		utils.InternalServerError(c, "Failed to generate token")
+		return
+	}
+
+	utils.GetLogger().Ctx(c.Request.Context()).Auth("oidc_login", user.Username, c.ClientIP(), true)
+	fragment := url.Values{
+		"token":         {tokens.AccessToken},
+		"refresh_token": {tokens.RefreshToken},
+		"token_type":    {tokens.TokenType},
+		"expires_in":    {strconv.FormatInt(tokens.ExpiresIn, 10)},
+	}
+	c.Redirect(http.StatusFound, strings.TrimSuffix(h.config.Server.PublicURL, "/")+flow.Next+"#"+fragment.Encode())
+}
+
+// setStateCookie 设置或清除登录流程状态Cookie
+func (h *OIDCHandler) setStateCookie(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, value, maxAge, "/api/auth/oidc", "", c.Request.TLS != nil, true)
+}
+
+// safeRedirectPath 只允许站内相对路径，防止开放重定向
+func safeRedirectPath(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"seldom-platform/database"
+	"seldom-platform/middleware"
 	"seldom-platform/models"
+	"seldom-platform/services"
 	"seldom-platform/utils"
 	"strconv"
 
@@ -10,11 +13,19 @@ import (
 )
 
 // ProjectHandler 项目处理器
-type ProjectHandler struct{}
+type ProjectHandler struct {
+	permissionService *services.PermissionService
+	auditService      *services.AuditService
+	trashService      *services.TrashService
+}
 
 // NewProjectHandler 创建项目处理器
 func NewProjectHandler() *ProjectHandler {
-	return &ProjectHandler{}
+	return &ProjectHandler{
+		permissionService: services.NewPermissionService(),
+		auditService:      services.NewAuditService(),
+		trashService:      services.NewTrashService(),
+	}
 }
 
 // CreateProjectRequest 创建项目请求结构
@@ -45,7 +56,7 @@ type UpdateProjectRequest struct {
 // @Security BearerAuth
 // @Param page query int false "页码" default(1)
 // @Param size query int false "每页数量" default(10)
-// @Param search query string false "搜索关键词"
+// @Param search query string false "搜索关键词，匹配名称和项目地址"
 // @Success 200 {object} utils.PageResponse{data=[]models.Project}
 // @Failure 401 {object} utils.Response
 // @Router /api/projects [get]
@@ -66,10 +77,11 @@ func (h *ProjectHandler) GetProjects(c *gin.Context) {
 
 	offset := (page - 1) * size
 
-	// 构建查询
-	query := db.Model(&models.Project{})
+	// 构建查询，只返回当前用户有权限的项目
+	query := h.permissionService.FilterProjects(db.Model(&models.Project{}), middleware.CurrentUser(c), "id").
+		Where("is_delete = ?", false)
 	if search != "" {
-		query = query.Where("name LIKE ? OR description LIKE ?", "%"+search+"%", "%"+search+"%")
+		query = query.Where("name LIKE ? OR address LIKE ?", "%"+search+"%", "%"+search+"%")
 	}
 
 	// 获取总数
@@ -101,7 +113,7 @@ func (h *ProjectHandler) GetProject(c *gin.Context) {
 	db := database.GetDB()
 
 	var project models.Project
-	if err := db.First(&project, id).Error; err != nil {
+	if err := db.Where("is_delete = ?", false).First(&project, id).Error; err != nil {
 		utils.NotFound(c, "Project not found")
 		return
 	}
@@ -131,7 +143,7 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
 
 	// 检查项目名是否已存在
 	var existingProject models.Project
-	if err := db.Where("name = ?", req.Name).First(&existingProject).Error; err == nil {
+	if err := db.Where("name = ? AND is_delete = ?", req.Name, false).First(&existingProject).Error; err == nil {
 		utils.BadRequest(c, "Project name already exists")
 		return
 	}
@@ -150,6 +162,13 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
 		return
 	}
 
+	// 创建者成为项目owner
+	if _, err := h.permissionService.AddProjectMember(project.ID, middleware.CurrentUser(c).ID, models.RoleOwner); err != nil {
+		utils.InternalServerError(c, "Failed to add project owner")
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionCreate, models.AuditResourceProject, project.ID), nil, project)
 	utils.SuccessWithMessage(c, "Project created successfully", project)
 }
 
@@ -177,10 +196,11 @@ func (h *ProjectHandler) UpdateProject(c *gin.Context) {
 	db := database.GetDB()
 	var project models.Project
 
-	if err := db.First(&project, id).Error; err != nil {
+	if err := db.Where("is_delete = ?", false).First(&project, id).Error; err != nil {
 		utils.NotFound(c, "Project not found")
 		return
 	}
+	before := project
 
 	// 更新项目信息
 	if req.Name != "" {
@@ -199,12 +219,13 @@ func (h *ProjectHandler) UpdateProject(c *gin.Context) {
 		return
 	}
 
+	h.auditService.Record(auditEntry(c, models.AuditActionUpdate, models.AuditResourceProject, project.ID), before, project)
 	utils.SuccessWithMessage(c, "Project updated successfully", project)
 }
 
 // DeleteProject 删除项目
 // @Summary 删除项目
-// @Description 删除项目
+// @Description 将项目及其任务移入回收站，任务同时从定时调度中移除
 // @Tags 项目管理
 // @Produce json
 // @Security BearerAuth
@@ -217,15 +238,50 @@ func (h *ProjectHandler) DeleteProject(c *gin.Context) {
 	db := database.GetDB()
 
 	var project models.Project
-	if err := db.First(&project, id).Error; err != nil {
+	if err := db.Where("is_delete = ?", false).First(&project, id).Error; err != nil {
 		utils.NotFound(c, "Project not found")
 		return
 	}
 
-	if err := db.Delete(&project).Error; err != nil {
+	if err := h.trashService.DeleteProject(&project); err != nil {
 		utils.InternalServerError(c, "Failed to delete project")
 		return
 	}
 
+	h.auditService.Record(auditEntry(c, models.AuditActionDelete, models.AuditResourceProject, project.ID), project, nil)
 	utils.SuccessWithMessage(c, "Project deleted successfully", nil)
-}
\ No newline at end of file
+}
+// RestoreProject 恢复项目
+// @Summary 恢复项目
+// @Description 从回收站恢复项目，以及随项目一起删除的任务
+// @Tags 项目管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "项目ID"
+// @Success 200 {object} utils.Response{data=models.Project}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/projects/{id}/restore [post]
+func (h *ProjectHandler) RestoreProject(c *gin.Context) {
+	id := c.Param("id")
+	db := database.GetDB()
+
+	var project models.Project
+	if err := db.Where("is_delete = ?", true).First(&project, id).Error; err != nil {
+		utils.NotFound(c, "Project not found in trash")
+		return
+	}
+	before := project
+
+	if err := h.trashService.RestoreProject(&project); err != nil {
+		if errors.Is(err, services.ErrNameConflict) {
+			utils.BadRequest(c, "Project name already exists")
+			return
+		}
+		utils.InternalServerError(c, "Failed to restore project")
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionRestore, models.AuditResourceProject, project.ID), before, project)
+	utils.SuccessWithMessage(c, "Project restored successfully", project)
+}
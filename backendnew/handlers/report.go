@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"seldom-platform/services"
+	"seldom-platform/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReportHandler 报告处理器
+type ReportHandler struct {
+	reportService *services.ReportService
+}
+
+// NewReportHandler 创建报告处理器
+func NewReportHandler() *ReportHandler {
+	return &ReportHandler{
+		reportService: services.NewReportService(),
+	}
+}
+
+// CompareReports 对比两个任务报告
+// @Summary 对比任务报告
+// @Description 按类名和用例名（或用例hash）匹配两个报告的用例，返回新增失败、新增通过、持续失败、新增、移除以及耗时退化的用例
+// @Tags 报告管理
+// @Produce json
+// @Security BearerAuth
+// @Param base query int true "基准报告ID"
+// @Param head query int true "对比报告ID"
+// @Param match query string false "匹配方式 name/hash" default(name)
+// @Param threshold query number false "耗时退化阈值（百分比）" default(20)
+// @Success 200 {object} utils.Response{data=services.ReportComparison}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/reports/compare [get]
+func (h *ReportHandler) CompareReports(c *gin.Context) {
+	baseID, err := strconv.ParseUint(c.Query("base"), 10, 32)
+	if err != nil || baseID == 0 {
+		utils.BadRequest(c, "Invalid base report id")
+		return
+	}
+	headID, err := strconv.ParseUint(c.Query("head"), 10, 32)
+	if err != nil || headID == 0 {
+		utils.BadRequest(c, "Invalid head report id")
+		return
+	}
+
+	opts := services.CompareOptions{
+		Match: c.DefaultQuery("match", services.CompareMatchByName),
+	}
+	if threshold := c.Query("threshold"); threshold != "" {
+		value, err := strconv.ParseFloat(threshold, 64)
+		if err != nil || value <= 0 {
+			utils.BadRequest(c, "Invalid threshold")
+			return
+		}
+		opts.Threshold = value
+	}
+	if opts.Match != services.CompareMatchByName && opts.Match != services.CompareMatchByHash {
+		utils.BadRequest(c, "Invalid match mode")
+		return
+	}
+
+	comparison, err := h.reportService.CompareReports(uint(baseID), uint(headID), opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrReportNotFound):
+			utils.NotFound(c, "Report not found")
+		case errors.Is(err, services.ErrReportTaskNotFound):
+			utils.NotFound(c, "Task not found")
+		default:
+			utils.InternalServerError(c, "Failed to compare reports")
+		}
+		return
+	}
+
+	utils.Success(c, comparison)
+}
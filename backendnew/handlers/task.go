@@ -1,21 +1,38 @@
 package handlers
 
 import (
+	"errors"
+	"net/http"
+	"seldom-platform/config"
 	"seldom-platform/database"
+	"seldom-platform/middleware"
 	"seldom-platform/models"
 	"seldom-platform/services"
 	"seldom-platform/utils"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
 )
 
 // TaskHandler 任务处理器
-type TaskHandler struct{}
+type TaskHandler struct {
+	permissionService *services.PermissionService
+	auditService      *services.AuditService
+	trashService      *services.TrashService
+	taskService       *services.TaskService
+	reportService     *services.ReportService
+}
 
 // NewTaskHandler 创建任务处理器
-func NewTaskHandler() *TaskHandler {
-	return &TaskHandler{}
+func NewTaskHandler(cfg *config.Config) *TaskHandler {
+	return &TaskHandler{
+		permissionService: services.NewPermissionService(),
+		auditService:      services.NewAuditService(),
+		trashService:      services.NewTrashService(),
+		taskService:       services.NewTaskService(cfg),
+		reportService:     services.NewReportService(),
+	}
 }
 
 // CreateTaskRequest 创建任务请求结构
@@ -23,6 +40,8 @@ type CreateTaskRequest struct {
 	Name           string `json:"name" binding:"required"`
 	Project        uint   `json:"project" binding:"required"`
 	Env            uint   `json:"env"`
+	Envs           []uint `json:"envs"` // 执行环境列表，每个环境执行一次；不传时使用env
+	Team           uint   `json:"team"`
 	CronTime       string `json:"cron_time"`
 	CronExpression string `json:"cron_expression"`
 	IsScheduled    bool   `json:"is_scheduled"`
@@ -40,6 +59,8 @@ type UpdateTaskRequest struct {
 	Name        string `json:"name"`
 	Project     uint   `json:"project"`
 	Env         uint   `json:"env"`
+	Envs        []uint `json:"envs"` // 不传表示不修改，传空数组表示清空执行环境
+	Team        *uint  `json:"team"` // 不传表示不修改，传0表示取消团队分配
 	CronTime    string `json:"cron_time"`
 	Type        int    `json:"type"`
 	Status      int    `json:"status"`
@@ -52,23 +73,57 @@ type UpdateTaskRequest struct {
 
 // GetTasks 获取任务列表
 // @Summary 获取任务列表
-// @Description 获取任务列表，支持分页和筛选
+// @Description 获取任务列表，支持分页和按项目、团队、名称筛选
 // @Tags 任务管理
 // @Produce json
 // @Security BearerAuth
 // @Param page query int false "页码" default(1)
 // @Param size query int false "每页数量" default(10)
 // @Param project query int false "项目ID"
+// @Param team query int false "团队ID"
+// @Param name query string false "任务名称"
 // @Success 200 {object} utils.PageResponse{data=[]models.TestTask}
 // @Failure 401 {object} utils.Response
 // @Router /api/tasks [get]
 func (h *TaskHandler) GetTasks(c *gin.Context) {
 	db := database.GetDB()
 
+	// 只返回当前用户有权限的任务
+	query := h.permissionService.FilterTasks(db.Model(&models.TestTask{}), middleware.CurrentUser(c))
+	h.listTasks(c, query)
+}
+
+// GetMyTeamTasks 获取我的团队任务列表
+// @Summary 获取我的团队任务列表
+// @Description 获取当前用户所在团队的任务列表，支持分页和按项目、团队、名称筛选
+// @Tags 任务管理
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param size query int false "每页数量" default(10)
+// @Param project query int false "项目ID"
+// @Param team query int false "团队ID"
+// @Param name query string false "任务名称"
+// @Success 200 {object} utils.PageResponse{data=[]models.TestTask}
+// @Failure 401 {object} utils.Response
+// @Router /api/tasks/mine [get]
+func (h *TaskHandler) GetMyTeamTasks(c *gin.Context) {
+	db := database.GetDB()
+
+	userID, _ := middleware.CurrentUserID(c)
+	query := db.Model(&models.TestTask{}).
+		Where("team_id IN (SELECT team_id FROM app_team_member WHERE user_id = ?)", userID)
+	h.listTasks(c, query)
+}
+
+// listTasks 按查询参数筛选并分页返回任务，按创建时间倒序
+func (h *TaskHandler) listTasks(c *gin.Context, query *gorm.DB) {
 	// 获取分页参数
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	size, _ := strconv.Atoi(c.DefaultQuery("size", "10"))
 	projectID := c.Query("project")
+	teamID := c.Query("team")
+	name := c.Query("name")
 
 	if page < 1 {
 		page = 1
@@ -79,10 +134,15 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 
 	offset := (page - 1) * size
 
-	// 构建查询
-	query := db.Model(&models.TestTask{})
+	query = query.Where("is_delete = ?", false)
 	if projectID != "" {
-		query = query.Where("project = ?", projectID)
+		query = query.Where("project_id = ?", projectID)
+	}
+	if teamID != "" {
+		query = query.Where("team_id = ?", teamID)
+	}
+	if name != "" {
+		query = query.Where("name LIKE ?", "%"+name+"%")
 	}
 
 	// 获取总数
@@ -91,10 +151,11 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 
 	// 获取数据
 	var tasks []models.TestTask
-	if err := query.Offset(offset).Limit(size).Find(&tasks).Error; err != nil {
+	if err := query.Order("create_time DESC").Offset(offset).Limit(size).Find(&tasks).Error; err != nil {
 		utils.InternalServerError(c, "Failed to fetch tasks")
 		return
 	}
+	h.taskService.LoadTaskEnvs(tasks)
 
 	utils.PageSuccess(c, tasks, total, page, size)
 }
@@ -114,10 +175,11 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 	db := database.GetDB()
 
 	var task models.TestTask
-	if err := db.First(&task, id).Error; err != nil {
+	if err := db.Where("is_delete = ?", false).First(&task, id).Error; err != nil {
 		utils.NotFound(c, "Task not found")
 		return
 	}
+	task.EnvIDs = h.taskService.TaskEnvIDs(&task)
 
 	utils.Success(c, task)
 }
@@ -142,22 +204,42 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 
 	db := database.GetDB()
 
+	if !projectActive(req.Project) {
+		utils.BadRequest(c, "Project not found")
+		return
+	}
+
+	envIDs := req.Envs
+	if envIDs == nil && req.Env != 0 {
+		envIDs = []uint{req.Env}
+	}
+	if err := h.taskService.ValidateTaskEnvs(req.Project, envIDs); err != nil {
+		respondUserError(c, err, "Failed to create task")
+		return
+	}
+
 	// 创建任务
 	task := models.TestTask{
 		Name:           req.Name,
 		ProjectID:      req.Project,
-		EnvID:          &req.Env,
 		Timed:          req.CronTime,
 		CronExpression: req.CronExpression,
 		IsScheduled:    req.IsScheduled,
 		Status:         req.Status,
 		Email:          req.Email,
 	}
+	if req.Team != 0 {
+		task.TeamID = &req.Team
+	}
 
 	if err := db.Create(&task).Error; err != nil {
 		utils.InternalServerError(c, "Failed to create task")
 		return
 	}
+	if err := h.taskService.SetTaskEnvs(&task, envIDs); err != nil {
+		respondUserError(c, err, "Failed to create task")
+		return
+	}
 
 	// 如果任务有cron表达式且设置为定时任务，添加到调度器
 	if req.CronExpression != "" && req.IsScheduled && req.Status == 1 {
@@ -169,6 +251,7 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		}
 	}
 
+	h.auditService.Record(auditEntry(c, models.AuditActionCreate, models.AuditResourceTask, task.ID), nil, task)
 	utils.SuccessWithMessage(c, "Task created successfully", task)
 }
 
@@ -196,20 +279,44 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 	db := database.GetDB()
 	var task models.TestTask
 
-	if err := db.First(&task, id).Error; err != nil {
+	if err := db.Where("is_delete = ?", false).First(&task, id).Error; err != nil {
 		utils.NotFound(c, "Task not found")
 		return
 	}
+	before := task
+	before.EnvIDs = h.taskService.TaskEnvIDs(&task)
 
 	// 更新任务信息
 	if req.Name != "" {
 		task.Name = req.Name
 	}
 	if req.Project != 0 {
+		if !projectActive(req.Project) {
+			utils.BadRequest(c, "Project not found")
+			return
+		}
 		task.ProjectID = req.Project
 	}
-	if req.Env != 0 {
-		task.EnvID = &req.Env
+	envIDs := req.Envs
+	if envIDs == nil && req.Env != 0 {
+		envIDs = []uint{req.Env}
+	}
+	if envIDs == nil && task.ProjectID != before.ProjectID {
+		// 项目变更后原有环境需要对新项目可用
+		envIDs = before.EnvIDs
+	}
+	if envIDs != nil {
+		if err := h.taskService.ValidateTaskEnvs(task.ProjectID, envIDs); err != nil {
+			respondUserError(c, err, "Failed to update task")
+			return
+		}
+	}
+	if req.Team != nil {
+		if *req.Team == 0 {
+			task.TeamID = nil
+		} else {
+			task.TeamID = req.Team
+		}
 	}
 	if req.CronTime != "" {
 		task.Timed = req.CronTime
@@ -223,13 +330,22 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 		utils.InternalServerError(c, "Failed to update task")
 		return
 	}
+	if envIDs != nil {
+		if err := h.taskService.SetTaskEnvs(&task, envIDs); err != nil {
+			respondUserError(c, err, "Failed to update task")
+			return
+		}
+	} else {
+		task.EnvIDs = before.EnvIDs
+	}
 
+	h.auditService.Record(auditEntry(c, models.AuditActionUpdate, models.AuditResourceTask, task.ID), before, task)
 	utils.SuccessWithMessage(c, "Task updated successfully", task)
 }
 
 // DeleteTask 删除任务
 // @Summary 删除任务
-// @Description 删除任务
+// @Description 将任务移入回收站，并从定时调度中移除
 // @Tags 任务管理
 // @Produce json
 // @Security BearerAuth
@@ -242,73 +358,100 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 	db := database.GetDB()
 
 	var task models.TestTask
-	if err := db.First(&task, id).Error; err != nil {
+	if err := db.Where("is_delete = ?", false).First(&task, id).Error; err != nil {
 		utils.NotFound(c, "Task not found")
 		return
 	}
 
-	if err := db.Delete(&task).Error; err != nil {
+	// 移入回收站并从调度器中移除任务
+	if err := h.trashService.DeleteTask(&task); err != nil {
 		utils.InternalServerError(c, "Failed to delete task")
 		return
 	}
 
-	// 从调度器中移除任务
-	if services.GlobalScheduler != nil {
-		taskID, _ := strconv.ParseUint(id, 10, 32)
-		services.GlobalScheduler.RemoveTask(uint(taskID))
-	}
-
+	h.auditService.Record(auditEntry(c, models.AuditActionDelete, models.AuditResourceTask, task.ID), task, nil)
 	utils.SuccessWithMessage(c, "Task deleted successfully", nil)
 }
 
 // RunTask 执行任务
 // @Summary 执行任务
-// @Description 手动执行任务
+// @Description 手动执行任务，任务配置多个环境时每个环境各执行一次，返回的run_id用于查询本次执行的报告
 // @Tags 任务管理
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "任务ID"
 // @Success 200 {object} utils.Response
 // @Failure 404 {object} utils.Response
+// @Failure 503 {object} utils.Response
 // @Router /api/tasks/{id}/run [post]
 func (h *TaskHandler) RunTask(c *gin.Context) {
 	id := c.Param("id")
 	db := database.GetDB()
 
 	var task models.TestTask
-	if err := db.First(&task, id).Error; err != nil {
+	if err := db.Where("is_delete = ?", false).First(&task, id).Error; err != nil {
 		utils.NotFound(c, "Task not found")
 		return
 	}
 
 	// 使用TaskService执行任务
-	taskService := services.NewTaskService()
-	go func() {
-		// 异步执行任务
-		result, err := taskService.ExecuteTask(task.ID)
-		if err != nil {
-			utils.LogError("Task execution failed", err)
-			return
-		}
-		utils.LogInfo("Task execution completed", map[string]interface{}{
-			"task_id": task.ID,
-			"status":  result.Status,
-		})
-	}()
-	
+	runID := services.NewRunID()
+	if err := h.taskService.StartTaskRun(c.Request.Context(), task.ID, runID); err != nil {
+		utils.Error(c, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionRun, models.AuditResourceTask, task.ID), nil, nil)
 	utils.SuccessWithMessage(c, "Task execution started", gin.H{
 		"task_id": task.ID,
+		"run_id":  runID,
 		"status":  "running",
 	})
 }
 
+// RestoreTask 恢复任务
+// @Summary 恢复任务
+// @Description 从回收站恢复任务，定时任务重新加入调度；所属项目在回收站中时需先恢复项目
+// @Tags 任务管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "任务ID"
+// @Success 200 {object} utils.Response{data=models.TestTask}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/tasks/{id}/restore [post]
+func (h *TaskHandler) RestoreTask(c *gin.Context) {
+	id := c.Param("id")
+	db := database.GetDB()
+
+	var task models.TestTask
+	if err := db.Where("is_delete = ?", true).First(&task, id).Error; err != nil {
+		utils.NotFound(c, "Task not found in trash")
+		return
+	}
+	before := task
+
+	if err := h.trashService.RestoreTask(&task); err != nil {
+		if errors.Is(err, services.ErrParentDeleted) {
+			utils.BadRequest(c, "Project is deleted, restore the project first")
+			return
+		}
+		utils.InternalServerError(c, "Failed to restore task")
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionRestore, models.AuditResourceTask, task.ID), before, task)
+	utils.SuccessWithMessage(c, "Task restored successfully", task)
+}
+
 // GetTaskReports 获取任务报告列表
 // @Summary 获取任务报告列表
-// @Description 获取指定任务的报告列表
+// @Description 获取指定任务的报告列表，可按执行批次筛选
 // @Tags 任务管理
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "任务ID"
+// @Param run_id query string false "执行批次ID"
 // @Param page query int false "页码" default(1)
 // @Param size query int false "每页数量" default(10)
 // @Success 200 {object} utils.PageResponse{data=[]models.TaskReport}
@@ -320,7 +463,7 @@ func (h *TaskHandler) GetTaskReports(c *gin.Context) {
 
 	// 检查任务是否存在
 	var task models.TestTask
-	if err := db.First(&task, taskID).Error; err != nil {
+	if err := db.Where("is_delete = ?", false).First(&task, taskID).Error; err != nil {
 		utils.NotFound(c, "Task not found")
 		return
 	}
@@ -338,15 +481,96 @@ func (h *TaskHandler) GetTaskReports(c *gin.Context) {
 
 	offset := (page - 1) * size
 
+	query := db.Model(&models.TaskReport{}).Where("task_id = ?", task.ID)
+	if runID := c.Query("run_id"); runID != "" {
+		query = query.Where("run_id = ?", runID)
+	}
+
 	// 获取报告列表
 	var total int64
-	db.Model(&models.TaskReport{}).Where("task = ?", taskID).Count(&total)
+	query.Count(&total)
 
 	var reports []models.TaskReport
-	if err := db.Where("task = ?", taskID).Offset(offset).Limit(size).Order("create_time DESC").Find(&reports).Error; err != nil {
+	if err := query.Offset(offset).Limit(size).Order("create_time DESC, id DESC").Find(&reports).Error; err != nil {
 		utils.InternalServerError(c, "Failed to fetch task reports")
 		return
 	}
 
 	utils.PageSuccess(c, reports, total, page, size)
-}
\ No newline at end of file
+}
+
+// GetTaskRuns 获取任务执行记录
+// @Summary 获取任务执行记录
+// @Description 按执行批次汇总任务报告，每次执行包含各环境的报告，按执行时间倒序
+// @Tags 任务管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "任务ID"
+// @Param page query int false "页码" default(1)
+// @Param size query int false "每页数量" default(10)
+// @Success 200 {object} utils.PageResponse{data=[]services.TaskRun}
+// @Failure 404 {object} utils.Response
+// @Router /api/tasks/{id}/runs [get]
+func (h *TaskHandler) GetTaskRuns(c *gin.Context) {
+	var task models.TestTask
+	if err := database.GetDB().Where("is_delete = ?", false).First(&task, c.Param("id")).Error; err != nil {
+		utils.NotFound(c, "Task not found")
+		return
+	}
+
+	// 获取分页参数
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 10
+	}
+
+	runs, total, err := h.reportService.ListTaskRuns(task.ID, (page-1)*size, size)
+	if err != nil {
+		utils.InternalServerError(c, "Failed to fetch task runs")
+		return
+	}
+
+	utils.PageSuccess(c, runs, total, page, size)
+}
+
+// GetTaskRun 获取单次执行报告
+// @Summary 获取单次执行报告
+// @Description 获取一次任务执行的汇总结果，报告按环境分组
+// @Tags 任务管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "任务ID"
+// @Param run_id path string true "执行批次ID"
+// @Success 200 {object} utils.Response{data=services.TaskRun}
+// @Failure 404 {object} utils.Response
+// @Router /api/tasks/{id}/runs/{run_id} [get]
+func (h *TaskHandler) GetTaskRun(c *gin.Context) {
+	var task models.TestTask
+	if err := database.GetDB().Where("is_delete = ?", false).First(&task, c.Param("id")).Error; err != nil {
+		utils.NotFound(c, "Task not found")
+		return
+	}
+
+	run, err := h.reportService.GetTaskRun(task.ID, c.Param("run_id"))
+	if err != nil {
+		if errors.Is(err, services.ErrRunNotFound) {
+			utils.NotFound(c, "Run not found")
+			return
+		}
+		utils.InternalServerError(c, "Failed to fetch task run")
+		return
+	}
+
+	utils.Success(c, run)
+}
+
+// projectActive 检查项目是否存在且未删除
+func projectActive(projectID uint) bool {
+	var project models.Project
+	return database.GetDB().Select("id").Where("is_delete = ?", false).First(&project, projectID).Error == nil
+}
@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"seldom-platform/database"
+	"seldom-platform/middleware"
 	"seldom-platform/models"
+	"seldom-platform/services"
 	"seldom-platform/utils"
 	"strconv"
 
@@ -10,11 +13,19 @@ import (
 )
 
 // TeamHandler 团队处理器
-type TeamHandler struct{}
+type TeamHandler struct {
+	permissionService *services.PermissionService
+	auditService      *services.AuditService
+	trashService      *services.TrashService
+}
 
 // NewTeamHandler 创建团队处理器
 func NewTeamHandler() *TeamHandler {
-	return &TeamHandler{}
+	return &TeamHandler{
+		permissionService: services.NewPermissionService(),
+		auditService:      services.NewAuditService(),
+		trashService:      services.NewTrashService(),
+	}
 }
 
 // CreateTeamRequest 创建团队请求结构
@@ -39,7 +50,7 @@ type UpdateTeamRequest struct {
 // @Security BearerAuth
 // @Param page query int false "页码" default(1)
 // @Param size query int false "每页数量" default(10)
-// @Param search query string false "搜索关键词"
+// @Param search query string false "搜索关键词，匹配名称和团队邮箱"
 // @Success 200 {object} utils.PageResponse{data=[]models.Team}
 // @Failure 401 {object} utils.Response
 // @Router /api/teams [get]
@@ -60,10 +71,11 @@ func (h *TeamHandler) GetTeams(c *gin.Context) {
 
 	offset := (page - 1) * size
 
-	// 构建查询
-	query := db.Model(&models.Team{})
+	// 构建查询，只返回当前用户有权限的团队
+	query := h.permissionService.FilterTeams(db.Model(&models.Team{}), middleware.CurrentUser(c), "id").
+		Where("is_delete = ?", false)
 	if search != "" {
-		query = query.Where("name LIKE ? OR description LIKE ?", "%"+search+"%", "%"+search+"%")
+		query = query.Where("name LIKE ? OR email LIKE ?", "%"+search+"%", "%"+search+"%")
 	}
 
 	// 获取总数
@@ -95,7 +107,7 @@ func (h *TeamHandler) GetTeam(c *gin.Context) {
 	db := database.GetDB()
 
 	var team models.Team
-	if err := db.First(&team, id).Error; err != nil {
+	if err := db.Where("is_delete = ?", false).First(&team, id).Error; err != nil {
 		utils.NotFound(c, "Team not found")
 		return
 	}
@@ -125,7 +137,7 @@ func (h *TeamHandler) CreateTeam(c *gin.Context) {
 
 	// 检查团队名是否已存在
 	var existingTeam models.Team
-	if err := db.Where("name = ?", req.Name).First(&existingTeam).Error; err == nil {
+	if err := db.Where("name = ? AND is_delete = ?", req.Name, false).First(&existingTeam).Error; err == nil {
 		utils.BadRequest(c, "Team name already exists")
 		return
 	}
@@ -141,6 +153,13 @@ func (h *TeamHandler) CreateTeam(c *gin.Context) {
 		return
 	}
 
+	// 创建者成为团队owner
+	if _, err := h.permissionService.AddTeamMember(team.ID, middleware.CurrentUser(c).ID, models.RoleOwner); err != nil {
+		utils.InternalServerError(c, "Failed to add team owner")
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionCreate, models.AuditResourceTeam, team.ID), nil, team)
 	utils.SuccessWithMessage(c, "Team created successfully", team)
 }
 
@@ -168,10 +187,11 @@ func (h *TeamHandler) UpdateTeam(c *gin.Context) {
 	db := database.GetDB()
 	var team models.Team
 
-	if err := db.First(&team, id).Error; err != nil {
+	if err := db.Where("is_delete = ?", false).First(&team, id).Error; err != nil {
 		utils.NotFound(c, "Team not found")
 		return
 	}
+	before := team
 
 	// 更新团队信息
 	if req.Name != "" {
@@ -186,12 +206,13 @@ func (h *TeamHandler) UpdateTeam(c *gin.Context) {
 		return
 	}
 
+	h.auditService.Record(auditEntry(c, models.AuditActionUpdate, models.AuditResourceTeam, team.ID), before, team)
 	utils.SuccessWithMessage(c, "Team updated successfully", team)
 }
 
 // DeleteTeam 删除团队
 // @Summary 删除团队
-// @Description 删除团队
+// @Description 将团队移入回收站
 // @Tags 团队管理
 // @Produce json
 // @Security BearerAuth
@@ -204,15 +225,50 @@ func (h *TeamHandler) DeleteTeam(c *gin.Context) {
 	db := database.GetDB()
 
 	var team models.Team
-	if err := db.First(&team, id).Error; err != nil {
+	if err := db.Where("is_delete = ?", false).First(&team, id).Error; err != nil {
 		utils.NotFound(c, "Team not found")
 		return
 	}
 
-	if err := db.Delete(&team).Error; err != nil {
+	if err := h.trashService.DeleteTeam(&team); err != nil {
 		utils.InternalServerError(c, "Failed to delete team")
 		return
 	}
 
+	h.auditService.Record(auditEntry(c, models.AuditActionDelete, models.AuditResourceTeam, team.ID), team, nil)
 	utils.SuccessWithMessage(c, "Team deleted successfully", nil)
-}
\ No newline at end of file
+}
+// RestoreTeam 恢复团队
+// @Summary 恢复团队
+// @Description 从回收站恢复团队
+// @Tags 团队管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "团队ID"
+// @Success 200 {object} utils.Response{data=models.Team}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/teams/{id}/restore [post]
+func (h *TeamHandler) RestoreTeam(c *gin.Context) {
+	id := c.Param("id")
+	db := database.GetDB()
+
+	var team models.Team
+	if err := db.Where("is_delete = ?", true).First(&team, id).Error; err != nil {
+		utils.NotFound(c, "Team not found in trash")
+		return
+	}
+	before := team
+
+	if err := h.trashService.RestoreTeam(&team); err != nil {
+		if errors.Is(err, services.ErrNameConflict) {
+			utils.BadRequest(c, "Team name already exists")
+			return
+		}
+		utils.InternalServerError(c, "Failed to restore team")
+		return
+	}
+
+	h.auditService.Record(auditEntry(c, models.AuditActionRestore, models.AuditResourceTeam, team.ID), before, team)
+	utils.SuccessWithMessage(c, "Team restored successfully", team)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"seldom-platform/database"
+	"seldom-platform/middleware"
+	"seldom-platform/models"
+	"seldom-platform/services"
+	"seldom-platform/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+)
+
+// TrashHandler 回收站处理器
+type TrashHandler struct {
+	permissionService *services.PermissionService
+}
+
+// NewTrashHandler 创建回收站处理器
+func NewTrashHandler() *TrashHandler {
+	return &TrashHandler{
+		permissionService: services.NewPermissionService(),
+	}
+}
+
+// GetTrash 获取回收站列表
+// @Summary 获取回收站列表
+// @Description 按类型列出已删除的项目、环境、任务或团队，按删除时间倒序排列；只返回当前用户有权限的资源，全局环境仅员工可见
+// @Tags 回收站
+// @Produce json
+// @Security BearerAuth
+// @Param type query string true "资源类型：project、env、task、team"
+// @Param page query int false "页码" default(1)
+// @Param size query int false "每页数量" default(10)
+// @Success 200 {object} utils.PageResponse
+// @Failure 400 {object} utils.Response
+// @Router /api/trash [get]
+func (h *TrashHandler) GetTrash(c *gin.Context) {
+	resourceType := c.Query("type")
+	if !services.IsTrashResource(resourceType) {
+		utils.BadRequest(c, "Invalid type, must be one of project, env, task, team")
+		return
+	}
+
+	// 获取分页参数
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 10
+	}
+
+	offset := (page - 1) * size
+
+	db := database.GetDB()
+	user := middleware.CurrentUser(c)
+
+	// 构建查询，只返回当前用户有权限的资源
+	var query *gorm.DB
+	var items interface{}
+	switch resourceType {
+	case models.AuditResourceProject:
+		query = h.permissionService.FilterProjects(db.Model(&models.Project{}), user, "id")
+		items = &[]models.Project{}
+	case models.AuditResourceTeam:
+		query = h.permissionService.FilterTeams(db.Model(&models.Team{}), user, "id")
+		items = &[]models.Team{}
+	case models.AuditResourceTask:
+		query = h.permissionService.FilterTasks(db.Model(&models.TestTask{}), user)
+		items = &[]models.TestTask{}
+	case models.AuditResourceEnv:
+		// 全局环境只有员工可以管理，其他用户只能看到有权限的项目的环境
+		query = h.permissionService.FilterProjects(db.Model(&models.Env{}), user, "project_id")
+		items = &[]models.Env{}
+	}
+	query = query.Where("is_delete = ?", true)
+
+	// 获取总数
+	var total int64
+	query.Count(&total)
+
+	// 获取数据
+	if err := query.Order("delete_time DESC, id DESC").Offset(offset).Limit(size).Find(items).Error; err != nil {
+		utils.InternalServerError(c, "Failed to fetch trash")
+		return
+	}
+
+	utils.PageSuccess(c, items, total, page, size)
+}
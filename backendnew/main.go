@@ -1,13 +1,23 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"seldom-platform/config"
 	"seldom-platform/database"
+	"seldom-platform/metrics"
 	"seldom-platform/routes"
 	"seldom-platform/services"
+	"seldom-platform/tracing"
 	"seldom-platform/utils"
 )
 
@@ -30,38 +40,164 @@ import (
 // @in header
 // @name Authorization
 func main() {
+	if err := run(); err != nil {
+		utils.GetLogger().Error("Server exited", "error", err)
+		os.Exit(1)
+	}
+}
+
+// run 启动服务并阻塞到收到退出信号，返回错误前已执行完所有清理
+func run() error {
 	// 加载配置
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("invalid configuration:\n%v", err)
+	}
+
+	// 执行命令行子命令，如 createsuperuser
+	if runCommand(cfg, os.Args[1:]) {
+		return nil
+	}
 
 	// 初始化日志记录器
-	if err := utils.InitLogger(); err != nil {
-		log.Fatal("Failed to initialize logger:", err)
+	if err := utils.InitLogger(cfg.Log); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	defer utils.CloseLogger()
+	logger := utils.GetLogger()
+	if cfg.File() != "" {
+		logger.Info("Configuration loaded", "file", cfg.File(), "profile", cfg.Profile())
+	}
+
+	// 初始化链路追踪
+	shutdownTracing, err := tracing.Setup(cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracing: %w", err)
 	}
+	defer func() {
+		// 导出剩余的span
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("Tracing shutdown failed", "error", err)
+		}
+	}()
 
 	// 初始化数据库
 	db, err := database.Init(cfg.Database)
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 	defer database.Close(db)
+	services.NewEnvVariableService(cfg).CheckKey()
+
+	// 注册数据库连接池指标
+	if cfg.Metrics.Enabled {
+		if err := metrics.RegisterDB(db.DB()); err != nil {
+			logger.Error("Failed to register database metrics", "error", err)
+		}
+	}
 
 	// 设置Gin模式
 	if cfg.Server.Mode == "release" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	// 初始化并启动调度服务
-	if err := services.InitGlobalScheduler(); err != nil {
-		log.Fatal("Failed to start scheduler service:", err)
+	// 恢复异常中断的执行，并定期更新本实例执行的心跳
+	executionService := services.NewExecutionService(cfg)
+	stopHeartbeat := executionService.Start()
+	defer stopHeartbeat()
+
+	// 初始化并启动调度服务，退出时在等待正在执行的任务之前停止
+	if err := services.InitGlobalScheduler(cfg); err != nil {
+		return fmt.Errorf("failed to start scheduler service: %w", err)
 	}
-	defer services.StopGlobalScheduler()
+
+	// 定期清理回收站中过期的资源
+	stopPurge := services.NewTrashService().StartPurge(cfg.Trash.RetentionDays, cfg.Trash.PurgeInterval)
+	defer stopPurge()
 
 	// 初始化路由
 	r := routes.Setup(cfg)
+	srv := &http.Server{
+		Addr:    ":" + cfg.Server.Port,
+		Handler: r,
+	}
 
 	// 启动服务器
-	log.Printf("Server starting on port %s", cfg.Server.Port)
-	if err := r.Run(":" + cfg.Server.Port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("Server starting", "port", cfg.Server.Port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	// 收到SIGHUP时重新加载配置
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			reloadConfig(cfg)
+		}
+	}()
+
+	// 收到退出信号后依次停止接收请求、停止调度、等待正在执行的任务完成
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	var runErr error
+	select {
+	case err := <-serverErr:
+		runErr = fmt.Errorf("failed to start server: %w", err)
+	case sig := <-quit:
+		logger.Info("Shutting down", "signal", sig.String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout)*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error("Server shutdown failed", "error", err)
+	}
+	services.StopGlobalScheduler()
+
+	drainTimeout := time.Duration(cfg.Run.DrainTimeout) * time.Second
+	logger.Info("Waiting for running tasks", "timeout", drainTimeout.String())
+	if !executionService.Drain(drainTimeout) {
+		logger.Warn("Running tasks did not finish in time and were marked as interrupted")
+	}
+
+	if runErr == nil {
+		logger.Info("Server stopped")
+	}
+	return runErr
+}
+
+// reloadConfig 重新加载配置，只有可热更新的配置项生效，配置不合法时保持原配置
+func reloadConfig(cfg *config.Config) {
+	logger := utils.GetLogger()
+	result, err := cfg.Reload()
+	if err != nil {
+		logger.Error("Failed to reload configuration", "error", err)
+		return
+	}
+
+	logChanged := false
+	for _, key := range result.Applied {
+		switch {
+		case strings.HasPrefix(key, "log."):
+			logChanged = true
+		case key == "run.max_concurrent":
+			services.SetMaxConcurrent(result.Config.Run.MaxConcurrent)
+		}
+	}
+	if logChanged {
+		if err := utils.InitLogger(result.Config.Log); err != nil {
+			logger.Error("Failed to reload logger", "error", err)
+		}
+	}
+
+	logger.Info("Configuration reloaded", "applied", result.Applied)
+	if len(result.Ignored) > 0 {
+		logger.Warn("Configuration changes require restart", "keys", result.Ignored)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,128 @@
+// Package metrics 定义平台的Prometheus指标，通过 /metrics 接口暴露
+package metrics
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "seldom"
+
+// Registry 平台指标注册表，包含Go运行时和进程指标
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests HTTP请求数，route为路由模板，未匹配路由时为空
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPDuration HTTP请求耗时
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// SchedulerEntries 调度器中的定时任务数
+	SchedulerEntries = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "entries",
+		Help:      "Scheduled tasks registered in the scheduler.",
+	})
+
+	// SchedulerFires 定时任务触发次数，result为started、skipped或failed
+	SchedulerFires = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "fires_total",
+		Help:      "Scheduled task fires by result.",
+	}, []string{"result"})
+
+	// RunQueueDepth 已接受但等待执行槽位的执行数
+	RunQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "executor",
+		Name:      "queue_depth",
+		Help:      "Runs accepted and waiting for an execution slot.",
+	})
+
+	// ActiveRuns 正在执行的任务和用例数
+	ActiveRuns = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "executor",
+		Name:      "active_runs",
+		Help:      "Task and case runs currently executing on this instance.",
+	}, []string{"kind"})
+
+	// Runs 执行结束的任务和用例数，status为执行结果
+	Runs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "executor",
+		Name:      "runs_total",
+		Help:      "Finished task and case runs by kind and status.",
+	}, []string{"kind", "status"})
+
+	// RunDuration 执行耗时
+	RunDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "executor",
+		Name:      "run_duration_seconds",
+		Help:      "Task and case run duration.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600},
+	}, []string{"kind"})
+
+	// CaseResults 用例执行结果数，按项目统计
+	CaseResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "executor",
+		Name:      "case_results_total",
+		Help:      "Executed test cases by project and status.",
+	}, []string{"project_id", "status"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		SchedulerEntries,
+		SchedulerFires,
+		RunQueueDepth,
+		ActiveRuns,
+		Runs,
+		RunDuration,
+		CaseResults,
+	)
+}
+
+// RegisterDB 注册数据库连接池指标，已注册时忽略
+func RegisterDB(db *sql.DB) error {
+	err := Registry.Register(collectors.NewDBStatsCollector(db, namespace))
+	if _, ok := err.(prometheus.AlreadyRegisteredError); ok {
+		return nil
+	}
+	return err
+}
+
+// ObserveRun 记录一次执行的结果和耗时
+func ObserveRun(kind, status string, duration time.Duration) {
+	Runs.WithLabelValues(kind, status).Inc()
+	RunDuration.WithLabelValues(kind).Observe(duration.Seconds())
+}
+
+// ObserveCase 记录一个用例的执行结果
+func ObserveCase(projectID uint, status string) {
+	CaseResults.WithLabelValues(strconv.FormatUint(uint64(projectID), 10), status).Inc()
+}
@@ -3,15 +3,20 @@ package middleware
 import (
 	"net/http"
 	"seldom-platform/config"
+	"seldom-platform/services"
 	"seldom-platform/utils"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
+// claimsKey 上下文中保存JWT声明的键
+const claimsKey = "jwt_claims"
+
 // AuthMiddleware JWT认证中间件
 func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+	authService := services.NewAuthService(cfg)
+
 	return func(c *gin.Context) {
 		// 获取Authorization头
 		authHeader := c.GetHeader("Authorization")
@@ -38,15 +43,8 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
 		// 验证token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// 验证签名方法
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(cfg.JWT.Secret), nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := utils.ParseJWT(tokenString, cfg.JWT.Secret)
+		if err != nil || authService.IsTokenRevoked(claims) {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "Invalid token",
 				"code":  401,
@@ -56,9 +54,16 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 		}
 
 		// 提取用户信息
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Set("user_id", claims["user_id"])
-			c.Set("username", claims["username"])
+		setClaims(c, claims)
+
+		// 用户被禁用后立即失效
+		if user := CurrentUser(c); user == nil || !user.IsActive {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "User not authenticated",
+				"code":  401,
+			})
+			c.Abort()
+			return
 		}
 
 		c.Next()
@@ -67,29 +72,35 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 
 // OptionalAuthMiddleware 可选认证中间件（某些接口不需要认证）
 func OptionalAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+	authService := services.NewAuthService(cfg)
+
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader != "" && strings.HasPrefix(authHeader, "Bearer ") {
 			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-			token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, jwt.ErrSignatureInvalid
-				}
-				return []byte(cfg.JWT.Secret), nil
-			})
-
-			if err == nil && token.Valid {
-				if claims, ok := token.Claims.(jwt.MapClaims); ok {
-					c.Set("user_id", claims["user_id"])
-					c.Set("username", claims["username"])
-				}
+			claims, err := utils.ParseJWT(tokenString, cfg.JWT.Secret)
+			if err == nil && !authService.IsTokenRevoked(claims) {
+				setClaims(c, claims)
 			}
 		}
 		c.Next()
 	}
 }
 
-// GenerateToken 生成JWT token
-func GenerateToken(userID uint, username string, cfg *config.Config) (string, error) {
-	return utils.GenerateJWT(userID, username, cfg.JWT.Secret, cfg.JWT.Expire)
-}
\ No newline at end of file
+// CurrentClaims 获取当前请求的JWT声明，需在AuthMiddleware之后使用
+func CurrentClaims(c *gin.Context) *utils.JWTClaims {
+	if value, exists := c.Get(claimsKey); exists {
+		if claims, ok := value.(*utils.JWTClaims); ok {
+			return claims
+		}
+	}
+	return nil
+}
+
+// setClaims 将JWT声明写入上下文，用户ID同时写入日志字段
+func setClaims(c *gin.Context, claims *utils.JWTClaims) {
+	c.Set(claimsKey, claims)
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Request = c.Request.WithContext(utils.WithLogFields(c.Request.Context(), "user_id", claims.UserID))
+}
@@ -1,49 +1,63 @@
 package middleware
 
 import (
+	"net/http"
+	"strconv"
+
+	"seldom-platform/config"
+
 	"github.com/gin-gonic/gin"
 )
 
-// CORSMiddleware 跨域中间件
-func CORSMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		method := c.Request.Method
-		origin := c.Request.Header.Get("Origin")
-
-		// 允许的源
-		allowedOrigins := []string{
-			"http://127.0.0.1:3000",
-			"http://127.0.0.1:5173",
-			"http://localhost:3000",
-			"http://localhost:5173",
+// CORSMiddleware 跨域中间件，只有允许的源可以跨域访问；不允许的源的预检请求返回403，
+// 其他请求照常处理但不返回跨域响应头，由浏览器拦截
+func CORSMiddleware(cfg config.CORSConfig) gin.HandlerFunc {
+	allowAll := false
+	allowed := make(map[string]bool, len(cfg.AllowedOrigins))
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			allowAll = true
 		}
+		allowed[origin] = true
+	}
+	maxAge := strconv.Itoa(cfg.MaxAge)
 
-		// 检查是否为允许的源
-		allowed := false
-		for _, allowedOrigin := range allowedOrigins {
-			if origin == allowedOrigin {
-				allowed = true
-				break
-			}
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
 		}
 
-		if allowed {
+		c.Header("Vary", "Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		switch {
+		case allowed[origin]:
 			c.Header("Access-Control-Allow-Origin", origin)
-		} else {
+			if cfg.AllowCredentials {
+				c.Header("Access-Control-Allow-Credentials", "true")
+			}
+		case allowAll:
 			c.Header("Access-Control-Allow-Origin", "*")
+		default:
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
 		}
-
-		c.Header("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, UPDATE")
-		c.Header("Access-Control-Allow-Headers", "Origin, X-Requested-With, Content-Type, Accept, Authorization")
-		c.Header("Access-Control-Expose-Headers", "Content-Length, Access-Control-Allow-Origin, Access-Control-Allow-Headers, Cache-Control, Content-Language, Content-Type")
-		c.Header("Access-Control-Allow-Credentials", "true")
+		c.Header("Access-Control-Expose-Headers", "Content-Length, Content-Disposition, X-Request-ID, X-Trace-ID, Retry-After")
 
 		// 处理预检请求
-		if method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		if preflight {
+			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			c.Header("Access-Control-Allow-Headers", "Origin, X-Requested-With, Content-Type, Accept, Authorization, X-Request-ID")
+			c.Header("Access-Control-Max-Age", maxAge)
+			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"seldom-platform/utils"
+)
+
+// djangoWriter 缓存响应内容，以便将共用中间件的错误响应转换为Django兼容格式
+type djangoWriter struct {
+	gin.ResponseWriter
+	body   bytes.Buffer
+	status int
+}
+
+func (w *djangoWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *djangoWriter) WriteHeaderNow() {}
+
+func (w *djangoWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *djangoWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *djangoWriter) Status() int {
+	return w.status
+}
+
+func (w *djangoWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *djangoWriter) Written() bool {
+	return w.body.Len() > 0
+}
+
+// DjangoEnvelope Django兼容接口响应中间件
+// 认证、权限、限流等共用中间件返回的错误会被转换为Django兼容的响应结构：
+// 认证失败保持401状态码，其余错误与原后端一致使用200状态码
+func DjangoEnvelope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		original := c.Writer
+		writer := &djangoWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = writer
+		c.Next()
+		c.Writer = original
+
+		status, body := writer.status, writer.body.Bytes()
+		if convertedStatus, converted, ok := djangoErrorBody(status, body); ok {
+			status, body = convertedStatus, converted
+			original.Header().Set("Content-Type", "application/json; charset=utf-8")
+		}
+		original.WriteHeader(status)
+		if len(body) > 0 {
+			original.Write(body)
+		}
+	}
+}
+
+// djangoErrorBody 将非Django格式的错误响应转换为Django兼容结构，已是Django格式或成功响应时返回false
+func djangoErrorBody(status int, body []byte) (int, []byte, bool) {
+	if status < http.StatusBadRequest {
+		return 0, nil, false
+	}
+
+	var payload map[string]interface{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &payload); err != nil {
+			payload = nil
+		}
+	}
+	if _, ok := payload["success"]; ok {
+		return 0, nil, false
+	}
+
+	var djangoErr utils.DjangoError
+	switch {
+	case status == http.StatusUnauthorized:
+		djangoErr = utils.DjangoErrInvalidToken
+	case status == http.StatusForbidden:
+		djangoErr = utils.DjangoErrPermissionDenied
+	case status >= http.StatusInternalServerError:
+		djangoErr = utils.DjangoErrSystem
+	default:
+		djangoErr = utils.DjangoErrParamsType
+		if message, ok := payload["message"].(string); ok && message != "" {
+			djangoErr = djangoErr.WithMessage(message)
+		}
+	}
+
+	converted, _ := json.Marshal(utils.DjangoResponse{
+		Success: false,
+		Error:   djangoErr,
+		Result:  gin.H{},
+	})
+	if status != http.StatusUnauthorized {
+		status = http.StatusOK
+	}
+	return status, converted, true
+}
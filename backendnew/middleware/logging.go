@@ -7,7 +7,8 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// LoggingMiddleware 日志记录中间件
+// LoggingMiddleware 请求日志中间件，每个请求记录一条日志，带有request_id、user_id和trace_id，
+// 5xx响应记录为错误级别，4xx为警告级别
 func LoggingMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 记录开始时间
@@ -16,17 +17,29 @@ func LoggingMiddleware() gin.HandlerFunc {
 		// 处理请求
 		c.Next()
 
-		// 计算处理时间
-		duration := time.Since(startTime)
-
-		// 记录请求日志
-		utils.LogRequest(
-			c.Request.Method,
-			c.Request.URL.Path,
-			c.ClientIP(),
-			c.Writer.Status(),
-			duration,
-		)
+		status := c.Writer.Status()
+		args := []any{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"route", c.FullPath(),
+			"status", status,
+			"latency_ms", float64(time.Since(startTime).Microseconds())/1000,
+			"ip", c.ClientIP(),
+			"size", c.Writer.Size(),
+		}
+		if len(c.Errors) > 0 {
+			args = append(args, "errors", c.Errors.String())
+		}
+
+		logger := utils.GetLogger().Ctx(c.Request.Context())
+		switch {
+		case status >= 500:
+			logger.Error("request", args...)
+		case status >= 400:
+			logger.Warn("request", args...)
+		default:
+			logger.Info("request", args...)
+		}
 	}
 }
 
@@ -36,11 +49,13 @@ func ErrorLoggingMiddleware() gin.HandlerFunc {
 		c.Next()
 
 		// 检查是否有错误
-		if len(c.Errors) > 0 {
-			for _, err := range c.Errors {
-				utils.LogError("Request error: %s - Path: %s, Method: %s, IP: %s", 
-					err.Error(), c.Request.URL.Path, c.Request.Method, c.ClientIP())
-			}
+		for _, err := range c.Errors {
+			utils.GetLogger().Ctx(c.Request.Context()).Error("Request error",
+				"error", err.Error(),
+				"path", c.Request.URL.Path,
+				"method", c.Request.Method,
+				"ip", c.ClientIP(),
+			)
 		}
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"seldom-platform/metrics"
+	"seldom-platform/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsMiddleware 记录HTTP请求数和耗时，按路由模板统计，未匹配的路由统一记为空
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		startTime := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		method := metricsMethod(c.Request.Method)
+		metrics.HTTPRequests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		metrics.HTTPDuration.WithLabelValues(method, route).Observe(time.Since(startTime).Seconds())
+	}
+}
+
+// metricsMethod 标准的HTTP方法原样返回，其他方法统一记为other，避免任意方法名造成标签数量无限增长
+func metricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "other"
+}
+
+// MetricsAuth 校验 /metrics 接口的Bearer令牌，token为空时不校验
+func MetricsAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			utils.Unauthorized(c, "Invalid metrics token")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
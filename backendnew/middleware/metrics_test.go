@@ -0,0 +1,19 @@
+package middleware
+
+import "testing"
+
+func TestMetricsMethod(t *testing.T) {
+	tests := map[string]string{
+		"GET":     "GET",
+		"DELETE":  "DELETE",
+		"OPTIONS": "OPTIONS",
+		"get":     "other",
+		"PURGE":   "other",
+		"":        "other",
+	}
+	for method, want := range tests {
+		if got := metricsMethod(method); got != want {
+			t.Errorf("metricsMethod(%q) = %q, want %q", method, got, want)
+		}
+	}
+}
@@ -0,0 +1,432 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"seldom-platform/database"
+	"seldom-platform/models"
+	"seldom-platform/services"
+	"seldom-platform/utils"
+)
+
+// currentUserKey 上下文中缓存当前用户的键
+const currentUserKey = "current_user"
+
+// errSkipPermission 解析器返回该错误时跳过权限校验（例如列表接口未指定项目，由处理器自行过滤）
+var errSkipPermission = errors.New("skip permission check")
+
+// permissionError 权限范围解析错误
+type permissionError struct {
+	status  int
+	message string
+}
+
+func (e *permissionError) Error() string {
+	return e.message
+}
+
+// ScopeResolver 从请求中解析权限校验范围
+type ScopeResolver func(c *gin.Context) (services.PermissionScope, error)
+
+// CurrentUser 获取当前登录用户，需在AuthMiddleware之后使用
+func CurrentUser(c *gin.Context) *models.User {
+	if value, exists := c.Get(currentUserKey); exists {
+		if user, ok := value.(*models.User); ok {
+			return user
+		}
+	}
+
+	userID, ok := CurrentUserID(c)
+	if !ok {
+		return nil
+	}
+
+	var user models.User
+	if err := database.GetDB().First(&user, userID).Error; err != nil {
+		return nil
+	}
+	c.Set(currentUserKey, &user)
+	return &user
+}
+
+// CurrentUserID 获取当前登录用户ID
+func CurrentUserID(c *gin.Context) (uint, bool) {
+	value, exists := c.Get("user_id")
+	if !exists {
+		return 0, false
+	}
+
+	switch id := value.(type) {
+	case float64:
+		return uint(id), true
+	case uint:
+		return id, true
+	case int:
+		return uint(id), true
+	}
+	return 0, false
+}
+
+// RequireRole 角色权限中间件，要求当前用户在解析出的项目/团队中至少拥有minRole角色
+func RequireRole(minRole string, resolve ScopeResolver) gin.HandlerFunc {
+	permissionService := services.NewPermissionService()
+
+	return func(c *gin.Context) {
+		user := CurrentUser(c)
+		if user == nil || !user.IsActive {
+			utils.Unauthorized(c, "User not authenticated")
+			c.Abort()
+			return
+		}
+
+		scope, err := resolve(c)
+		if err != nil {
+			if errors.Is(err, errSkipPermission) {
+				c.Next()
+				return
+			}
+			var permErr *permissionError
+			if errors.As(err, &permErr) {
+				utils.Error(c, permErr.status, permErr.message)
+			} else {
+				utils.BadRequest(c, err.Error())
+			}
+			c.Abort()
+			return
+		}
+
+		if !permissionService.HasRole(user, scope, minRole) {
+			utils.Forbidden(c, "Permission denied")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireStaff 员工权限中间件，只有员工或超级用户可以访问
+func RequireStaff() gin.HandlerFunc {
+	permissionService := services.NewPermissionService()
+
+	return func(c *gin.Context) {
+		user := CurrentUser(c)
+		if user == nil || !user.IsActive {
+			utils.Unauthorized(c, "User not authenticated")
+			c.Abort()
+			return
+		}
+
+		if !permissionService.CanCreate(user) {
+			utils.Forbidden(c, "Permission denied")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireSuperuser 超级用户权限中间件
+func RequireSuperuser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := CurrentUser(c)
+		if user == nil || !user.IsActive {
+			utils.Unauthorized(c, "User not authenticated")
+			c.Abort()
+			return
+		}
+
+		if !user.IsSuperuser {
+			utils.Forbidden(c, "Permission denied")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ProjectFromParam 从路径参数中解析项目
+func ProjectFromParam(name string) ScopeResolver {
+	return func(c *gin.Context) (services.PermissionScope, error) {
+		id, err := parseID(c.Param(name))
+		if err != nil {
+			return services.PermissionScope{}, err
+		}
+
+		var project models.Project
+		if err := database.GetDB().Select("id").First(&project, id).Error; err != nil {
+			return services.PermissionScope{}, &permissionError{http.StatusNotFound, "Project not found"}
+		}
+		return services.PermissionScope{ProjectID: project.ID}, nil
+	}
+}
+
+// ProjectFromQuery 从查询参数中解析项目，未指定时跳过校验
+func ProjectFromQuery(name string) ScopeResolver {
+	return func(c *gin.Context) (services.PermissionScope, error) {
+		value := c.Query(name)
+		if value == "" {
+			return services.PermissionScope{}, errSkipPermission
+		}
+
+		id, err := parseID(value)
+		if err != nil {
+			return services.PermissionScope{}, err
+		}
+		return services.PermissionScope{ProjectID: id}, nil
+	}
+}
+
+// ScopeFromQuery 从查询参数中解析项目和团队，两者都未指定时跳过校验
+func ScopeFromQuery(projectName, teamName string) ScopeResolver {
+	return func(c *gin.Context) (services.PermissionScope, error) {
+		var scope services.PermissionScope
+		if value := c.Query(projectName); value != "" {
+			id, err := parseID(value)
+			if err != nil {
+				return scope, err
+			}
+			scope.ProjectID = id
+		}
+		if value := c.Query(teamName); value != "" {
+			id, err := parseID(value)
+			if err != nil {
+				return scope, err
+			}
+			scope.TeamID = id
+		}
+
+		if scope.ProjectID == 0 && scope.TeamID == 0 {
+			return scope, errSkipPermission
+		}
+		return scope, nil
+	}
+}
+
+// ProjectFromBody 从JSON请求体中解析项目，请求体会被还原供处理器继续读取
+func ProjectFromBody(field string) ScopeResolver {
+	return func(c *gin.Context) (services.PermissionScope, error) {
+		id, err := bodyID(c, field)
+		if err != nil {
+			return services.PermissionScope{}, err
+		}
+		return services.PermissionScope{ProjectID: id}, nil
+	}
+}
+
+// TeamFromBody 从JSON请求体中解析团队，未指定团队时跳过校验，已删除的团队视为不存在
+func TeamFromBody(field string) ScopeResolver {
+	return func(c *gin.Context) (services.PermissionScope, error) {
+		id, err := bodyID(c, field)
+		if err != nil {
+			return services.PermissionScope{}, err
+		}
+
+		var team models.Team
+		if err := database.GetDB().Select("id").Where("is_delete = ?", false).First(&team, id).Error; err != nil {
+			return services.PermissionScope{}, &permissionError{http.StatusNotFound, "Team not found"}
+		}
+		return services.PermissionScope{TeamID: team.ID}, nil
+	}
+}
+
+// TeamFromParam 从路径参数中解析团队
+func TeamFromParam(name string) ScopeResolver {
+	return func(c *gin.Context) (services.PermissionScope, error) {
+		id, err := parseID(c.Param(name))
+		if err != nil {
+			return services.PermissionScope{}, err
+		}
+
+		var team models.Team
+		if err := database.GetDB().Select("id").First(&team, id).Error; err != nil {
+			return services.PermissionScope{}, &permissionError{http.StatusNotFound, "Team not found"}
+		}
+		return services.PermissionScope{TeamID: team.ID}, nil
+	}
+}
+
+// EnvFromParam 从路径参数中解析环境所属的项目，全局环境解析为全局范围
+func EnvFromParam(name string) ScopeResolver {
+	return func(c *gin.Context) (services.PermissionScope, error) {
+		id, err := parseID(c.Param(name))
+		if err != nil {
+			return services.PermissionScope{}, err
+		}
+
+		var env models.Env
+		if err := database.GetDB().Select("id, project_id").First(&env, id).Error; err != nil {
+			return services.PermissionScope{}, &permissionError{http.StatusNotFound, "Environment not found"}
+		}
+		if env.ProjectID == nil {
+			return services.PermissionScope{Global: true}, nil
+		}
+		return services.PermissionScope{ProjectID: *env.ProjectID}, nil
+	}
+}
+
+// EnvProjectFromBody 从JSON请求体中解析环境所属的项目，未指定项目时为全局环境
+func EnvProjectFromBody(field string) ScopeResolver {
+	return func(c *gin.Context) (services.PermissionScope, error) {
+		id, err := bodyID(c, field)
+		if errors.Is(err, errSkipPermission) {
+			return services.PermissionScope{Global: true}, nil
+		}
+		if err != nil {
+			return services.PermissionScope{}, err
+		}
+		return services.PermissionScope{ProjectID: id}, nil
+	}
+}
+
+// TaskFromParam 从路径参数中解析任务所属的项目和团队
+func TaskFromParam(name string) ScopeResolver {
+	return func(c *gin.Context) (services.PermissionScope, error) {
+		id, err := parseID(c.Param(name))
+		if err != nil {
+			return services.PermissionScope{}, err
+		}
+		return taskScope(id)
+	}
+}
+
+// CaseFromParam 从路径参数中解析用例所属的项目
+func CaseFromParam(name string) ScopeResolver {
+	return func(c *gin.Context) (services.PermissionScope, error) {
+		id, err := parseID(c.Param(name))
+		if err != nil {
+			return services.PermissionScope{}, err
+		}
+
+		var testCase models.TestCase
+		if err := database.GetDB().Select("id, project_id").First(&testCase, id).Error; err != nil {
+			return services.PermissionScope{}, &permissionError{http.StatusNotFound, "Test case not found"}
+		}
+		return services.PermissionScope{ProjectID: testCase.ProjectID}, nil
+	}
+}
+
+// ReportFromQuery 从查询参数中解析报告所属任务的项目和团队
+func ReportFromQuery(name string) ScopeResolver {
+	return func(c *gin.Context) (services.PermissionScope, error) {
+		id, err := parseID(c.Query(name))
+		if err != nil {
+			return services.PermissionScope{}, err
+		}
+		return reportScope(id)
+	}
+}
+
+// TaskFromQuery 从查询参数中解析任务所属的项目和团队
+func TaskFromQuery(name string) ScopeResolver {
+	return func(c *gin.Context) (services.PermissionScope, error) {
+		id, err := parseID(c.Query(name))
+		if err != nil {
+			return services.PermissionScope{}, err
+		}
+		return taskScope(id)
+	}
+}
+
+// TaskFromBody 从JSON请求体中解析任务所属的项目和团队
+func TaskFromBody(field string) ScopeResolver {
+	return func(c *gin.Context) (services.PermissionScope, error) {
+		id, err := bodyID(c, field)
+		if err != nil {
+			return services.PermissionScope{}, err
+		}
+		return taskScope(id)
+	}
+}
+
+// ReportFromParam 从路径参数中解析报告所属任务的项目和团队
+func ReportFromParam(name string) ScopeResolver {
+	return func(c *gin.Context) (services.PermissionScope, error) {
+		id, err := parseID(c.Param(name))
+		if err != nil {
+			return services.PermissionScope{}, err
+		}
+		return reportScope(id)
+	}
+}
+
+// reportScope 获取报告所属任务的权限范围
+func reportScope(reportID uint) (services.PermissionScope, error) {
+	var report models.TaskReport
+	if err := database.GetDB().Select("id, task_id").First(&report, reportID).Error; err != nil {
+		return services.PermissionScope{}, &permissionError{http.StatusNotFound, "Report not found"}
+	}
+	return taskScope(report.TaskID)
+}
+
+// taskScope 获取任务的权限范围
+func taskScope(taskID uint) (services.PermissionScope, error) {
+	var task models.TestTask
+	if err := database.GetDB().Select("id, project_id, team_id").First(&task, taskID).Error; err != nil {
+		return services.PermissionScope{}, &permissionError{http.StatusNotFound, "Task not found"}
+	}
+
+	scope := services.PermissionScope{ProjectID: task.ProjectID}
+	if task.TeamID != nil {
+		scope.TeamID = *task.TeamID
+	}
+	return scope, nil
+}
+
+// bodyID 从JSON请求体中读取资源ID并还原请求体，字段缺失或为空时返回errSkipPermission
+func bodyID(c *gin.Context, field string) (uint, error) {
+	payload, err := peekJSONBody(c)
+	if err != nil {
+		return 0, err
+	}
+
+	// 兼容以字符串传递的ID，例如Django兼容接口中的项目ID
+	switch value := payload[field].(type) {
+	case float64:
+		if value > 0 {
+			return uint(value), nil
+		}
+	case string:
+		if id, err := parseID(value); err == nil {
+			return id, nil
+		}
+	}
+	// 交给处理器处理参数校验
+	return 0, errSkipPermission
+}
+
+// peekJSONBody 读取JSON请求体并还原，供后续处理器继续读取
+func peekJSONBody(c *gin.Context) (map[string]interface{}, error) {
+	if c.Request.Body == nil {
+		return nil, errors.New("Invalid request format")
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, errors.New("Invalid request format")
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, errors.New("Invalid request format")
+	}
+	return payload, nil
+}
+
+// parseID 解析资源ID
+func parseID(value string) (uint, error) {
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || id == 0 {
+		return 0, errors.New("Invalid id")
+	}
+	return uint(id), nil
+}
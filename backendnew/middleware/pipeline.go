@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"seldom-platform/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Pipeline 按配置返回全局中间件，依次为：指标、链路追踪、请求ID、请求日志、安全响应头、跨域、
+// 请求体大小限制、全局限流和路由组策略。panic恢复需在此之前单独添加
+func Pipeline(cfg *config.Config) []gin.HandlerFunc {
+	var handlers []gin.HandlerFunc
+	if cfg.Metrics.Enabled {
+		handlers = append(handlers, MetricsMiddleware())
+	}
+	if cfg.Tracing.Enabled {
+		handlers = append(handlers, TracingMiddleware(cfg.Tracing.ServiceName), TraceIDMiddleware())
+	}
+	handlers = append(handlers, RequestIDMiddleware(), LoggingMiddleware())
+
+	settings := cfg.Middleware
+	if settings.SecurityHeaders {
+		handlers = append(handlers, SecurityHeaders())
+	}
+	handlers = append(handlers, CORSMiddleware(settings.CORS))
+	if settings.MaxBodySize > 0 {
+		handlers = append(handlers, RequestSizeLimit(int64(settings.MaxBodySize)<<20))
+	}
+	if settings.RateLimit > 0 {
+		handlers = append(handlers, RateLimitMiddleware(settings.RateLimit, time.Minute))
+	}
+	if len(settings.Groups) > 0 {
+		handlers = append(handlers, GroupPolicyMiddleware(settings.Groups))
+	}
+	return handlers
+}
+
+// groupPolicy 解析后的路由组策略
+type groupPolicy struct {
+	prefix      string
+	allowlist   *ipAllowlist
+	limiter     *RateLimiter
+	maxBodySize int64
+}
+
+// matches 路径是否属于路由组，按路径段匹配，/api/user不匹配/api/users
+func (p *groupPolicy) matches(path string) bool {
+	return p.prefix == "/" || path == p.prefix || strings.HasPrefix(path, p.prefix+"/")
+}
+
+// GroupPolicyMiddleware 路由组策略中间件，请求路径匹配多个路由组时从短到长依次检查IP白名单、请求体大小和限流，
+// 每个路由组单独计数
+func GroupPolicyMiddleware(groups map[string]config.GroupPolicy) gin.HandlerFunc {
+	policies := make([]*groupPolicy, 0, len(groups))
+	for prefix, group := range groups {
+		policy := &groupPolicy{prefix: prefix, maxBodySize: int64(group.MaxBodySize) << 20}
+		if len(group.IPAllowlist) > 0 {
+			policy.allowlist = newIPAllowlist(group.IPAllowlist)
+		}
+		if group.RateLimit > 0 {
+			policy.limiter = NewRateLimiter(group.RateLimit, time.Minute)
+		}
+		if policy.allowlist != nil || policy.limiter != nil || policy.maxBodySize > 0 {
+			policies = append(policies, policy)
+		}
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return len(policies[i].prefix) < len(policies[j].prefix)
+	})
+
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		for _, policy := range policies {
+			if !policy.matches(path) {
+				continue
+			}
+			if policy.allowlist != nil && !policy.allowlist.allows(c.ClientIP()) {
+				rejectIP(c)
+				return
+			}
+			if policy.maxBodySize > 0 && !limitBody(c, policy.maxBodySize) {
+				return
+			}
+			if policy.limiter != nil && !policy.limiter.Allow(c.ClientIP()) {
+				tooManyRequests(c, time.Minute)
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
@@ -2,6 +2,9 @@ package middleware
 
 import (
 	"net/http"
+	"seldom-platform/utils"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -105,11 +108,7 @@ func RateLimitMiddleware(rate int, window time.Duration) gin.HandlerFunc {
 		ip := c.ClientIP()
 		
 		if !limiter.Allow(ip) {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "Too many requests",
-				"code":  429,
-			})
-			c.Abort()
+			tooManyRequests(c, window)
 			return
 		}
 
@@ -123,6 +122,42 @@ func APIRateLimitMiddleware() gin.HandlerFunc {
 }
 
 // AuthRateLimitMiddleware 认证接口限流中间件（防止暴力破解）
-func AuthRateLimitMiddleware() gin.HandlerFunc {
-	return RateLimitMiddleware(10, time.Minute) // 每分钟10个请求
-}
\ No newline at end of file
+// 按客户端IP和请求体中的用户名（或邮箱）分别计数，任一超限即拒绝，rate为每分钟允许的请求数
+func AuthRateLimitMiddleware(rate int) gin.HandlerFunc {
+	ipLimiter := NewRateLimiter(rate, time.Minute)
+	userLimiter := NewRateLimiter(rate, time.Minute)
+
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+		allowed := ipLimiter.Allow(ip)
+
+		username := ""
+		if payload, err := peekJSONBody(c); err == nil {
+			username, _ = payload["username"].(string)
+			if username == "" {
+				username, _ = payload["email"].(string)
+			}
+		}
+		if username != "" && !userLimiter.Allow(strings.ToLower(username)) {
+			allowed = false
+		}
+
+		if !allowed {
+			utils.GetLogger().Ctx(c.Request.Context()).Auth("rate_limit "+c.Request.URL.Path, username, ip, false)
+			tooManyRequests(c, time.Minute)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// tooManyRequests 拒绝超出限流的请求，Retry-After为限流的时间窗口
+func tooManyRequests(c *gin.Context, window time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(window.Seconds())))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error": "Too many requests",
+		"code":  429,
+	})
+	c.Abort()
+}
@@ -13,17 +13,13 @@ import (
 func RecoveryMiddleware() gin.HandlerFunc {
 	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
 		// 记录panic信息
-		stack := debug.Stack()
-		errorMsg := fmt.Sprintf("Panic recovered: %v\nStack trace:\n%s", recovered, string(stack))
-		
-		// 记录到日志
-		if logger := utils.GetLogger(); logger != nil {
-			logger.LogError("PANIC", errorMsg, map[string]interface{}{
-				"method": c.Request.Method,
-				"path":   c.Request.URL.Path,
-				"ip":     c.ClientIP(),
-			})
-		}
+		utils.GetLogger().Ctx(c.Request.Context()).Error("Panic recovered",
+			"panic", fmt.Sprintf("%v", recovered),
+			"stack", string(debug.Stack()),
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"ip", c.ClientIP(),
+		)
 
 		// 返回500错误
 		utils.InternalServerError(c, "服务器内部错误")
@@ -47,18 +43,14 @@ func CustomRecoveryWithWriter() gin.HandlerFunc {
 
 		// 记录详细的错误信息
 		stack := debug.Stack()
-		fullErrorMsg := fmt.Sprintf("Panic: %s\nStack: %s", errorMsg, string(stack))
-		
-		// 记录到日志
-		if logger := utils.GetLogger(); logger != nil {
-			logger.LogError("PANIC_RECOVERY", fullErrorMsg, map[string]interface{}{
-				"method":     c.Request.Method,
-				"path":       c.Request.URL.Path,
-				"ip":         c.ClientIP(),
-				"user_agent": c.Request.UserAgent(),
-				"headers":    c.Request.Header,
-			})
-		}
+		utils.GetLogger().Ctx(c.Request.Context()).Error("Panic recovered",
+			"panic", errorMsg,
+			"stack", string(stack),
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"ip", c.ClientIP(),
+			"user_agent", c.Request.UserAgent(),
+		)
 
 		// 根据Accept头返回不同格式的错误
 		accept := c.GetHeader("Accept")
@@ -84,23 +76,15 @@ func CustomRecoveryWithWriter() gin.HandlerFunc {
 
 // PanicHandler 处理panic的函数
 func PanicHandler(c *gin.Context, err interface{}) {
-	// 获取堆栈信息
-	stack := debug.Stack()
-	
-	// 构造错误信息
-	errorInfo := map[string]interface{}{
-		"error":      fmt.Sprintf("%v", err),
-		"method":     c.Request.Method,
-		"path":       c.Request.URL.Path,
-		"ip":         c.ClientIP(),
-		"user_agent": c.Request.UserAgent(),
-		"stack":      string(stack),
-	}
-
 	// 记录错误日志
-	if logger := utils.GetLogger(); logger != nil {
-		logger.LogError("PANIC", fmt.Sprintf("Panic recovered: %v", err), errorInfo)
-	}
+	utils.GetLogger().Ctx(c.Request.Context()).Error("Panic recovered",
+		"panic", fmt.Sprintf("%v", err),
+		"stack", string(debug.Stack()),
+		"method", c.Request.Method,
+		"path", c.Request.URL.Path,
+		"ip", c.ClientIP(),
+		"user_agent", c.Request.UserAgent(),
+	)
 
 	// 返回错误响应
 	utils.InternalServerError(c, "服务器发生了意外错误")
@@ -124,14 +108,10 @@ func SafeAsyncHandler(handler func()) {
 		defer func() {
 			if err := recover(); err != nil {
 				// 记录异步任务中的panic
-				stack := debug.Stack()
-				errorMsg := fmt.Sprintf("Async panic recovered: %v\nStack: %s", err, string(stack))
-				
-				if logger := utils.GetLogger(); logger != nil {
-					logger.LogError("ASYNC_PANIC", errorMsg, map[string]interface{}{
-						"type": "async_task",
-					})
-				}
+				utils.GetLogger().Error("Async panic recovered",
+					"panic", fmt.Sprintf("%v", err),
+					"stack", string(debug.Stack()),
+				)
 			}
 		}()
 		handler()
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"net"
 	"net/http"
 	"strings"
 
@@ -38,36 +39,76 @@ func SecurityHeaders() gin.HandlerFunc {
 	}
 }
 
-// IPWhitelistMiddleware IP白名单中间件
+// ipAllowlist IP白名单，条目为IP或CIDR，*表示允许所有IP
+type ipAllowlist struct {
+	all  bool
+	nets []*net.IPNet
+}
+
+// newIPAllowlist 解析白名单条目，忽略无法解析的条目
+func newIPAllowlist(entries []string) *ipAllowlist {
+	list := &ipAllowlist{}
+	for _, entry := range entries {
+		if entry == "*" {
+			list.all = true
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			list.nets = append(list.nets, ipNet)
+		}
+	}
+	return list
+}
+
+func (l *ipAllowlist) allows(clientIP string) bool {
+	if l.all {
+		return true
+	}
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range l.nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// IPWhitelistMiddleware IP白名单中间件，allowedIPs为IP或CIDR，客户端IP只采用可信代理设置的X-Forwarded-For
 func IPWhitelistMiddleware(allowedIPs []string) gin.HandlerFunc {
+	allowlist := newIPAllowlist(allowedIPs)
 	return func(c *gin.Context) {
-		clientIP := utils.GetClientIP(c.Request)
-		
 		// 如果没有配置白名单，则允许所有IP
 		if len(allowedIPs) == 0 {
 			c.Next()
 			return
 		}
-		
-		// 检查IP是否在白名单中
-		allowed := false
-		for _, ip := range allowedIPs {
-			if ip == clientIP || ip == "*" {
-				allowed = true
-				break
-			}
-		}
-		
-		if !allowed {
-			utils.Forbidden(c, "IP地址不在允许范围内")
-			c.Abort()
+
+		if !allowlist.allows(c.ClientIP()) {
+			rejectIP(c)
 			return
 		}
-		
+
 		c.Next()
 	}
 }
 
+// rejectIP 拒绝不在白名单中的请求
+func rejectIP(c *gin.Context) {
+	utils.GetLogger().Ctx(c.Request.Context()).Warn("IP not allowed", "ip", c.ClientIP(), "path", c.Request.URL.Path)
+	utils.Forbidden(c, "IP地址不在允许范围内")
+	c.Abort()
+}
+
 // UserAgentFilterMiddleware 用户代理过滤中间件
 func UserAgentFilterMiddleware(blockedUserAgents []string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -86,19 +127,30 @@ func UserAgentFilterMiddleware(blockedUserAgents []string) gin.HandlerFunc {
 	}
 }
 
-// RequestSizeLimit 请求大小限制中间件
+// RequestSizeLimit 请求大小限制中间件，maxSize为字节数
 func RequestSizeLimit(maxSize int64) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if c.Request.ContentLength > maxSize {
-			utils.BadRequest(c, "请求体过大")
-			c.Abort()
+		if !limitBody(c, maxSize) {
 			return
 		}
-		
+
 		c.Next()
 	}
 }
 
+// limitBody 限制请求体大小，Content-Length超出时返回413；没有Content-Length的请求读取超出maxSize时返回错误
+func limitBody(c *gin.Context, maxSize int64) bool {
+	if c.Request.ContentLength > maxSize {
+		utils.Error(c, http.StatusRequestEntityTooLarge, "请求体过大")
+		c.Abort()
+		return false
+	}
+	if c.Request.Body != nil {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
+	}
+	return true
+}
+
 // HTTPSRedirect HTTPS重定向中间件
 func HTTPSRedirect() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -175,11 +227,14 @@ func NoCache() gin.HandlerFunc {
 	}
 }
 
-// RequestIDMiddleware 请求ID中间件
+// RequestIDKey 请求ID在上下文中的键
+const RequestIDKey = "request_id"
+
+// RequestIDMiddleware 请求ID中间件，请求ID同时写入日志字段
 func RequestIDMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID := c.GetHeader("X-Request-ID")
-		if requestID == "" {
+		if requestID == "" || len(requestID) > 64 {
 			// 生成新的请求ID
 			if id, err := utils.GenerateRandomString(16); err == nil {
 				requestID = id
@@ -189,7 +244,8 @@ func RequestIDMiddleware() gin.HandlerFunc {
 		}
 		
 		c.Header("X-Request-ID", requestID)
-		c.Set("request_id", requestID)
+		c.Set(RequestIDKey, requestID)
+		c.Request = c.Request.WithContext(utils.WithLogFields(c.Request.Context(), "request_id", requestID))
 		c.Next()
 	}
 }
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+
+	"seldom-platform/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// TraceIDKey 链路追踪ID在上下文中的键
+const TraceIDKey = "trace_id"
+
+// 不记录span的路径，指标采集和健康检查探针
+var untracedPaths = map[string]bool{
+	"/metrics": true,
+	"/health":  true,
+	"/livez":   true,
+	"/readyz":  true,
+}
+
+// TracingMiddleware 为每个请求创建span，span名为路由模板，指标和健康检查接口不记录
+func TracingMiddleware(serviceName string) gin.HandlerFunc {
+	return otelgin.Middleware(serviceName, otelgin.WithFilter(func(r *http.Request) bool {
+		return !untracedPaths[r.URL.Path]
+	}))
+}
+
+// TraceIDMiddleware 把请求的trace ID写入上下文和X-Trace-ID响应头，需放在TracingMiddleware之后
+func TraceIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if traceID := tracing.TraceID(c.Request.Context()); traceID != "" {
+			c.Set(TraceIDKey, traceID)
+			c.Header("X-Trace-ID", traceID)
+		}
+		c.Next()
+	}
+}
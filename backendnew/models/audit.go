@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// 审计操作类型
+const (
+	AuditActionCreate         = "create"
+	AuditActionUpdate         = "update"
+	AuditActionDelete         = "delete"
+	AuditActionRun            = "run"             // 执行任务
+	AuditActionResetPassword  = "reset_password"  // 管理员重置或通过邮件链接重置密码
+	AuditActionChangePassword = "change_password" // 用户校验旧密码后修改自己的密码
+	AuditActionLogout         = "logout"          // 强制下线
+	AuditActionRestore        = "restore"         // 从回收站恢复
+	AuditActionPurge          = "purge"           // 回收站到期清理
+)
+
+// 审计资源类型
+const (
+	AuditResourceProject       = "project"
+	AuditResourceEnv           = "env"
+	AuditResourceCase          = "case"
+	AuditResourceTask          = "task"
+	AuditResourceTeam          = "team"
+	AuditResourceProjectMember = "project_member"
+	AuditResourceTeamMember    = "team_member"
+	AuditResourceUser          = "user"
+)
+
+// JSONText 以文本保存的JSON，接口中按原始JSON输出
+type JSONText string
+
+// MarshalJSON 输出原始JSON，空值输出null
+func (j JSONText) MarshalJSON() ([]byte, error) {
+	if j == "" {
+		return []byte("null"), nil
+	}
+	return []byte(j), nil
+}
+
+// AuditLog 审计日志表，记录所有修改类接口的操作人、操作对象和修改前后的内容
+//
+// Before和After为JSON对象：创建只记录After，删除只记录Before，
+// 更新只记录发生变化的字段。
+type AuditLog struct {
+	ID           uint      `gorm:"primary_key" json:"id"`
+	ActorID      uint      `gorm:"index" json:"actor_id"`                                          // 操作人ID，0表示匿名
+	ActorName    string    `gorm:"size:150;default:''" json:"actor_name"`                          // 操作人用户名
+	Action       string    `gorm:"size:32;not null;index" json:"action"`                           // 操作类型
+	ResourceType string    `gorm:"size:32;not null;index:idx_audit_resource" json:"resource_type"` // 资源类型
+	ResourceID   uint      `gorm:"index:idx_audit_resource" json:"resource_id"`                    // 资源ID
+	Before       JSONText  `gorm:"type:text" json:"before" swaggertype:"object"`                   // 修改前
+	After        JSONText  `gorm:"type:text" json:"after" swaggertype:"object"`                    // 修改后
+	IP           string    `gorm:"column:ip;size:64;default:''" json:"ip"`                         // 客户端IP
+	RequestID    string    `gorm:"size:64;default:'';index" json:"request_id"`                     // 请求ID
+	CreateTime   time.Time `gorm:"autoCreateTime;index" json:"create_time"`                        // 操作时间
+}
+
+// TableName 指定表名
+func (AuditLog) TableName() string {
+	return "app_audit_log"
+}
+
+// BeforeCreate GORM钩子，创建前执行
+func (a *AuditLog) BeforeCreate(scope *gorm.Scope) error {
+	scope.SetColumn("CreateTime", time.Now())
+	return nil
+}
@@ -37,7 +37,7 @@ type TestCase struct {
 	CaseName   string    `gorm:"size:200;not null;default:''" json:"case_name"`                     // 方法名
 	CaseDoc    string    `gorm:"type:text;default:''" json:"case_doc"`                              // 方法描述
 	Label      string    `gorm:"type:text;default:''" json:"label"`                                 // 用例标签
-	Status     int       `gorm:"default:0" json:"status"`                                           // 状态 0未执行、1执行中、2已执行
+	Status     int       `gorm:"default:0" json:"status"`                                           // 状态 0未执行、1执行中、2已执行、3已中断
 	CaseHash   string    `gorm:"size:200;not null;default:''" json:"case_hash"`                     // 用例hash
 	CreateTime time.Time `gorm:"autoCreateTime" json:"create_time"`                                 // 创建时间
 	UpdateTime time.Time `gorm:"autoUpdateTime" json:"update_time"`                                 // 更新时间
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// 任务和用例的执行状态
+const (
+	RunStatusIdle        = 0 // 未执行
+	RunStatusRunning     = 1 // 执行中
+	RunStatusDone        = 2 // 已执行
+	RunStatusInterrupted = 3 // 已中断，服务退出或实例异常时未执行完
+)
+
+// 执行对象类型
+const (
+	ExecutionKindTask = "task"
+	ExecutionKindCase = "case"
+)
+
+// 执行记录状态
+const (
+	ExecutionRunning     = "running"
+	ExecutionFinished    = "finished"
+	ExecutionInterrupted = "interrupted"
+)
+
+// Execution 执行记录，记录任务或用例由哪个服务实例执行，实例定期更新心跳，
+// 心跳超时的执行视为没有存活的执行者，标记为已中断
+type Execution struct {
+	ID          uint       `gorm:"primary_key" json:"id"`
+	Kind        string     `gorm:"size:10;not null;index:idx_execution_target" json:"kind"` // 执行对象类型 task、case
+	TargetID    uint       `gorm:"not null;index:idx_execution_target" json:"target_id"`    // 任务ID或用例ID
+	RunID       string     `gorm:"size:32;default:''" json:"run_id"`                        // 任务执行批次ID
+	EnvID       *uint      `json:"env_id"`                                                  // 用例执行环境ID
+	Owner       string     `gorm:"size:100;not null;default:''" json:"owner"`               // 执行实例
+	Status      string     `gorm:"size:20;not null;default:'running';index" json:"status"`  // 状态 running、finished、interrupted
+	Requeued    bool       `gorm:"default:false" json:"requeued"`                           // 中断后是否已重新执行
+	HeartbeatAt time.Time  `json:"heartbeat_at"`                                            // 最近心跳时间
+	StartTime   time.Time  `gorm:"autoCreateTime" json:"start_time"`                        // 开始时间
+	EndTime     *time.Time `json:"end_time"`                                                // 结束或中断时间
+}
+
+// TableName 指定表名
+func (Execution) TableName() string {
+	return "app_task_execution"
+}
+
+// BeforeCreate 创建前设置开始时间和心跳时间
+func (e *Execution) BeforeCreate(scope *gorm.Scope) error {
+	now := time.Now()
+	scope.SetColumn("StartTime", now)
+	scope.SetColumn("HeartbeatAt", now)
+	return nil
+}
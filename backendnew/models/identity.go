@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// 外部身份源
+const (
+	IdentityProviderOIDC = "oidc"
+	IdentityProviderLDAP = "ldap"
+)
+
+// UserIdentity 外部身份源账号与平台用户的关联表
+//
+// 通过单点登录或目录服务首次登录时自动创建用户并记录关联，之后按 Provider + Subject 识别用户，
+// 外部账号改名不会产生新用户。
+type UserIdentity struct {
+	ID         uint       `gorm:"primary_key" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`                                   // 用户ID
+	Provider   string     `gorm:"size:20;not null;unique_index:idx_user_identity" json:"provider"` // 身份源
+	Subject    string     `gorm:"size:255;not null;unique_index:idx_user_identity" json:"subject"` // 身份源中的唯一标识
+	LastLogin  *time.Time `json:"last_login"`                                                      // 最后登录时间
+	CreateTime time.Time  `gorm:"autoCreateTime" json:"create_time"`                               // 创建时间
+}
+
+// TableName 指定表名
+func (UserIdentity) TableName() string {
+	return "app_user_identity"
+}
+
+// BeforeCreate GORM钩子，创建前执行
+func (i *UserIdentity) BeforeCreate(scope *gorm.Scope) error {
+	scope.SetColumn("CreateTime", time.Now())
+	return nil
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// 成员角色，权限从低到高依次为 viewer < runner < maintainer < owner
+const (
+	RoleViewer     = "viewer"     // 只读
+	RoleRunner     = "runner"     // 可执行任务
+	RoleMaintainer = "maintainer" // 可创建、修改资源
+	RoleOwner      = "owner"      // 可删除资源、管理成员
+)
+
+// roleLevels 角色等级
+var roleLevels = map[string]int{
+	RoleViewer:     1,
+	RoleRunner:     2,
+	RoleMaintainer: 3,
+	RoleOwner:      4,
+}
+
+// RoleLevel 获取角色等级，未知角色返回0
+func RoleLevel(role string) int {
+	return roleLevels[role]
+}
+
+// IsValidRole 检查角色是否合法
+func IsValidRole(role string) bool {
+	_, ok := roleLevels[role]
+	return ok
+}
+
+// ProjectMember 项目成员表
+type ProjectMember struct {
+	ID         uint      `gorm:"primary_key" json:"id"`
+	ProjectID  uint      `gorm:"not null;unique_index:idx_project_member" json:"project_id"` // 项目ID
+	UserID     uint      `gorm:"not null;unique_index:idx_project_member" json:"user_id"`    // 用户ID
+	User       User      `gorm:"foreignkey:UserID;save_associations:false" json:"user"`      // 用户关联
+	Role       string    `gorm:"size:20;not null;default:'viewer'" json:"role"`              // 角色
+	CreateTime time.Time `gorm:"autoCreateTime" json:"create_time"`                          // 创建时间
+	UpdateTime time.Time `gorm:"autoUpdateTime" json:"update_time"`                          // 更新时间
+}
+
+// TableName 指定表名
+func (ProjectMember) TableName() string {
+	return "app_project_member"
+}
+
+// TeamMember 团队成员表
+type TeamMember struct {
+	ID         uint      `gorm:"primary_key" json:"id"`
+	TeamID     uint      `gorm:"not null;unique_index:idx_team_member" json:"team_id"`  // 团队ID
+	UserID     uint      `gorm:"not null;unique_index:idx_team_member" json:"user_id"`  // 用户ID
+	User       User      `gorm:"foreignkey:UserID;save_associations:false" json:"user"` // 用户关联
+	Role       string    `gorm:"size:20;not null;default:'viewer'" json:"role"`         // 角色
+	CreateTime time.Time `gorm:"autoCreateTime" json:"create_time"`                     // 创建时间
+	UpdateTime time.Time `gorm:"autoUpdateTime" json:"update_time"`                     // 更新时间
+}
+
+// TableName 指定表名
+func (TeamMember) TableName() string {
+	return "app_team_member"
+}
+
+// BeforeCreate GORM钩子，创建前执行
+func (m *ProjectMember) BeforeCreate(scope *gorm.Scope) error {
+	now := time.Now()
+	scope.SetColumn("CreateTime", now)
+	scope.SetColumn("UpdateTime", now)
+	return nil
+}
+
+// BeforeUpdate GORM钩子，更新前执行
+func (m *ProjectMember) BeforeUpdate(scope *gorm.Scope) error {
+	scope.SetColumn("UpdateTime", time.Now())
+	return nil
+}
+
+// BeforeCreate GORM钩子，创建前执行
+func (m *TeamMember) BeforeCreate(scope *gorm.Scope) error {
+	now := time.Now()
+	scope.SetColumn("CreateTime", now)
+	scope.SetColumn("UpdateTime", now)
+	return nil
+}
+
+// BeforeUpdate GORM钩子，更新前执行
+func (m *TeamMember) BeforeUpdate(scope *gorm.Scope) error {
+	scope.SetColumn("UpdateTime", time.Now())
+	return nil
+}
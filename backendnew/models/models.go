@@ -6,6 +6,7 @@ package models
 // 项目相关模型
 // Project - 项目表
 // Env - 环境管理表
+// ProjectMember - 项目成员表
 
 // 用例相关模型  
 // TestCase - 测试用例表
@@ -17,9 +18,11 @@ package models
 // TaskCaseRelevance - 任务用例关联表
 // TaskReport - 任务报告表
 // ReportDetails - 报告详情表
+// Execution - 执行记录表
 
 // 团队相关模型
 // Team - 团队表
+// TeamMember - 团队成员表
 
 // 用户相关模型
 // User - 用户表
\ No newline at end of file
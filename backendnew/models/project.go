@@ -13,6 +13,7 @@ type Project struct {
 	Address    string    `gorm:"size:200;not null" json:"address" binding:"required"`               // 项目地址
 	CaseDir    string    `gorm:"size:200;default:'test_dir'" json:"case_dir"`                       // 用例目录
 	IsDelete   bool      `gorm:"default:false" json:"is_delete"`                                    // 删除
+	DeleteTime *time.Time `json:"delete_time"`                                                      // 删除时间，用于回收站到期清理
 	CreateTime time.Time `gorm:"autoCreateTime" json:"create_time"`                                 // 创建时间
 	UpdateTime time.Time `gorm:"autoUpdateTime" json:"update_time"`                                 // 更新时间
 	CoverName  string    `gorm:"size:64;default:''" json:"cover_name"`                              // 封面名称
@@ -31,6 +32,7 @@ func (Project) TableName() string {
 type Env struct {
 	ID           uint      `gorm:"primary_key" json:"id"`
 	Name         string    `gorm:"size:50;not null" json:"name" binding:"required"`        // 名称
+	ProjectID    *uint     `gorm:"index" json:"project_id"`                                // 所属项目，为空表示全局环境
 	TestType     string    `gorm:"size:20;default:'http'" json:"test_type"`                // 测试类型
 	Env          string    `gorm:"size:50;default:''" json:"env"`                          // 环境值
 	Rerun        int       `gorm:"default:0" json:"rerun"`                                 // 重跑次数
@@ -41,6 +43,7 @@ type Env struct {
 	AppServer    string    `gorm:"size:100;default:''" json:"app_server"`                  // APP服务
 	AppInfo      string    `gorm:"size:1000;default:'{}'" json:"app_info"`                 // APP信息
 	IsDelete     bool      `gorm:"default:false" json:"is_delete"`                         // 删除
+	DeleteTime   *time.Time `json:"delete_time"`                                           // 删除时间，用于回收站到期清理
 	CreateTime   time.Time `gorm:"autoCreateTime" json:"create_time"`                      // 创建时间
 	UpdateTime   time.Time `gorm:"autoUpdateTime" json:"update_time"`                      // 更新时间
 }
@@ -50,6 +53,23 @@ func (Env) TableName() string {
 	return "app_project_env"
 }
 
+// EnvVariable 环境变量，执行任务时注入seldom进程；密钥变量的值加密存储
+type EnvVariable struct {
+	ID          uint      `gorm:"primary_key" json:"id"`
+	EnvID       uint      `gorm:"not null;unique_index:idx_env_name" json:"env_id"`          // 环境ID
+	Name        string    `gorm:"size:100;not null;unique_index:idx_env_name" json:"name"`   // 变量名
+	Value       string    `gorm:"type:text;default:''" json:"value"`                         // 变量值，密钥变量为密文
+	IsSecret    bool      `gorm:"default:false" json:"is_secret"`                            // 是否为密钥
+	Description string    `gorm:"size:200;default:''" json:"description"`                    // 描述
+	CreateTime  time.Time `gorm:"autoCreateTime" json:"create_time"`                         // 创建时间
+	UpdateTime  time.Time `gorm:"autoUpdateTime" json:"update_time"`                         // 更新时间
+}
+
+// TableName 指定表名
+func (EnvVariable) TableName() string {
+	return "app_project_envvariable"
+}
+
 // BeforeCreate GORM钩子，创建前执行
 func (p *Project) BeforeCreate(scope *gorm.Scope) error {
 	now := time.Now()
@@ -76,4 +96,17 @@ func (e *Env) BeforeCreate(scope *gorm.Scope) error {
 func (e *Env) BeforeUpdate(scope *gorm.Scope) error {
 	scope.SetColumn("UpdateTime", time.Now())
 	return nil
-}
\ No newline at end of file
+}
+// BeforeCreate GORM钩子，创建前执行
+func (v *EnvVariable) BeforeCreate(scope *gorm.Scope) error {
+	now := time.Now()
+	scope.SetColumn("CreateTime", now)
+	scope.SetColumn("UpdateTime", now)
+	return nil
+}
+
+// BeforeUpdate GORM钩子，更新前执行
+func (v *EnvVariable) BeforeUpdate(scope *gorm.Scope) error {
+	scope.SetColumn("UpdateTime", time.Now())
+	return nil
+}
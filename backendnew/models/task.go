@@ -12,8 +12,9 @@ type TestTask struct {
 	ProjectID      uint      `gorm:"not null" json:"project_id"`                                       // 项目ID
 	Project        Project   `gorm:"foreignkey:ProjectID;constraint:OnDelete:CASCADE" json:"project"` // 项目关联
 	Name           string    `gorm:"size:200;not null;default:''" json:"name"`                         // 任务名
-	Status         int       `gorm:"default:0" json:"status"`                                          // 状态 0未执行、1执行中、2已执行
-	EnvID          *uint     `json:"env_id"`                                                           // 环境ID
+	Status         int       `gorm:"default:0" json:"status"`                                          // 状态 0未执行、1执行中、2已执行、3已中断
+	EnvID          *uint     `json:"env_id"`                                                           // 环境ID（第一个执行环境，兼容旧数据）
+	EnvIDs         []uint    `gorm:"-" json:"env_ids"`                                                 // 执行环境ID列表，每个环境执行一次
 	TeamID         *uint     `json:"team_id"`                                                          // 团队ID
 	Email          string    `gorm:"size:100" json:"email"`                                            // 发送告警邮箱
 	Timed          string    `gorm:"size:500;default:''" json:"timed"`                                 // 定时任务
@@ -21,6 +22,7 @@ type TestTask struct {
 	CronExpression string    `gorm:"size:200;default:''" json:"cron_expression"`                       // Cron表达式
 	ExecuteCount   int       `gorm:"default:0" json:"execute_count"`                                   // 执行次数
 	IsDelete       bool      `gorm:"default:false" json:"is_delete"`                                   // 删除
+	DeleteTime     *time.Time `json:"delete_time"`                                                     // 删除时间，用于回收站到期清理
 	CreateTime     time.Time `gorm:"autoCreateTime" json:"create_time"`                                // 创建时间
 	UpdateTime     time.Time `gorm:"autoUpdateTime" json:"update_time"`                                // 更新时间
 }
@@ -44,12 +46,28 @@ func (TaskCaseRelevance) TableName() string {
 	return "app_task_taskcaserelevance"
 }
 
+// TaskEnvRelevance 任务环境关联表，任务在每个关联环境中各执行一次
+type TaskEnvRelevance struct {
+	ID         uint      `gorm:"primary_key" json:"id"`
+	TaskID     uint      `gorm:"not null;unique_index:idx_task_env" json:"task_id"` // 任务ID
+	EnvID      uint      `gorm:"not null;unique_index:idx_task_env" json:"env_id"`  // 环境ID
+	CreateTime time.Time `gorm:"autoCreateTime" json:"create_time"`                 // 创建时间
+}
+
+// TableName 指定表名
+func (TaskEnvRelevance) TableName() string {
+	return "app_task_taskenvrelevance"
+}
+
 // TaskReport 任务报告
 type TaskReport struct {
 	ID         uint      `gorm:"primary_key" json:"id"`
 	TaskID     uint      `gorm:"not null" json:"task_id"`                                           // 任务ID
 	Task       TestTask  `gorm:"foreignkey:TaskID;constraint:OnDelete:CASCADE" json:"task"`        // 任务关联
 	Name       string    `gorm:"size:500;not null;default:''" json:"name"`                          // 名称
+	RunID      string    `gorm:"size:32;default:'';index" json:"run_id"`                            // 执行批次ID，同一次执行的各环境报告相同
+	EnvID      *uint     `json:"env_id"`                                                            // 执行环境ID
+	EnvName    string    `gorm:"size:50;default:''" json:"env_name"`                                // 执行环境名称
 	Report     string    `gorm:"type:text;default:''" json:"report"`                                // 报告内容
 	Passed     int       `gorm:"default:0" json:"passed"`                                           // 通过用例
 	Error      int       `gorm:"default:0" json:"error"`                                            // 错误用例
@@ -105,6 +123,12 @@ func (t *TaskCaseRelevance) BeforeCreate(scope *gorm.Scope) error {
 	return nil
 }
 
+// BeforeCreate GORM钩子，创建前执行
+func (t *TaskEnvRelevance) BeforeCreate(scope *gorm.Scope) error {
+	scope.SetColumn("CreateTime", time.Now())
+	return nil
+}
+
 // BeforeCreate GORM钩子，创建前执行
 func (t *TaskReport) BeforeCreate(scope *gorm.Scope) error {
 	scope.SetColumn("CreateTime", time.Now())
@@ -12,6 +12,7 @@ type Team struct {
 	Name       string    `gorm:"size:200;not null" json:"name" binding:"required"`   // 团队名
 	Email      string    `gorm:"type:text;default:''" json:"email"`                  // 团队邮箱
 	IsDelete   bool      `gorm:"default:false" json:"is_delete"`                     // 删除
+	DeleteTime *time.Time `json:"delete_time"`                                       // 删除时间，用于回收站到期清理
 	CreateTime time.Time `gorm:"autoCreateTime" json:"create_time"`                  // 创建时间
 	UpdateTime time.Time `gorm:"autoUpdateTime" json:"update_time"`                  // 更新时间
 }
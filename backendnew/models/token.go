@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// RefreshToken 刷新令牌表
+//
+// 只保存令牌的SHA256摘要。每次刷新都会吊销旧令牌并在同一会话（SessionID）下签发新令牌，
+// 已吊销的令牌再次被使用时视为泄露，整个会话随之失效。
+type RefreshToken struct {
+	ID         uint       `gorm:"primary_key" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`            // 用户ID
+	SessionID  string     `gorm:"size:64;not null;index" json:"session_id"` // 会话ID
+	TokenHash  string     `gorm:"size:64;not null;unique_index" json:"-"`   // 令牌摘要
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`               // 过期时间
+	RevokedAt  *time.Time `json:"revoked_at"`                               // 吊销时间
+	UserAgent  string     `gorm:"size:255;default:''" json:"user_agent"`    // 客户端
+	IP         string     `gorm:"column:ip;size:64;default:''" json:"ip"`   // 客户端IP
+	CreateTime time.Time  `gorm:"autoCreateTime" json:"create_time"`        // 创建时间
+}
+
+// TableName 指定表名
+func (RefreshToken) TableName() string {
+	return "app_user_refreshtoken"
+}
+
+// BeforeCreate GORM钩子，创建前执行
+func (t *RefreshToken) BeforeCreate(scope *gorm.Scope) error {
+	scope.SetColumn("CreateTime", time.Now())
+	return nil
+}
+
+// RevokedToken 已吊销的access token（黑名单），过期后可清理
+type RevokedToken struct {
+	ID        uint      `gorm:"primary_key" json:"id"`
+	JTI       string    `gorm:"column:jti;size:64;not null;unique_index" json:"jti"` // token唯一标识
+	UserID    uint      `gorm:"not null" json:"user_id"`                             // 用户ID
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`                    // token过期时间
+}
+
+// TableName 指定表名
+func (RevokedToken) TableName() string {
+	return "app_user_revokedtoken"
+}
+
+// LoginAttempt 账号登录失败记录，用于连续失败后临时锁定账号
+type LoginAttempt struct {
+	ID           uint       `gorm:"primary_key" json:"id"`
+	UserID       uint       `gorm:"not null;unique_index" json:"user_id"` // 用户ID
+	FailedCount  int        `gorm:"default:0" json:"failed_count"`        // 连续失败次数
+	LastFailedAt *time.Time `json:"last_failed_at"`                       // 最近失败时间
+	LockedUntil  *time.Time `json:"locked_until"`                         // 锁定截止时间
+}
+
+// TableName 指定表名
+func (LoginAttempt) TableName() string {
+	return "app_user_loginattempt"
+}
+
+// IsLocked 账号当前是否处于锁定状态
+func (a *LoginAttempt) IsLocked() bool {
+	return a.LockedUntil != nil && time.Now().Before(*a.LockedUntil)
+}
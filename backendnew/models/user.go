@@ -4,7 +4,7 @@ import (
 	"time"
 
 	"github.com/jinzhu/gorm"
-	"golang.org/x/crypto/bcrypt"
+	"seldom-platform/utils"
 )
 
 // User 用户表（对应Django的User模型）
@@ -16,10 +16,11 @@ type User struct {
 	LastName    string    `gorm:"size:150" json:"last_name"`                                       // 姓
 	Password    string    `gorm:"size:128;not null" json:"-"`                                      // 密码（不返回给前端）
 	IsStaff     bool      `gorm:"default:false" json:"is_staff"`                                   // 是否为员工
-	IsActive    bool      `gorm:"default:true" json:"is_active"`                                   // 是否激活
+	IsActive    bool      `json:"is_active"`                                                      // 是否激活，不设置gorm默认值，创建时按字段值写入
 	IsSuperuser bool      `gorm:"default:false" json:"is_superuser"`                               // 是否为超级用户
 	DateJoined  time.Time `gorm:"autoCreateTime" json:"date_joined"`                               // 加入时间
 	LastLogin   *time.Time `json:"last_login"`                                                     // 最后登录时间
+	VerifyVersion int      `gorm:"not null;default:0" json:"-"`                                     // 邮箱验证令牌版本，验证邮箱和启用、禁用账号时递增
 }
 
 // TableName 指定表名
@@ -27,20 +28,39 @@ func (User) TableName() string {
 	return "auth_user"
 }
 
-// SetPassword 设置密码（加密）
+// SetPassword 设置密码（使用默认算法加密，与Django格式兼容）
 func (u *User) SetPassword(password string) error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := utils.MakePassword(password)
 	if err != nil {
 		return err
 	}
-	u.Password = string(hashedPassword)
+	u.Password = hashedPassword
 	return nil
 }
 
-// CheckPassword 验证密码
+// SetUnusablePassword 设置不可用密码，用于只能通过单点登录或目录服务登录的用户
+func (u *User) SetUnusablePassword() error {
+	password, err := utils.MakeUnusablePassword()
+	if err != nil {
+		return err
+	}
+	u.Password = password
+	return nil
+}
+
+// HasUsablePassword 是否设置了可用于本地登录的密码
+func (u *User) HasUsablePassword() bool {
+	return utils.IsPasswordUsable(u.Password)
+}
+
+// CheckPassword 验证密码，支持Django的pbkdf2_sha256、argon2、bcrypt格式
 func (u *User) CheckPassword(password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
-	return err == nil
+	return utils.CheckPassword(password, u.Password)
+}
+
+// PasswordNeedsUpgrade 密码不是默认算法或参数已过时，应在验证成功后重新加密
+func (u *User) PasswordNeedsUpgrade() bool {
+	return utils.PasswordMustUpdate(u.Password)
 }
 
 // BeforeCreate GORM钩子，创建前执行
@@ -49,6 +69,16 @@ func (u *User) BeforeCreate(scope *gorm.Scope) error {
 	return nil
 }
 
+// AfterSave GORM钩子，用户被禁用时吊销其所有refresh token
+func (u *User) AfterSave(scope *gorm.Scope) error {
+	if u.IsActive || u.ID == 0 {
+		return nil
+	}
+	return scope.NewDB().Model(&RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", u.ID).
+		Update("revoked_at", time.Now()).Error
+}
+
 // GetFullName 获取全名
 func (u *User) GetFullName() string {
 	if u.FirstName != "" && u.LastName != "" {
@@ -0,0 +1,99 @@
+package routes
+
+import (
+	"seldom-platform/config"
+	"seldom-platform/handlers"
+	"seldom-platform/middleware"
+	"seldom-platform/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupDjangoRoutes 设置Django兼容路由，路径、请求和响应结构与原后端（Django Ninja）一致，供frontendv3使用
+// 认证和权限规则与REST接口相同，错误统一转换为原后端的响应结构；authRateLimit与REST登录接口共用
+func setupDjangoRoutes(api *gin.RouterGroup, cfg *config.Config, authRateLimit gin.HandlerFunc) {
+	djangoHandler := handlers.NewDjangoHandler(cfg)
+	compat := api.Group("")
+	compat.Use(middleware.DjangoEnvelope())
+
+	// 用户路由（不需要认证）
+	user := compat.Group("/user")
+	{
+		user.POST("/login", authRateLimit, djangoHandler.Login)
+		user.POST("/register", authRateLimit, djangoHandler.Register)
+		user.POST("/logout", djangoHandler.Logout)
+	}
+
+	authenticated := compat.Group("")
+	authenticated.Use(middleware.AuthMiddleware(cfg))
+
+	viewer := models.RoleViewer
+	runner := models.RoleRunner
+	maintainer := models.RoleMaintainer
+	owner := models.RoleOwner
+
+	// 项目和环境路由
+	project := authenticated.Group("/project")
+	{
+		projectScope := middleware.ProjectFromParam("id")
+		project.POST("/create", middleware.RequireStaff(), djangoHandler.CreateProject)
+		project.GET("/list", djangoHandler.GetProjects)
+		project.GET("/:id/", middleware.RequireRole(viewer, projectScope), djangoHandler.GetProject)
+		project.PUT("/:id/", middleware.RequireRole(maintainer, projectScope), djangoHandler.UpdateProject)
+		project.DELETE("/:id/", middleware.RequireRole(owner, projectScope), djangoHandler.DeleteProject)
+		project.GET("/:id/files", middleware.RequireRole(viewer, projectScope), djangoHandler.GetProjectFiles)
+		project.GET("/:id/cases", middleware.RequireRole(viewer, projectScope), djangoHandler.GetProjectCases)
+		project.GET("/:id/subdirectory", middleware.RequireRole(viewer, projectScope), djangoHandler.GetProjectSubdirectory)
+
+		// 基于本地git仓库的代码同步接口暂不支持
+		for _, path := range []string{"/:id/sync_code", "/:id/sync_case", "/:id/sync_result", "/:id/clone", "/:id/sync", "/sync_log"} {
+			project.GET(path, djangoHandler.NotSupported)
+		}
+		project.POST("/:id/sync_merge", djangoHandler.NotSupported)
+
+		envScope := middleware.EnvFromParam("id")
+		project.POST("/env", middleware.RequireRole(maintainer, middleware.EnvProjectFromBody("project")), djangoHandler.CreateEnv)
+		project.GET("/env/list", djangoHandler.GetEnvs)
+		project.GET("/env/:id/", middleware.RequireRole(viewer, envScope), djangoHandler.GetEnv)
+		project.PUT("/env/:id/", middleware.RequireRole(maintainer, envScope), djangoHandler.UpdateEnv)
+		project.DELETE("/env/:id/", middleware.RequireRole(maintainer, envScope), djangoHandler.DeleteEnv)
+	}
+
+	// 任务路由
+	task := authenticated.Group("/task")
+	{
+		taskScope := middleware.TaskFromParam("id")
+		queryTask := middleware.TaskFromQuery("task_id")
+		bodyTeam := middleware.TeamFromBody("team_id")
+		task.POST("/create", middleware.RequireRole(maintainer, middleware.ProjectFromBody("project")), middleware.RequireRole(maintainer, bodyTeam), djangoHandler.CreateTask)
+		task.GET("/list", middleware.RequireRole(viewer, middleware.ScopeFromQuery("project_id", "team_id")), djangoHandler.GetTasks)
+		task.GET("/reports", middleware.RequireRole(viewer, queryTask), djangoHandler.GetTaskReports)
+		task.POST("/report/:id/results", middleware.RequireRole(viewer, middleware.ReportFromParam("id")), djangoHandler.GetReportResults)
+		task.POST("/timed/create", middleware.RequireRole(maintainer, middleware.TaskFromBody("task_id")), djangoHandler.CreateTimed)
+		task.PUT("/timed/switch", middleware.RequireRole(maintainer, queryTask), djangoHandler.SwitchTimed)
+		task.DELETE("/timed/delete", middleware.RequireRole(maintainer, queryTask), djangoHandler.DeleteTimed)
+		task.GET("/:id/", middleware.RequireRole(viewer, taskScope), djangoHandler.GetTask)
+		task.PUT("/:id/", middleware.RequireRole(maintainer, taskScope), middleware.RequireRole(maintainer, bodyTeam), djangoHandler.UpdateTask)
+		task.DELETE("/:id/", middleware.RequireRole(maintainer, taskScope), djangoHandler.DeleteTask)
+		task.GET("/:id/running", middleware.RequireRole(runner, taskScope), djangoHandler.RunTask)
+	}
+
+	// 团队路由
+	team := authenticated.Group("/team")
+	{
+		teamScope := middleware.TeamFromParam("id")
+		team.POST("/create", middleware.RequireStaff(), djangoHandler.CreateTeam)
+		team.GET("/list", djangoHandler.GetTeams)
+		team.GET("/:id/", middleware.RequireRole(viewer, teamScope), djangoHandler.GetTeam)
+		team.PUT("/:id/", middleware.RequireRole(maintainer, teamScope), djangoHandler.UpdateTeam)
+		team.DELETE("/:id/", middleware.RequireRole(owner, teamScope), djangoHandler.DeleteTeam)
+	}
+
+	// 用例路由
+	caseGroup := authenticated.Group("/case")
+	{
+		caseScope := middleware.CaseFromParam("id")
+		caseGroup.POST("/:id/running", middleware.RequireRole(runner, caseScope), djangoHandler.RunCase)
+		caseGroup.GET("/:id/result", middleware.RequireRole(viewer, caseScope), djangoHandler.GetCaseResult)
+	}
+}
@@ -1,30 +1,49 @@
 package routes
 
 import (
+	"io/fs"
+	"log"
+	"os"
+
 	"seldom-platform/config"
 	"seldom-platform/handlers"
+	"seldom-platform/metrics"
 	"seldom-platform/middleware"
+	"seldom-platform/models"
+	"seldom-platform/web"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
 // Setup 创建并配置Gin引擎
 func Setup(cfg *config.Config) *gin.Engine {
-	r := gin.Default()
+	r := gin.New()
+	r.Use(middleware.RecoveryMiddleware())
 	SetupRoutes(r, cfg)
 	return r
 }
 
 // SetupRoutes 设置路由
 func SetupRoutes(r *gin.Engine, cfg *config.Config) {
-	// 添加中间件
-	r.Use(middleware.CORSMiddleware())
+	// 只信任来自可信代理的X-Forwarded-For，限流和IP白名单依赖真实的客户端IP
+	if err := r.SetTrustedProxies(cfg.Middleware.TrustedProxies); err != nil {
+		log.Printf("Warning: invalid trusted proxies: %v", err)
+	}
+
+	// 添加中间件，顺序和开关由middleware配置决定
+	r.Use(middleware.Pipeline(cfg)...)
 
 	// Swagger文档路由
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Prometheus指标
+	if cfg.Metrics.Enabled {
+		r.GET("/metrics", middleware.MetricsAuth(cfg.Metrics.Token), gin.WrapH(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})))
+	}
+
 	// 健康检查
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -32,18 +51,36 @@ func SetupRoutes(r *gin.Engine, cfg *config.Config) {
 			"message": "Seldom Platform API is running",
 		})
 	})
+	healthHandler := handlers.NewHealthHandler(cfg)
+	r.GET("/livez", healthHandler.Livez)
+	r.GET("/readyz", healthHandler.Readyz)
 
 	// API路由组
 	api := r.Group("/api")
 
+	// 登录、注册接口的限流，REST和Django兼容接口共用同一计数
+	authRateLimit := middleware.AuthRateLimitMiddleware(cfg.Security.AuthRateLimit)
+
 	// 认证相关路由（不需要认证）
 	authHandler := handlers.NewAuthHandler(cfg)
 	auth := api.Group("/auth")
 	{
-		auth.POST("/login", authHandler.Login)
-		auth.POST("/register", authHandler.Register)
+		auth.POST("/login", authRateLimit, authHandler.Login)
+		auth.POST("/register", authRateLimit, authHandler.Register)
+		auth.POST("/refresh", authHandler.Refresh)
+		auth.POST("/password/forgot", authRateLimit, authHandler.ForgotPassword)
+		auth.POST("/password/reset", authRateLimit, authHandler.ResetPassword)
+		auth.POST("/email/verify", authRateLimit, authHandler.VerifyEmail)
+
+		// OIDC单点登录
+		oidcHandler := handlers.NewOIDCHandler(cfg)
+		auth.GET("/oidc/login", authRateLimit, oidcHandler.Login)
+		auth.GET("/oidc/callback", authRateLimit, oidcHandler.Callback)
 	}
 
+	// Django兼容路由，供frontendv3使用
+	setupDjangoRoutes(api, cfg, authRateLimit)
+
 	// 需要认证的路由
 	authenticated := api.Group("")
 	authenticated.Use(middleware.AuthMiddleware(cfg))
@@ -51,63 +88,159 @@ func SetupRoutes(r *gin.Engine, cfg *config.Config) {
 		// 用户信息路由
 		authenticated.GET("/auth/profile", authHandler.GetProfile)
 		authenticated.PUT("/auth/profile", authHandler.UpdateProfile)
+		authenticated.POST("/auth/logout", authHandler.Logout)
+		authenticated.POST("/auth/password/change", authHandler.ChangePassword)
+
+		// 角色权限：viewer查看、runner执行任务、maintainer创建和修改、owner删除和管理成员
+		viewer := models.RoleViewer
+		runner := models.RoleRunner
+		maintainer := models.RoleMaintainer
+		owner := models.RoleOwner
+		memberHandler := handlers.NewMemberHandler()
 
 		// 项目管理路由
 		projectHandler := handlers.NewProjectHandler()
 		projects := authenticated.Group("/projects")
 		{
+			projectScope := middleware.ProjectFromParam("id")
 			projects.GET("", projectHandler.GetProjects)
-			projects.POST("", projectHandler.CreateProject)
-			projects.GET("/:id", projectHandler.GetProject)
-			projects.PUT("/:id", projectHandler.UpdateProject)
-			projects.DELETE("/:id", projectHandler.DeleteProject)
+			projects.POST("", middleware.RequireStaff(), projectHandler.CreateProject)
+			projects.GET("/:id", middleware.RequireRole(viewer, projectScope), projectHandler.GetProject)
+			projects.PUT("/:id", middleware.RequireRole(maintainer, projectScope), projectHandler.UpdateProject)
+			projects.DELETE("/:id", middleware.RequireRole(owner, projectScope), projectHandler.DeleteProject)
+			projects.POST("/:id/restore", middleware.RequireRole(owner, projectScope), projectHandler.RestoreProject)
+
+			projects.GET("/:id/members", middleware.RequireRole(viewer, projectScope), memberHandler.GetProjectMembers)
+			projects.POST("/:id/members", middleware.RequireRole(owner, projectScope), memberHandler.AddProjectMember)
+			projects.PUT("/:id/members/:user_id", middleware.RequireRole(owner, projectScope), memberHandler.UpdateProjectMember)
+			projects.DELETE("/:id/members/:user_id", middleware.RequireRole(owner, projectScope), memberHandler.RemoveProjectMember)
 		}
 
 		// 测试用例管理路由
 		caseHandler := handlers.NewCaseHandler()
 		cases := authenticated.Group("/cases")
 		{
-			cases.GET("", caseHandler.GetCases)
-			cases.POST("", caseHandler.CreateCase)
-			cases.GET("/:id", caseHandler.GetCase)
-			cases.PUT("/:id", caseHandler.UpdateCase)
-			cases.DELETE("/:id", caseHandler.DeleteCase)
-			cases.POST("/:id/copy", caseHandler.CopyCase)
+			caseScope := middleware.CaseFromParam("id")
+			bodyProject := middleware.ProjectFromBody("project")
+			cases.GET("", middleware.RequireRole(viewer, middleware.ProjectFromQuery("project")), caseHandler.GetCases)
+			cases.POST("", middleware.RequireRole(maintainer, bodyProject), caseHandler.CreateCase)
+			cases.GET("/:id", middleware.RequireRole(viewer, caseScope), caseHandler.GetCase)
+			cases.PUT("/:id", middleware.RequireRole(maintainer, caseScope), middleware.RequireRole(maintainer, bodyProject), caseHandler.UpdateCase)
+			cases.DELETE("/:id", middleware.RequireRole(maintainer, caseScope), caseHandler.DeleteCase)
+			cases.POST("/:id/copy", middleware.RequireRole(maintainer, caseScope), caseHandler.CopyCase)
 		}
 
-		// 环境管理路由
-		envHandler := handlers.NewEnvHandler()
+		// 环境管理路由（项目环境由项目维护者管理，全局环境只有员工可以修改）
+		envHandler := handlers.NewEnvHandler(cfg)
 		envs := authenticated.Group("/envs")
 		{
-			envs.GET("", envHandler.GetEnvs)
-			envs.POST("", envHandler.CreateEnv)
-			envs.GET("/:id", envHandler.GetEnv)
-			envs.PUT("/:id", envHandler.UpdateEnv)
-			envs.DELETE("/:id", envHandler.DeleteEnv)
+			envScope := middleware.EnvFromParam("id")
+			envs.GET("", middleware.RequireRole(viewer, middleware.ProjectFromQuery("project")), envHandler.GetEnvs)
+			envs.POST("", middleware.RequireRole(maintainer, middleware.EnvProjectFromBody("project")), envHandler.CreateEnv)
+			envs.GET("/:id", middleware.RequireRole(viewer, envScope), envHandler.GetEnv)
+			envs.PUT("/:id", middleware.RequireRole(maintainer, envScope), middleware.RequireRole(maintainer, middleware.ProjectFromBody("project")), envHandler.UpdateEnv)
+			envs.DELETE("/:id", middleware.RequireRole(maintainer, envScope), envHandler.DeleteEnv)
+			envs.POST("/:id/restore", middleware.RequireRole(maintainer, envScope), envHandler.RestoreEnv)
+			envs.GET("/:id/variables", middleware.RequireRole(viewer, envScope), envHandler.GetEnvVariables)
+			envs.PUT("/:id/variables", middleware.RequireRole(maintainer, envScope), envHandler.SetEnvVariables)
 		}
 
 		// 任务管理路由
-		taskHandler := handlers.NewTaskHandler()
+		taskHandler := handlers.NewTaskHandler(cfg)
 		tasks := authenticated.Group("/tasks")
 		{
-			tasks.GET("", taskHandler.GetTasks)
-			tasks.POST("", taskHandler.CreateTask)
-			tasks.GET("/:id", taskHandler.GetTask)
-			tasks.PUT("/:id", taskHandler.UpdateTask)
-			tasks.DELETE("/:id", taskHandler.DeleteTask)
-			tasks.POST("/:id/run", taskHandler.RunTask)
-			tasks.GET("/:id/reports", taskHandler.GetTaskReports)
+			taskScope := middleware.TaskFromParam("id")
+			bodyProject := middleware.ProjectFromBody("project")
+			bodyTeam := middleware.TeamFromBody("team")
+			tasks.GET("", middleware.RequireRole(viewer, middleware.ScopeFromQuery("project", "team")), taskHandler.GetTasks)
+			tasks.GET("/mine", taskHandler.GetMyTeamTasks)
+			tasks.POST("", middleware.RequireRole(maintainer, bodyProject), middleware.RequireRole(maintainer, bodyTeam), taskHandler.CreateTask)
+			tasks.GET("/:id", middleware.RequireRole(viewer, taskScope), taskHandler.GetTask)
+			tasks.PUT("/:id", middleware.RequireRole(maintainer, taskScope), middleware.RequireRole(maintainer, bodyProject), middleware.RequireRole(maintainer, bodyTeam), taskHandler.UpdateTask)
+			tasks.DELETE("/:id", middleware.RequireRole(maintainer, taskScope), taskHandler.DeleteTask)
+			tasks.POST("/:id/restore", middleware.RequireRole(maintainer, taskScope), taskHandler.RestoreTask)
+			tasks.POST("/:id/run", middleware.RequireRole(runner, taskScope), taskHandler.RunTask)
+			tasks.GET("/:id/reports", middleware.RequireRole(viewer, taskScope), taskHandler.GetTaskReports)
+			tasks.GET("/:id/runs", middleware.RequireRole(viewer, taskScope), taskHandler.GetTaskRuns)
+			tasks.GET("/:id/runs/:run_id", middleware.RequireRole(viewer, taskScope), taskHandler.GetTaskRun)
+		}
+
+		// 报告管理路由
+		reportHandler := handlers.NewReportHandler()
+		reports := authenticated.Group("/reports")
+		{
+			reports.GET("/compare",
+				middleware.RequireRole(viewer, middleware.ReportFromQuery("base")),
+				middleware.RequireRole(viewer, middleware.ReportFromQuery("head")),
+				reportHandler.CompareReports)
+		}
+
+		// 质量看板路由
+		dashboardHandler := handlers.NewDashboardHandler()
+		dashboard := authenticated.Group("/dashboard")
+		{
+			dashboard.GET("/projects/:id", middleware.RequireRole(viewer, middleware.ProjectFromParam("id")), dashboardHandler.GetProjectDashboard)
+			dashboard.GET("/teams/:id", middleware.RequireRole(viewer, middleware.TeamFromParam("id")), dashboardHandler.GetTeamDashboard)
 		}
 
 		// 团队管理路由
 		teamHandler := handlers.NewTeamHandler()
 		teams := authenticated.Group("/teams")
 		{
+			teamScope := middleware.TeamFromParam("id")
 			teams.GET("", teamHandler.GetTeams)
-			teams.POST("", teamHandler.CreateTeam)
-			teams.GET("/:id", teamHandler.GetTeam)
-			teams.PUT("/:id", teamHandler.UpdateTeam)
-			teams.DELETE("/:id", teamHandler.DeleteTeam)
+			teams.POST("", middleware.RequireStaff(), teamHandler.CreateTeam)
+			teams.GET("/:id", middleware.RequireRole(viewer, teamScope), teamHandler.GetTeam)
+			teams.PUT("/:id", middleware.RequireRole(maintainer, teamScope), teamHandler.UpdateTeam)
+			teams.DELETE("/:id", middleware.RequireRole(owner, teamScope), teamHandler.DeleteTeam)
+			teams.POST("/:id/restore", middleware.RequireRole(owner, teamScope), teamHandler.RestoreTeam)
+
+			teams.GET("/:id/members", middleware.RequireRole(viewer, teamScope), memberHandler.GetTeamMembers)
+			teams.POST("/:id/members", middleware.RequireRole(owner, teamScope), memberHandler.AddTeamMember)
+			teams.PUT("/:id/members/:user_id", middleware.RequireRole(owner, teamScope), memberHandler.UpdateTeamMember)
+			teams.DELETE("/:id/members/:user_id", middleware.RequireRole(owner, teamScope), memberHandler.RemoveTeamMember)
 		}
+
+		// 回收站路由，恢复接口在各资源路由中
+		trashHandler := handlers.NewTrashHandler()
+		authenticated.GET("/trash", trashHandler.GetTrash)
+
+		// 用户管理和审计日志路由（仅超级用户，受/api/admin的IP白名单限制）
+		adminHandler := handlers.NewAdminHandler(cfg)
+		auditHandler := handlers.NewAuditHandler()
+		admin := authenticated.Group("/admin")
+		admin.Use(middleware.RequireSuperuser())
+		{
+			admin.GET("/users", adminHandler.GetUsers)
+			admin.POST("/users", adminHandler.CreateUser)
+			admin.GET("/users/:id", adminHandler.GetUser)
+			admin.POST("/users/:id/activate", adminHandler.ActivateUser)
+			admin.POST("/users/:id/deactivate", adminHandler.DeactivateUser)
+			admin.PUT("/users/:id/staff", adminHandler.SetStaff)
+			admin.POST("/users/:id/password", adminHandler.ResetUserPassword)
+			admin.POST("/users/:id/logout", adminHandler.ForceLogout)
+			admin.GET("/audit", auditHandler.GetAuditLogs)
+		}
+	}
+
+	// 前端页面，未匹配的路径交给前端路由处理
+	if files := frontendFiles(cfg); files != nil {
+		r.NoRoute(handlers.NewFrontendHandler(files).Serve)
+	}
+}
+
+// frontendFiles 返回前端构建产物，优先使用WEB_DIR指定的目录，其次是内嵌的文件，都没有时返回nil
+func frontendFiles(cfg *config.Config) fs.FS {
+	files := web.Dist()
+	if cfg.Server.WebDir != "" {
+		files = os.DirFS(cfg.Server.WebDir)
 	}
-}
\ No newline at end of file
+	if files == nil {
+		return nil
+	}
+	if _, err := fs.Stat(files, "index.html"); err != nil {
+		log.Printf("Warning: frontend index.html not found, frontend disabled: %v", err)
+		return nil
+	}
+	return files
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"seldom-platform/database"
+	"seldom-platform/models"
+	"seldom-platform/utils"
+)
+
+// auditIgnoredFields 对比修改前后内容时忽略的字段
+var auditIgnoredFields = map[string]bool{
+	"update_time": true,
+}
+
+// auditRedactedFields 不写入审计日志明文的字段
+var auditRedactedFields = map[string]bool{
+	"password": true,
+}
+
+// auditRedacted 敏感字段在审计日志中的占位值
+const auditRedacted = "******"
+
+// AuditEntry 一条审计记录的操作人、操作和请求信息
+type AuditEntry struct {
+	ActorID      uint
+	ActorName    string
+	Action       string
+	ResourceType string
+	ResourceID   uint
+	IP           string
+	RequestID    string
+}
+
+// AuditService 审计日志服务
+type AuditService struct {
+	logger *utils.Logger
+}
+
+// NewAuditService 创建审计日志服务实例
+func NewAuditService() *AuditService {
+	return &AuditService{
+		logger: utils.GetLogger(),
+	}
+}
+
+// Record 写入审计日志，before和after为修改前后的资源（创建时before为nil，删除时after为nil），
+// 两者都不为nil时只保存发生变化的字段。写入失败只记录错误日志，不影响业务操作
+func (s *AuditService) Record(entry AuditEntry, before, after interface{}) {
+	beforeFields, afterFields := auditDiff(auditFields(before), auditFields(after))
+
+	log := models.AuditLog{
+		ActorID:      entry.ActorID,
+		ActorName:    entry.ActorName,
+		Action:       entry.Action,
+		ResourceType: entry.ResourceType,
+		ResourceID:   entry.ResourceID,
+		Before:       auditJSON(beforeFields),
+		After:        auditJSON(afterFields),
+		IP:           entry.IP,
+		RequestID:    entry.RequestID,
+	}
+	if err := database.GetDB().Create(&log).Error; err != nil {
+		s.logger.Error("写入审计日志失败",
+			"category", "audit",
+			"action", entry.Action,
+			"resource_type", entry.ResourceType,
+			"resource_id", entry.ResourceID,
+			"error", err.Error(),
+		)
+	}
+}
+
+// auditFields 将资源转换为JSON字段，并隐藏敏感字段
+func auditFields(value interface{}) map[string]interface{} {
+	if value == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+
+	for key := range fields {
+		if auditRedactedFields[key] {
+			fields[key] = auditRedacted
+		}
+	}
+	return fields
+}
+
+// auditDiff 修改前后都存在时只保留发生变化的字段
+func auditDiff(before, after map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	if before == nil || after == nil {
+		return before, after
+	}
+
+	changedBefore := map[string]interface{}{}
+	changedAfter := map[string]interface{}{}
+	for key, value := range after {
+		if auditIgnoredFields[key] || reflect.DeepEqual(before[key], value) {
+			continue
+		}
+		changedBefore[key] = before[key]
+		changedAfter[key] = value
+	}
+	for key, value := range before {
+		if _, ok := after[key]; !ok && !auditIgnoredFields[key] {
+			changedBefore[key] = value
+		}
+	}
+	return changedBefore, changedAfter
+}
+
+func auditJSON(fields map[string]interface{}) models.JSONText {
+	if fields == nil {
+		return ""
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return ""
+	}
+	return models.JSONText(data)
+}
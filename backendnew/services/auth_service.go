@@ -0,0 +1,399 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"seldom-platform/config"
+	"seldom-platform/database"
+	"seldom-platform/models"
+	"seldom-platform/utils"
+)
+
+// 认证相关错误
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+	ErrUserDisabled        = errors.New("user account is disabled")
+	ErrInvalidLinkToken    = errors.New("invalid or expired token")
+)
+
+// 签名令牌用途
+const (
+	tokenPurposePasswordReset = "password_reset"
+	tokenPurposeVerifyEmail   = "verify_email"
+)
+
+// TokenPair 登录或刷新后签发的令牌
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // access token有效期（秒）
+}
+
+// ClientInfo 签发令牌时记录的客户端信息
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// AuthService 认证服务，负责令牌的签发、轮换和吊销，以及密码策略和登录失败锁定
+type AuthService struct {
+	logger      *utils.Logger
+	config      *config.Config
+	mailService *MailService
+}
+
+// NewAuthService 创建认证服务实例
+func NewAuthService(cfg *config.Config) *AuthService {
+	return &AuthService{
+		logger:      utils.GetLogger(),
+		config:      cfg,
+		mailService: NewMailService(cfg),
+	}
+}
+
+// IssueTokens 为用户创建新会话并签发令牌
+func (s *AuthService) IssueTokens(user *models.User, client ClientInfo) (*TokenPair, error) {
+	sessionID, err := utils.GenerateTokenID()
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(database.GetDB(), user, sessionID, client)
+}
+
+// RefreshTokens 使用refresh token换取新令牌，旧的refresh token随即失效
+func (s *AuthService) RefreshTokens(refreshToken string, client ClientInfo) (*TokenPair, *models.User, error) {
+	db := database.GetDB()
+
+	var stored models.RefreshToken
+	if err := db.Where("token_hash = ?", utils.GenerateSHA256(refreshToken)).First(&stored).Error; err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	// 已轮换的令牌被再次使用，说明令牌可能泄露，吊销整个会话
+	if stored.RevokedAt != nil {
+		s.RevokeSession(stored.SessionID)
+		return nil, nil, ErrRefreshTokenReused
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	var user models.User
+	if err := db.First(&user, stored.UserID).Error; err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if !user.IsActive {
+		s.RevokeSession(stored.SessionID)
+		return nil, nil, ErrUserDisabled
+	}
+
+	var pair *TokenPair
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 条件更新保证并发刷新时只有一个请求成功
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", stored.ID).
+			Update("revoked_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+
+		var err error
+		pair, err = s.issue(tx, &user, stored.SessionID, client)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pair, &user, nil
+}
+
+// Logout 吊销当前access token及其所属会话
+func (s *AuthService) Logout(claims *utils.JWTClaims) error {
+	db := database.GetDB()
+
+	if claims.ID != "" && claims.ExpiresAt != nil {
+		revoked := models.RevokedToken{
+			JTI:       claims.ID,
+			UserID:    claims.UserID,
+			ExpiresAt: claims.ExpiresAt.Time,
+		}
+		if err := db.Where(models.RevokedToken{JTI: claims.ID}).FirstOrCreate(&revoked).Error; err != nil {
+			return err
+		}
+	}
+
+	if claims.SessionID != "" {
+		if err := s.RevokeSession(claims.SessionID); err != nil {
+			return err
+		}
+	}
+
+	// 顺带清理已过期的黑名单记录
+	db.Where("expires_at < ?", time.Now()).Delete(&models.RevokedToken{})
+	return nil
+}
+
+// RevokeSession 吊销会话下所有refresh token
+func (s *AuthService) RevokeSession(sessionID string) error {
+	return database.GetDB().Model(&models.RefreshToken{}).
+		Where("session_id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// IsTokenRevoked 检查access token是否已被吊销：token在黑名单中，或所属会话已被吊销
+func (s *AuthService) IsTokenRevoked(claims *utils.JWTClaims) bool {
+	db := database.GetDB()
+
+	if claims.ID != "" {
+		var count int
+		db.Model(&models.RevokedToken{}).Where("jti = ?", claims.ID).Count(&count)
+		if count > 0 {
+			return true
+		}
+	}
+
+	if claims.SessionID != "" {
+		var count int
+		db.Model(&models.RefreshToken{}).
+			Where("session_id = ? AND revoked_at IS NULL AND expires_at > ?", claims.SessionID, time.Now()).
+			Count(&count)
+		return count == 0
+	}
+	return false
+}
+
+// ValidatePassword 按配置的密码策略校验密码
+func (s *AuthService) ValidatePassword(password, username string) error {
+	security := s.config.Current().Security
+	policy := utils.PasswordPolicy{
+		MinLength:     security.PasswordMinLength,
+		RequireUpper:  security.PasswordRequireUpper,
+		RequireLower:  security.PasswordRequireLower,
+		RequireDigit:  security.PasswordRequireDigit,
+		RequireSymbol: security.PasswordRequireSymbol,
+	}
+	return policy.Validate(password, username)
+}
+
+// LockedUntil 返回账号锁定截止时间，未锁定时返回nil；锁定已到期的账号会被解锁
+func (s *AuthService) LockedUntil(user *models.User, ip string) *time.Time {
+	var attempt models.LoginAttempt
+	if err := database.GetDB().Where("user_id = ?", user.ID).First(&attempt).Error; err != nil {
+		return nil
+	}
+
+	if attempt.IsLocked() {
+		return attempt.LockedUntil
+	}
+	if attempt.LockedUntil != nil {
+		s.ResetLoginFailures(user)
+		s.logger.Auth("unlock", user.Username, ip, true)
+	}
+	return nil
+}
+
+// RecordLoginFailure 记录一次登录失败，连续失败达到上限时锁定账号并返回锁定截止时间
+func (s *AuthService) RecordLoginFailure(user *models.User, ip string) *time.Time {
+	security := s.config.Current().Security
+	maxAttempts := security.LoginMaxAttempts
+	if maxAttempts <= 0 {
+		return nil
+	}
+
+	db := database.GetDB()
+	lockout := time.Duration(security.LoginLockoutMinutes) * time.Minute
+	now := time.Now()
+
+	var attempt models.LoginAttempt
+	db.Where(models.LoginAttempt{UserID: user.ID}).FirstOrInit(&attempt)
+
+	// 距上次失败超过锁定时长的视为重新计数
+	if attempt.LastFailedAt == nil || now.Sub(*attempt.LastFailedAt) > lockout {
+		attempt.FailedCount = 0
+	}
+	attempt.FailedCount++
+	attempt.LastFailedAt = &now
+
+	var lockedUntil *time.Time
+	if attempt.FailedCount >= maxAttempts {
+		until := now.Add(lockout)
+		attempt.LockedUntil = &until
+		lockedUntil = &until
+	}
+
+	if err := db.Save(&attempt).Error; err != nil {
+		s.logger.Error("记录登录失败次数失败",
+			"category", "auth",
+			"user_id", user.ID,
+			"error", err.Error(),
+		)
+		return nil
+	}
+
+	if lockedUntil != nil {
+		s.logger.Auth("lockout", user.Username, ip, false)
+	}
+	return lockedUntil
+}
+
+// ResetLoginFailures 清除账号的登录失败记录
+func (s *AuthService) ResetLoginFailures(user *models.User) {
+	database.GetDB().Where("user_id = ?", user.ID).Delete(&models.LoginAttempt{})
+}
+
+// ChangePassword 修改用户密码，并吊销除keepSessionID外的所有会话和登录失败记录
+func (s *AuthService) ChangePassword(user *models.User, password, keepSessionID string) error {
+	if err := user.SetPassword(password); err != nil {
+		return err
+	}
+	if err := database.GetDB().Save(user).Error; err != nil {
+		return err
+	}
+
+	s.ResetLoginFailures(user)
+	return s.RevokeUserSessions(user.ID, keepSessionID)
+}
+
+// RevokeUserSessions 吊销用户除exceptSessionID外的所有refresh token
+func (s *AuthService) RevokeUserSessions(userID uint, exceptSessionID string) error {
+	query := database.GetDB().Model(&models.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+	if exceptSessionID != "" {
+		query = query.Where("session_id <> ?", exceptSessionID)
+	}
+	return query.Update("revoked_at", time.Now()).Error
+}
+
+// SendPasswordResetMail 发送密码重置邮件，令牌在密码修改或用户再次登录后失效
+func (s *AuthService) SendPasswordResetMail(user *models.User) {
+	minutes := s.config.Current().Security.ResetTokenExpire
+	expire := time.Duration(minutes) * time.Minute
+	token := utils.MakeSignedToken(s.config.JWT.Secret, tokenPurposePasswordReset, user.ID, passwordResetState(user), expire)
+
+	body := fmt.Sprintf("%s，您好：\n\n我们收到了重置 seldom-platform 账号密码的请求。请在 %d 分钟内访问以下链接设置新密码：\n\n%s/reset-password?token=%s\n\n"+
+		"如果这不是您本人的操作，请忽略本邮件。\n", user.GetFullName(), minutes, s.config.Server.PublicURL, token)
+	s.mailService.SendAsync([]string{user.Email}, "seldom-platform 重置密码", body)
+}
+
+// CheckPasswordResetToken 校验密码重置令牌，返回对应用户
+func (s *AuthService) CheckPasswordResetToken(token string) (*models.User, error) {
+	return s.checkLinkToken(token, tokenPurposePasswordReset, passwordResetState)
+}
+
+// SendVerificationMail 发送邮箱验证邮件，令牌在账号激活后失效
+func (s *AuthService) SendVerificationMail(user *models.User) {
+	hours := s.config.Current().Security.VerifyTokenExpire
+	expire := time.Duration(hours) * time.Hour
+	token := utils.MakeSignedToken(s.config.JWT.Secret, tokenPurposeVerifyEmail, user.ID, verifyEmailState(user), expire)
+
+	body := fmt.Sprintf("%s，您好：\n\n感谢注册 seldom-platform。请在 %d 小时内访问以下链接验证邮箱并激活账号：\n\n%s/verify-email?token=%s\n",
+		user.GetFullName(), hours, s.config.Server.PublicURL, token)
+	s.mailService.SendAsync([]string{user.Email}, "seldom-platform 邮箱验证", body)
+}
+
+// CheckEmailVerificationToken 校验邮箱验证令牌，返回对应用户
+func (s *AuthService) CheckEmailVerificationToken(token string) (*models.User, error) {
+	return s.checkLinkToken(token, tokenPurposeVerifyEmail, verifyEmailState)
+}
+
+// VerifyEmail 激活验证邮箱的用户并递增验证令牌版本，令牌只能使用一次；
+// 用户状态在校验令牌后被修改时返回ErrInvalidLinkToken
+func (s *AuthService) VerifyEmail(user *models.User) error {
+	result := database.GetDB().Model(&models.User{}).
+		Where("id = ? AND verify_version = ?", user.ID, user.VerifyVersion).
+		Updates(map[string]interface{}{"is_active": true, "verify_version": gorm.Expr("verify_version + 1")})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidLinkToken
+	}
+	return nil
+}
+
+// MailEnabled 是否已配置邮件服务
+func (s *AuthService) MailEnabled() bool {
+	return s.mailService.IsConfigured()
+}
+
+// checkLinkToken 解析令牌并使用用户当前状态校验签名
+func (s *AuthService) checkLinkToken(token, purpose string, state func(*models.User) string) (*models.User, error) {
+	userID, err := utils.ParseSignedToken(token, purpose)
+	if err != nil {
+		return nil, ErrInvalidLinkToken
+	}
+
+	var user models.User
+	if err := database.GetDB().First(&user, userID).Error; err != nil {
+		return nil, ErrInvalidLinkToken
+	}
+	if !utils.CheckSignedToken(token, s.config.JWT.Secret, purpose, state(&user)) {
+		return nil, ErrInvalidLinkToken
+	}
+	return &user, nil
+}
+
+// passwordResetState 密码重置令牌绑定的用户状态，修改密码或登录后令牌失效
+func passwordResetState(user *models.User) string {
+	lastLogin := ""
+	if user.LastLogin != nil {
+		lastLogin = strconv.FormatInt(user.LastLogin.Unix(), 10)
+	}
+	return user.Password + "|" + lastLogin + "|" + user.Email
+}
+
+// verifyEmailState 邮箱验证令牌绑定的用户状态，验证邮箱、管理员启用或禁用账号以及修改邮箱后令牌失效，
+// 避免被管理员禁用的账号通过旧链接重新激活
+func verifyEmailState(user *models.User) string {
+	return user.Email + "|" + strconv.Itoa(user.VerifyVersion)
+}
+
+// issue 在指定会话下签发access token和refresh token
+func (s *AuthService) issue(db *gorm.DB, user *models.User, sessionID string, client ClientInfo) (*TokenPair, error) {
+	accessExpire := time.Duration(s.config.Current().JWT.AccessExpire) * time.Minute
+	accessToken, _, err := utils.GenerateJWT(user.ID, user.Username, sessionID, s.config.JWT.Secret, accessExpire)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.GenerateTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	stored := models.RefreshToken{
+		UserID:    user.ID,
+		SessionID: sessionID,
+		TokenHash: utils.GenerateSHA256(refreshToken),
+		ExpiresAt: time.Now().Add(time.Duration(s.config.Current().JWT.RefreshExpire) * time.Hour),
+		UserAgent: truncate(client.UserAgent, 255),
+		IP:        client.IP,
+	}
+	if err := db.Create(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(accessExpire.Seconds()),
+	}, nil
+}
+
+// truncate 截断字符串到指定长度
+func truncate(value string, length int) string {
+	if len(value) > length {
+		return value[:length]
+	}
+	return value
+}
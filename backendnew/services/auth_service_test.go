@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"seldom-platform/database"
+	"seldom-platform/models"
+	"seldom-platform/utils"
+)
+
+// createUnverifiedUser 创建等待验证邮箱的用户，返回其验证令牌
+func createUnverifiedUser(t *testing.T, service *AuthService) (*models.User, string) {
+	t.Helper()
+	user := models.User{Username: "alice", Email: "alice@example.com"}
+	if err := user.SetPassword("alice-password"); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.GetDB().Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if user.IsActive {
+		t.Fatal("user was created active")
+	}
+	token := utils.MakeSignedToken(service.config.JWT.Secret, tokenPurposeVerifyEmail, user.ID, verifyEmailState(&user), time.Hour)
+	return &user, token
+}
+
+func TestVerifyEmailTokenIsOneShot(t *testing.T) {
+	cfg := newTestConfig(t)
+	setupTestDB(t, cfg)
+	service := NewAuthService(cfg)
+	_, token := createUnverifiedUser(t, service)
+
+	user, err := service.CheckEmailVerificationToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.VerifyEmail(user); err != nil {
+		t.Fatal(err)
+	}
+	database.GetDB().First(user, user.ID)
+	if !user.IsActive {
+		t.Error("verified user is not active")
+	}
+
+	// 同时提交的第二次验证使用校验时读到的旧状态
+	if err := service.VerifyEmail(&models.User{ID: user.ID, VerifyVersion: user.VerifyVersion - 1}); !errors.Is(err, ErrInvalidLinkToken) {
+		t.Errorf("concurrent second verification: err = %v, want ErrInvalidLinkToken", err)
+	}
+	if _, err := service.CheckEmailVerificationToken(token); !errors.Is(err, ErrInvalidLinkToken) {
+		t.Errorf("reused link: err = %v, want ErrInvalidLinkToken", err)
+	}
+}
+
+func TestVerifyEmailTokenCannotReactivateDeactivatedUser(t *testing.T) {
+	cfg := newTestConfig(t)
+	setupTestDB(t, cfg)
+	service := NewAuthService(cfg)
+	users := NewUserService(cfg)
+
+	// 验证后在首次登录前被管理员禁用
+	user, token := createUnverifiedUser(t, service)
+	if _, err := service.CheckEmailVerificationToken(token); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.VerifyEmail(user); err != nil {
+		t.Fatal(err)
+	}
+	database.GetDB().First(user, user.ID)
+	if err := users.SetActive(user, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.CheckEmailVerificationToken(token); !errors.Is(err, ErrInvalidLinkToken) {
+		t.Errorf("link reused after deactivation: err = %v, want ErrInvalidLinkToken", err)
+	}
+
+	// 验证前被管理员禁用
+	other := models.User{Username: "bob", Email: "bob@example.com"}
+	if err := database.GetDB().Create(&other).Error; err != nil {
+		t.Fatal(err)
+	}
+	otherToken := utils.MakeSignedToken(cfg.JWT.Secret, tokenPurposeVerifyEmail, other.ID, verifyEmailState(&other), time.Hour)
+	if err := users.SetActive(&other, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.CheckEmailVerificationToken(otherToken); !errors.Is(err, ErrInvalidLinkToken) {
+		t.Errorf("link used after deactivating an unverified user: err = %v, want ErrInvalidLinkToken", err)
+	}
+
+	var active int
+	database.GetDB().Model(&models.User{}).Where("is_active = ?", true).Count(&active)
+	if active != 0 {
+		t.Errorf("%d users are active, want none", active)
+	}
+}
@@ -0,0 +1,320 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"seldom-platform/database"
+	"seldom-platform/utils"
+)
+
+// DashboardService 质量看板服务，所有聚合均在数据库中完成
+type DashboardService struct {
+	logger *utils.Logger
+}
+
+// NewDashboardService 创建质量看板服务实例
+func NewDashboardService() *DashboardService {
+	return &DashboardService{
+		logger: utils.GetLogger(),
+	}
+}
+
+// DashboardScope 看板统计范围，项目、团队、任务三选一
+type DashboardScope struct {
+	ProjectID uint
+	TeamID    uint
+	TaskID    uint
+}
+
+// DashboardOptions 看板统计参数
+type DashboardOptions struct {
+	Days int // 统计天数
+	Top  int // 失败用例TopN
+}
+
+// DailyTrend 每日执行趋势
+type DailyTrend struct {
+	Date     string  `json:"date"`
+	Runs     int64   `json:"runs"`
+	Tests    int64   `json:"tests"`
+	Passed   int64   `json:"passed"`
+	Failure  int64   `json:"failure"`
+	Error    int64   `json:"error"`
+	Skipped  int64   `json:"skipped"`
+	PassRate float64 `json:"pass_rate"`
+}
+
+// DurationStats 执行耗时统计（秒）
+type DurationStats struct {
+	Mean float64 `json:"mean"`
+	P95  float64 `json:"p95"`
+	Max  float64 `json:"max"`
+}
+
+// FailingCase 失败用例统计
+type FailingCase struct {
+	ClassName    string `json:"class_name"`
+	Name         string `json:"name"`
+	Failures     int64  `json:"failures"`
+	LastReportID uint   `json:"last_report_id"` // 最近一次失败所在报告
+}
+
+// LabelCount 标签用例数
+type LabelCount struct {
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+// DashboardSummary 看板汇总
+type DashboardSummary struct {
+	Runs     int64   `json:"runs"`
+	Tests    int64   `json:"tests"`
+	Passed   int64   `json:"passed"`
+	PassRate float64 `json:"pass_rate"`
+}
+
+// Dashboard 质量看板数据
+type Dashboard struct {
+	StartDate   string           `json:"start_date"`
+	EndDate     string           `json:"end_date"`
+	Summary     DashboardSummary `json:"summary"`
+	Trend       []DailyTrend     `json:"trend"`
+	Duration    DurationStats    `json:"duration"`
+	TopFailing  []FailingCase    `json:"top_failing"`
+	LabelCounts []LabelCount     `json:"label_counts"`
+}
+
+// GetDashboard 获取指定范围内的质量看板数据
+func (s *DashboardService) GetDashboard(scope DashboardScope, opts DashboardOptions) (*Dashboard, error) {
+	if opts.Days <= 0 {
+		opts.Days = 30
+	}
+	if opts.Top <= 0 {
+		opts.Top = 10
+	}
+
+	end := time.Now()
+	start := utils.GetBeginningOfDay(utils.AddDays(end, -(opts.Days - 1)))
+
+	dashboard := &Dashboard{
+		StartDate: utils.FormatTime(start, utils.DateFormat),
+		EndDate:   utils.FormatTime(end, utils.DateFormat),
+	}
+
+	var err error
+	if dashboard.Trend, err = s.dailyTrend(scope, start, end); err != nil {
+		return nil, err
+	}
+	if dashboard.Duration, err = s.durationStats(scope, start); err != nil {
+		return nil, err
+	}
+	if dashboard.TopFailing, err = s.topFailingCases(scope, start, opts.Top); err != nil {
+		return nil, err
+	}
+	if dashboard.LabelCounts, err = s.labelCounts(scope); err != nil {
+		return nil, err
+	}
+
+	for _, day := range dashboard.Trend {
+		dashboard.Summary.Runs += day.Runs
+		dashboard.Summary.Tests += day.Tests
+		dashboard.Summary.Passed += day.Passed
+	}
+	dashboard.Summary.PassRate = passRate(dashboard.Summary.Passed, dashboard.Summary.Tests)
+
+	return dashboard, nil
+}
+
+// dailyTrend 按天统计执行次数和通过率，缺失的日期补零
+func (s *DashboardService) dailyTrend(scope DashboardScope, start, end time.Time) ([]DailyTrend, error) {
+	db := database.GetDB()
+	bucket := utils.SQLDateBucket(dialectName(db), "r.create_time")
+	where, args := scopeCondition(scope, start)
+
+	var rows []DailyTrend
+	err := db.Raw(fmt.Sprintf(`SELECT %s AS date, COUNT(*) AS runs,
+		COALESCE(SUM(r.tests), 0) AS tests, COALESCE(SUM(r.passed), 0) AS passed,
+		COALESCE(SUM(r.failure), 0) AS failure, COALESCE(SUM(r.error), 0) AS error,
+		COALESCE(SUM(r.skipped), 0) AS skipped
+		FROM app_task_taskreport r JOIN app_task_testtask t ON t.id = r.task_id
+		WHERE %s GROUP BY %s ORDER BY date`, bucket, where, bucket), args...).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("统计执行趋势失败: %v", err)
+	}
+
+	byDate := make(map[string]DailyTrend, len(rows))
+	for _, row := range rows {
+		byDate[row.Date] = row
+	}
+
+	days := utils.DayBuckets(start, end)
+	trend := make([]DailyTrend, 0, len(days))
+	for _, day := range days {
+		row, ok := byDate[day]
+		if !ok {
+			row = DailyTrend{Date: day}
+		}
+		row.PassRate = passRate(row.Passed, row.Tests)
+		trend = append(trend, row)
+	}
+	return trend, nil
+}
+
+// durationStats 统计平均耗时、P95（最近秩法）和最大耗时
+// P95先取记录数再按偏移量查询，不依赖窗口函数，兼容MySQL 5.7和旧版本SQLite
+func (s *DashboardService) durationStats(scope DashboardScope, start time.Time) (DurationStats, error) {
+	db := database.GetDB()
+	duration := runTimeExpr(dialectName(db), "r.run_time")
+	where, args := scopeCondition(scope, start)
+
+	var stats DurationStats
+	var count int
+	row := db.Raw(fmt.Sprintf(`SELECT COUNT(%s), COALESCE(AVG(%s), 0), COALESCE(MAX(%s), 0)
+		FROM app_task_taskreport r JOIN app_task_testtask t ON t.id = r.task_id
+		WHERE %s`, duration, duration, duration, where), args...).Row()
+	if err := row.Scan(&count, &stats.Mean, &stats.Max); err != nil {
+		return stats, fmt.Errorf("统计执行耗时失败: %v", err)
+	}
+	if count == 0 {
+		return stats, nil
+	}
+
+	// 最近秩法：P95为升序排列后第 ceil(0.95*n) 个值
+	offset := (count*95+99)/100 - 1
+	row = db.Raw(fmt.Sprintf(`SELECT %s AS d
+		FROM app_task_taskreport r JOIN app_task_testtask t ON t.id = r.task_id
+		WHERE %s AND %s IS NOT NULL ORDER BY d LIMIT 1 OFFSET ?`, duration, where, duration), append(args, offset)...).Row()
+	if err := row.Scan(&stats.P95); err != nil {
+		return stats, fmt.Errorf("统计P95耗时失败: %v", err)
+	}
+
+	return stats, nil
+}
+
+// topFailingCases 统计失败次数最多的用例
+func (s *DashboardService) topFailingCases(scope DashboardScope, start time.Time, limit int) ([]FailingCase, error) {
+	db := database.GetDB()
+	where, args := scopeCondition(scope, start)
+	args = append(args, limit)
+
+	cases := make([]FailingCase, 0)
+	err := db.Raw(fmt.Sprintf(`SELECT d.class_name, d.name, COUNT(*) AS failures, MAX(r.id) AS last_report_id
+		FROM app_task_reportdetails d
+		JOIN app_task_taskreport r ON r.id = d.result_id
+		JOIN app_task_testtask t ON t.id = r.task_id
+		WHERE %s AND (d.error_out <> '' OR d.failure_message <> '' OR d.status IN ('failure', 'error'))
+		GROUP BY d.class_name, d.name ORDER BY failures DESC, d.class_name, d.name LIMIT ?`, where), args...).Scan(&cases).Error
+	if err != nil {
+		return nil, fmt.Errorf("统计失败用例失败: %v", err)
+	}
+	return cases, nil
+}
+
+// labelCounts 按标签统计用例数，团队和任务范围内只统计任务关联的用例
+func (s *DashboardService) labelCounts(scope DashboardScope) ([]LabelCount, error) {
+	db := database.GetDB()
+
+	var query string
+	var args []interface{}
+	switch {
+	case scope.ProjectID != 0:
+		query = `SELECT c.label AS label, COUNT(*) AS count FROM app_case_testcase c
+			WHERE c.project_id = ? GROUP BY c.label ORDER BY count DESC, c.label`
+		args = append(args, scope.ProjectID)
+	case scope.TeamID != 0:
+		query = `SELECT c.label AS label, COUNT(DISTINCT c.id) AS count FROM app_case_testcase c
+			JOIN app_task_taskcaserelevance rel ON rel.case_hash = c.case_hash
+			JOIN app_task_testtask t ON t.id = rel.task_id
+			WHERE t.team_id = ? AND t.is_delete = ? GROUP BY c.label ORDER BY count DESC, c.label`
+		args = append(args, scope.TeamID, false)
+	default:
+		query = `SELECT c.label AS label, COUNT(DISTINCT c.id) AS count FROM app_case_testcase c
+			JOIN app_task_taskcaserelevance rel ON rel.case_hash = c.case_hash
+			WHERE rel.task_id = ? GROUP BY c.label ORDER BY count DESC, c.label`
+		args = append(args, scope.TaskID)
+	}
+
+	counts := make([]LabelCount, 0)
+	if err := db.Raw(query, args...).Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("统计用例标签失败: %v", err)
+	}
+	return counts, nil
+}
+
+// RunStatistics 执行次数统计
+type RunStatistics struct {
+	TotalRuns   int64   `json:"total_runs"`
+	SuccessRuns int64   `json:"success_runs"`
+	FailedRuns  int64   `json:"failed_runs"`
+	AvgDuration float64 `json:"avg_duration"`
+}
+
+// GetRunStatistics 统计范围内的执行次数、成功/失败次数和平均耗时（单次查询）
+// 没有失败和错误用例的执行视为成功
+func (s *DashboardService) GetRunStatistics(scope DashboardScope, days int) (*RunStatistics, error) {
+	db := database.GetDB()
+	start := utils.AddDays(time.Now(), -days)
+	where, args := scopeCondition(scope, start)
+
+	var stats RunStatistics
+	row := db.Raw(fmt.Sprintf(`SELECT COUNT(*),
+		COALESCE(SUM(CASE WHEN r.failure + r.error = 0 THEN 1 ELSE 0 END), 0),
+		COALESCE(AVG(%s), 0)
+		FROM app_task_taskreport r JOIN app_task_testtask t ON t.id = r.task_id
+		WHERE %s`, runTimeExpr(dialectName(db), "r.run_time"), where), args...).Row()
+	if err := row.Scan(&stats.TotalRuns, &stats.SuccessRuns, &stats.AvgDuration); err != nil {
+		return nil, fmt.Errorf("统计执行次数失败: %v", err)
+	}
+	stats.FailedRuns = stats.TotalRuns - stats.SuccessRuns
+
+	return &stats, nil
+}
+
+// scopeCondition 构建报告统计的过滤条件（报告表别名r，任务表别名t）
+func scopeCondition(scope DashboardScope, start time.Time) (string, []interface{}) {
+	conditions := []string{"t.is_delete = ?", "r.create_time >= ?"}
+	args := []interface{}{false, start}
+
+	switch {
+	case scope.ProjectID != 0:
+		conditions = append(conditions, "t.project_id = ?")
+		args = append(args, scope.ProjectID)
+	case scope.TeamID != 0:
+		conditions = append(conditions, "t.team_id = ?")
+		args = append(args, scope.TeamID)
+	default:
+		conditions = append(conditions, "t.id = ?")
+		args = append(args, scope.TaskID)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// runTimeExpr 将报告中的耗时字符串（如 "1.23" 或 "1.23s"）转换为数值
+func runTimeExpr(dialect, column string) string {
+	value := fmt.Sprintf("NULLIF(REPLACE(%s, 's', ''), '')", column)
+	switch dialect {
+	case "mysql":
+		return fmt.Sprintf("CAST(%s AS DECIMAL(12,3))", value)
+	case "postgres":
+		return fmt.Sprintf("CAST(%s AS DOUBLE PRECISION)", value)
+	default:
+		return fmt.Sprintf("CAST(%s AS REAL)", value)
+	}
+}
+
+// dialectName 获取当前数据库方言名称
+func dialectName(db *gorm.DB) string {
+	return db.Dialect().GetName()
+}
+
+// passRate 计算通过率（百分比）
+func passRate(passed, tests int64) float64 {
+	if tests == 0 {
+		return 0
+	}
+	return float64(passed) / float64(tests) * 100
+}
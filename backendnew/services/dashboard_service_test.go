@@ -0,0 +1,241 @@
+package services
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"seldom-platform/database"
+	"seldom-platform/models"
+	"seldom-platform/utils"
+)
+
+// createDashboardTask 创建项目和任务
+func createDashboardTask(t *testing.T) (models.Project, models.TestTask) {
+	t.Helper()
+	db := database.GetDB()
+	project := models.Project{Name: "dashboard", Address: "https://example.com/dashboard.git"}
+	if err := db.Create(&project).Error; err != nil {
+		t.Fatal(err)
+	}
+	task := models.TestTask{ProjectID: project.ID, Name: "nightly"}
+	if err := db.Create(&task).Error; err != nil {
+		t.Fatal(err)
+	}
+	return project, task
+}
+
+// addReport 为任务添加一份报告，创建时间为at
+func addReport(t *testing.T, taskID uint, at time.Time, report models.TaskReport) models.TaskReport {
+	t.Helper()
+	db := database.GetDB()
+	report.TaskID = taskID
+	if err := db.Create(&report).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&report).UpdateColumn("create_time", at).Error; err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+// daysAgoNoon 返回days天前的中午，避免落在日期边界上
+func daysAgoNoon(days int) time.Time {
+	return utils.GetBeginningOfDay(utils.AddDays(time.Now(), -days)).Add(12 * time.Hour)
+}
+
+func TestDashboardWithoutReports(t *testing.T) {
+	setupTestDB(t, newTestConfig(t))
+	_, task := createDashboardTask(t)
+
+	dashboard, err := NewDashboardService().GetDashboard(DashboardScope{TaskID: task.ID}, DashboardOptions{Days: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dashboard.Trend) != 7 {
+		t.Fatalf("trend has %d days, want 7", len(dashboard.Trend))
+	}
+	for _, day := range dashboard.Trend {
+		if day != (DailyTrend{Date: day.Date}) {
+			t.Errorf("trend day = %+v, want zeros", day)
+		}
+	}
+	if dashboard.Summary != (DashboardSummary{}) || dashboard.Duration != (DurationStats{}) {
+		t.Errorf("summary = %+v, duration = %+v, want zeros", dashboard.Summary, dashboard.Duration)
+	}
+	// 空结果序列化为[]而不是null
+	if dashboard.TopFailing == nil || len(dashboard.TopFailing) != 0 || dashboard.LabelCounts == nil || len(dashboard.LabelCounts) != 0 {
+		t.Errorf("top failing = %#v, label counts = %#v, want empty slices", dashboard.TopFailing, dashboard.LabelCounts)
+	}
+}
+
+func TestDashboardDurationP95(t *testing.T) {
+	tests := []struct {
+		n    int
+		want float64 // 耗时为1..n秒时，升序第ceil(0.95n)个值
+	}{
+		{1, 1},
+		{2, 2},
+		{5, 5},
+		{19, 19},
+		{20, 19},
+		{21, 20},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.n), func(t *testing.T) {
+			setupTestDB(t, newTestConfig(t))
+			_, task := createDashboardTask(t)
+			// 倒序插入，并混用带s和不带s的耗时
+			for i := tt.n; i >= 1; i-- {
+				runTime := strconv.Itoa(i)
+				if i%2 == 0 {
+					runTime += "s"
+				}
+				addReport(t, task.ID, daysAgoNoon(1), models.TaskReport{Tests: 1, Passed: 1, RunTime: runTime})
+			}
+			// 空耗时不参与统计，run_time有默认值'0'，创建后再置空
+			empty := addReport(t, task.ID, daysAgoNoon(1), models.TaskReport{Tests: 1, Passed: 1})
+			if err := database.GetDB().Model(&empty).UpdateColumn("run_time", "").Error; err != nil {
+				t.Fatal(err)
+			}
+
+			dashboard, err := NewDashboardService().GetDashboard(DashboardScope{TaskID: task.ID}, DashboardOptions{Days: 7})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := DurationStats{Mean: float64(tt.n+1) / 2, P95: tt.want, Max: float64(tt.n)}
+			if dashboard.Duration != want {
+				t.Errorf("duration = %+v, want %+v", dashboard.Duration, want)
+			}
+		})
+	}
+}
+
+func TestDashboardTrendFillsMissingDays(t *testing.T) {
+	setupTestDB(t, newTestConfig(t))
+	_, task := createDashboardTask(t)
+	addReport(t, task.ID, daysAgoNoon(0), models.TaskReport{Tests: 4, Passed: 3, Failure: 1, RunTime: "1"})
+	addReport(t, task.ID, daysAgoNoon(2), models.TaskReport{Tests: 2, Passed: 2, RunTime: "1"})
+	addReport(t, task.ID, daysAgoNoon(2), models.TaskReport{Tests: 2, Passed: 0, Error: 1, Skipped: 1, RunTime: "1"})
+	// 统计范围之外
+	addReport(t, task.ID, daysAgoNoon(3), models.TaskReport{Tests: 10, Passed: 10, RunTime: "1"})
+
+	dashboard, err := NewDashboardService().GetDashboard(DashboardScope{TaskID: task.ID}, DashboardOptions{Days: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := func(days int) string { return utils.FormatTime(daysAgoNoon(days), utils.DateFormat) }
+	want := []DailyTrend{
+		{Date: day(2), Runs: 2, Tests: 4, Passed: 2, Error: 1, Skipped: 1, PassRate: 50},
+		{Date: day(1)},
+		{Date: day(0), Runs: 1, Tests: 4, Passed: 3, Failure: 1, PassRate: 75},
+	}
+	if !reflect.DeepEqual(dashboard.Trend, want) {
+		t.Errorf("trend = %+v, want %+v", dashboard.Trend, want)
+	}
+	if dashboard.StartDate != day(2) || dashboard.EndDate != day(0) {
+		t.Errorf("range = %s..%s, want %s..%s", dashboard.StartDate, dashboard.EndDate, day(2), day(0))
+	}
+	summary := DashboardSummary{Runs: 3, Tests: 8, Passed: 5, PassRate: 62.5}
+	if dashboard.Summary != summary {
+		t.Errorf("summary = %+v, want %+v", dashboard.Summary, summary)
+	}
+}
+
+func TestDashboardTopFailingCasesAndLabels(t *testing.T) {
+	setupTestDB(t, newTestConfig(t))
+	db := database.GetDB()
+	project, task := createDashboardTask(t)
+
+	first := addReport(t, task.ID, daysAgoNoon(1), models.TaskReport{Tests: 3, RunTime: "1"})
+	second := addReport(t, task.ID, daysAgoNoon(0), models.TaskReport{Tests: 3, RunTime: "1"})
+	details := []models.ReportDetails{
+		{ResultID: first.ID, ClassName: "LoginTest", Name: "test_login", Status: "failure"},
+		{ResultID: second.ID, ClassName: "LoginTest", Name: "test_login", FailureMessage: "assert failed"},
+		{ResultID: first.ID, ClassName: "LoginTest", Name: "test_logout", ErrorOut: "timeout"},
+		{ResultID: first.ID, ClassName: "CartTest", Name: "test_add", Status: "error"},
+		{ResultID: second.ID, ClassName: "CartTest", Name: "test_remove", Status: "passed"},
+	}
+	for i := range details {
+		if err := db.Create(&details[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	service := NewDashboardService()
+	dashboard, err := service.GetDashboard(DashboardScope{TaskID: task.ID}, DashboardOptions{Days: 7, Top: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantFailing := []FailingCase{
+		{ClassName: "LoginTest", Name: "test_login", Failures: 2, LastReportID: second.ID},
+		{ClassName: "CartTest", Name: "test_add", Failures: 1, LastReportID: first.ID},
+	}
+	if !reflect.DeepEqual(dashboard.TopFailing, wantFailing) {
+		t.Errorf("top failing = %+v, want %+v", dashboard.TopFailing, wantFailing)
+	}
+
+	// 项目范围统计全部用例，任务范围只统计关联的用例，同一hash的用例只计一次
+	cases := []models.TestCase{
+		{ProjectID: project.ID, ClassName: "LoginTest", CaseName: "test_login", Label: "smoke", CaseHash: "login"},
+		{ProjectID: project.ID, ClassName: "LoginTest", CaseName: "test_logout", Label: "smoke", CaseHash: "logout"},
+		{ProjectID: project.ID, ClassName: "CartTest", CaseName: "test_add", Label: "regression", CaseHash: "add"},
+		{ProjectID: project.ID, ClassName: "CartTest", CaseName: "test_remove", Label: "", CaseHash: "remove"},
+	}
+	for i := range cases {
+		if err := db.Create(&cases[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, hash := range []string{"login", "login", "add"} {
+		if err := db.Create(&models.TaskCaseRelevance{TaskID: task.ID, CaseHash: hash}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	labelTests := []struct {
+		scope DashboardScope
+		want  []LabelCount
+	}{
+		{DashboardScope{ProjectID: project.ID}, []LabelCount{{"smoke", 2}, {"", 1}, {"regression", 1}}},
+		{DashboardScope{TaskID: task.ID}, []LabelCount{{"regression", 1}, {"smoke", 1}}},
+	}
+	for _, tt := range labelTests {
+		dashboard, err := service.GetDashboard(tt.scope, DashboardOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(dashboard.LabelCounts, tt.want) {
+			t.Errorf("%+v: label counts = %+v, want %+v", tt.scope, dashboard.LabelCounts, tt.want)
+		}
+	}
+}
+
+func TestGetTaskStatistics(t *testing.T) {
+	setupTestDB(t, newTestConfig(t))
+	_, task := createDashboardTask(t)
+	now := time.Now()
+	addReport(t, task.ID, now.Add(-time.Hour), models.TaskReport{Tests: 2, Passed: 2, RunTime: "1.5s"})
+	addReport(t, task.ID, now.Add(-2*time.Hour), models.TaskReport{Tests: 2, Passed: 1, Skipped: 1, RunTime: "2.5"})
+	addReport(t, task.ID, now.Add(-3*time.Hour), models.TaskReport{Tests: 2, Passed: 1, Failure: 1, RunTime: "3"})
+	addReport(t, task.ID, now.Add(-4*time.Hour), models.TaskReport{Tests: 2, Passed: 1, Error: 1, RunTime: "5"})
+	// 统计范围之外
+	addReport(t, task.ID, utils.AddDays(now, -8), models.TaskReport{Tests: 2, Failure: 2, RunTime: "100"})
+
+	stats, err := NewSchedulerService(newTestConfig(t)).GetTaskStatistics(task.ID, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"total_runs":   int64(4),
+		"success_runs": int64(2),
+		"failed_runs":  int64(2),
+		"success_rate": float64(50),
+		"avg_duration": float64(3),
+		"period_days":  7,
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("GetTaskStatistics = %#v, want %#v", stats, want)
+	}
+}
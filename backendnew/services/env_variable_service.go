@@ -0,0 +1,200 @@
+package services
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"seldom-platform/config"
+	"seldom-platform/database"
+	"seldom-platform/models"
+	"seldom-platform/utils"
+
+	"github.com/jinzhu/gorm"
+)
+
+// SecretMask 密钥变量在接口响应、审计日志和执行日志中的占位值
+const SecretMask = "******"
+
+// envVariableNamePattern 变量名只能包含字母、数字和下划线，且不能以数字开头
+var envVariableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,99}$`)
+
+// EnvVariableInput 环境变量输入，密钥变量的值为空或为占位值时保留原值
+type EnvVariableInput struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	IsSecret    bool   `json:"is_secret"`
+	Description string `json:"description"`
+}
+
+// ErrSecretKeyRequired 没有配置独立的加密密钥时不能保存新的密钥变量
+var ErrSecretKeyRequired = &ValidationError{Message: "Secret variables require a dedicated encryption key, set SECRET_ENCRYPTION_KEY"}
+
+// EnvVariableService 环境变量服务
+type EnvVariableService struct {
+	logger       *utils.Logger
+	key          string
+	dedicatedKey bool // 是否配置了独立的加密密钥
+}
+
+// NewEnvVariableService 创建环境变量服务，密钥变量使用security.secret_key加密。
+// 未配置时只能读取之前使用JWT密钥加密的密钥变量，不能保存新的密钥变量，避免轮换JWT密钥后无法解密
+func NewEnvVariableService(cfg *config.Config) *EnvVariableService {
+	secret := cfg.Security.SecretKey
+	dedicated := secret != ""
+	if !dedicated {
+		secret = cfg.JWT.Secret
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return &EnvVariableService{
+		logger:       utils.GetLogger(),
+		key:          string(sum[:]),
+		dedicatedKey: dedicated,
+	}
+}
+
+// CheckKey 没有配置独立的加密密钥但已有密钥变量（使用JWT密钥加密）时记录警告
+func (s *EnvVariableService) CheckKey() {
+	if s.dedicatedKey {
+		return
+	}
+	var count int
+	if err := database.GetDB().Model(&models.EnvVariable{}).Where("is_secret = ?", true).Count(&count).Error; err != nil || count == 0 {
+		return
+	}
+	s.logger.Warn("密钥变量使用JWT密钥加密，轮换JWT密钥后将无法解密，请将SECRET_ENCRYPTION_KEY设置为当前的JWT密钥",
+		"category", "env_variable",
+		"secrets", count,
+	)
+}
+
+// ListVariables 获取环境的变量，按变量名排序
+func (s *EnvVariableService) ListVariables(envID uint) ([]models.EnvVariable, error) {
+	var variables []models.EnvVariable
+	if err := database.GetDB().Where("env_id = ?", envID).Order("name ASC").Find(&variables).Error; err != nil {
+		return nil, err
+	}
+	return variables, nil
+}
+
+// SetVariables 以输入替换环境的全部变量，返回替换前后的变量
+func (s *EnvVariableService) SetVariables(envID uint, inputs []EnvVariableInput) ([]models.EnvVariable, []models.EnvVariable, error) {
+	before, err := s.ListVariables(envID)
+	if err != nil {
+		return nil, nil, err
+	}
+	existing := make(map[string]models.EnvVariable, len(before))
+	for _, variable := range before {
+		existing[variable.Name] = variable
+	}
+
+	seen := make(map[string]bool, len(inputs))
+	variables := make([]models.EnvVariable, 0, len(inputs))
+	for _, input := range inputs {
+		name := strings.TrimSpace(input.Name)
+		if !envVariableNamePattern.MatchString(name) {
+			return nil, nil, &ValidationError{Message: fmt.Sprintf("Invalid variable name: %s", input.Name)}
+		}
+		if seen[name] {
+			return nil, nil, &ValidationError{Message: fmt.Sprintf("Duplicate variable name: %s", name)}
+		}
+		seen[name] = true
+
+		variable := models.EnvVariable{
+			EnvID:       envID,
+			Name:        name,
+			Value:       input.Value,
+			IsSecret:    input.IsSecret,
+			Description: input.Description,
+		}
+		if input.IsSecret {
+			old, ok := existing[name]
+			if (input.Value == "" || input.Value == SecretMask) && ok && old.IsSecret {
+				// 未修改的密钥沿用原密文
+				variable.Value = old.Value
+			} else {
+				if !s.dedicatedKey {
+					return nil, nil, ErrSecretKeyRequired
+				}
+				encrypted, err := utils.EncryptAES(input.Value, s.key)
+				if err != nil {
+					return nil, nil, fmt.Errorf("加密环境变量失败: %v", err)
+				}
+				variable.Value = encrypted
+			}
+		}
+		variables = append(variables, variable)
+	}
+
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("env_id = ?", envID).Delete(&models.EnvVariable{}).Error; err != nil {
+			return err
+		}
+		for i := range variables {
+			if err := tx.Create(&variables[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	after, err := s.ListVariables(envID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+// ResolveVariables 获取环境变量的明文，返回变量和需要在日志中隐藏的密钥值
+func (s *EnvVariableService) ResolveVariables(envID uint) (map[string]string, []string, error) {
+	variables, err := s.ListVariables(envID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values := make(map[string]string, len(variables))
+	secrets := make([]string, 0)
+	for _, variable := range variables {
+		value := variable.Value
+		if variable.IsSecret {
+			value, err = utils.DecryptAES(variable.Value, s.key)
+			if err != nil {
+				s.logger.Error("解密环境变量失败",
+					"category", "env_variable",
+					"env_id", envID,
+					"name", variable.Name,
+				)
+				return nil, nil, fmt.Errorf("解密环境变量 %s 失败，请检查加密密钥", variable.Name)
+			}
+			if value != "" {
+				secrets = append(secrets, value)
+			}
+		}
+		values[variable.Name] = value
+	}
+	return values, secrets, nil
+}
+
+// MaskVariables 返回隐藏了密钥值的变量副本，用于接口响应和审计日志
+func MaskVariables(variables []models.EnvVariable) []models.EnvVariable {
+	masked := make([]models.EnvVariable, len(variables))
+	for i, variable := range variables {
+		if variable.IsSecret {
+			variable.Value = SecretMask
+		}
+		masked[i] = variable
+	}
+	return masked
+}
+
+// MaskSecrets 将文本中出现的密钥值替换为占位值
+func MaskSecrets(text string, secrets []string) string {
+	for _, secret := range secrets {
+		text = strings.ReplaceAll(text, secret, SecretMask)
+	}
+	return text
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"seldom-platform/database"
+	"seldom-platform/models"
+)
+
+func TestSetVariablesEncryptsSecrets(t *testing.T) {
+	cfg := newTestConfig(t)
+	setupTestDB(t, cfg)
+	env := models.Env{Name: "dev"}
+	database.GetDB().Create(&env)
+
+	service := NewEnvVariableService(cfg)
+	_, after, err := service.SetVariables(env.ID, []EnvVariableInput{
+		{Name: "TOKEN", Value: "t0ken", IsSecret: true},
+		{Name: "USER", Value: "alice"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, variable := range after {
+		if variable.Name == "TOKEN" && variable.Value == "t0ken" {
+			t.Error("secret variable is stored in plain text")
+		}
+	}
+
+	// 占位值表示保留原密钥
+	if _, _, err := service.SetVariables(env.ID, []EnvVariableInput{{Name: "TOKEN", Value: SecretMask, IsSecret: true}}); err != nil {
+		t.Fatal(err)
+	}
+	values, secrets, err := service.ResolveVariables(env.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["TOKEN"] != "t0ken" || len(secrets) != 1 || secrets[0] != "t0ken" {
+		t.Errorf("resolved %v %v, want the original secret", values, secrets)
+	}
+	if _, ok := values["USER"]; ok {
+		t.Error("variables are replaced as a whole, USER should be removed")
+	}
+}
+
+func TestSetVariablesRequiresDedicatedKey(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Security.SecretKey = ""
+	setupTestDB(t, cfg)
+	env := models.Env{Name: "dev"}
+	database.GetDB().Create(&env)
+
+	service := NewEnvVariableService(cfg)
+	_, _, err := service.SetVariables(env.ID, []EnvVariableInput{{Name: "TOKEN", Value: "t0ken", IsSecret: true}})
+	if !errors.Is(err, ErrSecretKeyRequired) {
+		t.Fatalf("err = %v, want ErrSecretKeyRequired", err)
+	}
+	if _, _, err := service.SetVariables(env.ID, []EnvVariableInput{{Name: "USER", Value: "alice"}}); err != nil {
+		t.Fatalf("plain variables should not need the key: %v", err)
+	}
+}
+
+func TestMaskSecrets(t *testing.T) {
+	got := MaskSecrets("user=alice password=p@ss token=p@ss", []string{"p@ss"})
+	if got != "user=alice password=****** token=******" {
+		t.Errorf("MaskSecrets = %q", got)
+	}
+}
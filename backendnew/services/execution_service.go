@@ -0,0 +1,352 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"seldom-platform/config"
+	"seldom-platform/database"
+	"seldom-platform/metrics"
+	"seldom-platform/models"
+	"seldom-platform/utils"
+)
+
+// ErrShuttingDown 服务正在退出，不再接受新的执行
+var ErrShuttingDown = errors.New("服务正在关闭，请稍后重试")
+
+// executions 本实例中正在进行的执行，所有TaskService共用
+var executions = newExecutionTracker()
+
+// executionTracker 记录本实例中正在进行的执行，退出时用于等待执行完成
+type executionTracker struct {
+	owner    string // 本实例标识，写入执行记录
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	draining bool
+	slots    chan struct{} // 执行槽位，为nil时不限制同时执行的数量
+	queued   atomic.Int64  // 等待执行槽位的执行数
+	active   atomic.Int64  // 正在执行的执行数
+}
+
+// ExecutorStats 本实例执行器的状态
+type ExecutorStats struct {
+	Active   int  `json:"active"`   // 正在执行的任务和用例数
+	Queued   int  `json:"queued"`   // 等待执行槽位的任务和用例数
+	Limit    int  `json:"limit"`    // 同时执行的数量上限，0表示不限制
+	Draining bool `json:"draining"` // 服务正在退出，不再接受新的执行
+}
+
+// Saturated 执行槽位已用满且有执行在排队
+func (s ExecutorStats) Saturated() bool {
+	return s.Limit > 0 && s.Active >= s.Limit && s.Queued > 0
+}
+
+// GetExecutorStats 返回本实例执行器的状态
+func GetExecutorStats() ExecutorStats {
+	executions.mu.Lock()
+	defer executions.mu.Unlock()
+	return ExecutorStats{
+		Active:   int(executions.active.Load()),
+		Queued:   int(executions.queued.Load()),
+		Limit:    cap(executions.slots),
+		Draining: executions.draining,
+	}
+}
+
+// SetMaxConcurrent 修改本实例同时执行的任务和用例数上限，0表示不限制；正在执行和排队中的执行仍使用原来的上限
+func SetMaxConcurrent(limit int) {
+	executions.setLimit(limit)
+}
+
+func newExecutionTracker() *executionTracker {
+	hostname, _ := os.Hostname()
+	suffix, err := utils.GenerateTokenID()
+	if err != nil {
+		suffix = fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return &executionTracker{owner: fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), suffix[:8])}
+}
+
+// begin 开始一次执行并保存执行记录，服务正在退出时返回ErrShuttingDown
+func (t *executionTracker) begin(execution *models.Execution) error {
+	t.mu.Lock()
+	if t.draining {
+		t.mu.Unlock()
+		return ErrShuttingDown
+	}
+	t.wg.Add(1)
+	t.mu.Unlock()
+
+	execution.Owner = t.owner
+	execution.Status = models.ExecutionRunning
+	if err := database.GetDB().Create(execution).Error; err != nil {
+		utils.GetLogger().Error("Failed to save execution", "kind", execution.Kind, "target_id", execution.TargetID, "error", err)
+	}
+	return nil
+}
+
+// setLimit 设置本实例同时执行的数量上限，limit不大于0时不限制
+func (t *executionTracker) setLimit(limit int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if limit > 0 {
+		t.slots = make(chan struct{}, limit)
+	} else {
+		t.slots = nil
+	}
+}
+
+// acquire 等待执行槽位，返回用于释放槽位的函数
+func (t *executionTracker) acquire(kind string) func() {
+	t.mu.Lock()
+	slots := t.slots
+	t.mu.Unlock()
+
+	t.queued.Add(1)
+	metrics.RunQueueDepth.Inc()
+	if slots != nil {
+		slots <- struct{}{}
+	}
+	t.queued.Add(-1)
+	metrics.RunQueueDepth.Dec()
+	t.active.Add(1)
+	metrics.ActiveRuns.WithLabelValues(kind).Inc()
+
+	return func() {
+		t.active.Add(-1)
+		metrics.ActiveRuns.WithLabelValues(kind).Dec()
+		if slots != nil {
+			<-slots
+		}
+	}
+}
+
+// finish 结束一次执行
+func (t *executionTracker) finish(execution *models.Execution) {
+	defer t.wg.Done()
+	if execution.ID == 0 {
+		return
+	}
+	database.GetDB().Model(&models.Execution{}).Where("id = ?", execution.ID).Updates(map[string]interface{}{
+		"status":   models.ExecutionFinished,
+		"end_time": time.Now(),
+	})
+}
+
+// ExecutionService 执行记录服务：定期更新本实例执行的心跳，退出时等待执行完成，
+// 并把没有存活执行者的执行标记为已中断，按配置重新执行
+type ExecutionService struct {
+	logger      *utils.Logger
+	taskService *TaskService
+	heartbeat   time.Duration
+	requeue     bool
+}
+
+// NewExecutionService 创建执行记录服务实例
+func NewExecutionService(cfg *config.Config) *ExecutionService {
+	heartbeat := time.Duration(cfg.Run.HeartbeatInterval) * time.Second
+	if heartbeat <= 0 {
+		heartbeat = 30 * time.Second
+	}
+	executions.setLimit(cfg.Run.MaxConcurrent)
+	return &ExecutionService{
+		logger:      utils.GetLogger(),
+		taskService: NewTaskService(cfg),
+		heartbeat:   heartbeat,
+		requeue:     cfg.Run.RequeueInterrupted,
+	}
+}
+
+// Start 立即恢复一次中断的执行，之后按心跳间隔更新本实例执行的心跳并检查其他实例的执行，返回用于停止的函数
+func (s *ExecutionService) Start() func() {
+	ticker := time.NewTicker(s.heartbeat)
+	done := make(chan struct{})
+
+	check := func() {
+		count, err := s.Recover()
+		if err != nil {
+			s.logger.Error("恢复中断的执行失败", "category", "EXECUTION", "error", err.Error())
+			return
+		}
+		if count > 0 {
+			s.logger.Info("已将没有存活执行者的执行标记为已中断",
+				"category", "EXECUTION",
+				"count", count,
+				"requeue", s.requeue,
+			)
+		}
+	}
+
+	check()
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				s.beat()
+				check()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
+
+// beat 更新本实例正在进行的执行的心跳
+func (s *ExecutionService) beat() {
+	err := database.GetDB().Model(&models.Execution{}).
+		Where("owner = ? AND status = ?", executions.owner, models.ExecutionRunning).
+		Update("heartbeat_at", time.Now()).Error
+	if err != nil {
+		s.logger.Error("更新执行心跳失败", "category", "EXECUTION", "error", err.Error())
+	}
+}
+
+// Drain 不再接受新的执行并等待本实例正在进行的执行完成，超时后把未完成的执行标记为已中断，
+// 全部执行完成时返回true
+func (s *ExecutionService) Drain(timeout time.Duration) bool {
+	executions.mu.Lock()
+	executions.draining = true
+	executions.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		executions.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return true
+	case <-time.After(timeout):
+	}
+
+	var running []models.Execution
+	database.GetDB().Where("owner = ? AND status = ?", executions.owner, models.ExecutionRunning).Find(&running)
+	for _, execution := range running {
+		s.interrupt(execution)
+	}
+	s.logger.Info("退出时仍有未完成的执行，已标记为已中断", "category", "EXECUTION", "count", len(running))
+	return false
+}
+
+// Recover 把心跳超时（超过3个心跳间隔未更新）的其他实例的执行标记为已中断，
+// 没有执行记录但状态为执行中的任务和用例同样标记为已中断；开启重新执行时重新执行被中断的任务和用例。
+// 返回本次标记的执行数量
+func (s *ExecutionService) Recover() (int, error) {
+	db := database.GetDB()
+
+	var stale []models.Execution
+	err := db.Where("status = ? AND owner <> ? AND heartbeat_at < ?",
+		models.ExecutionRunning, executions.owner, time.Now().Add(-3*s.heartbeat)).Find(&stale).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var interrupted []models.Execution
+	for _, execution := range stale {
+		if s.interrupt(execution) {
+			interrupted = append(interrupted, execution)
+		}
+	}
+
+	// 没有执行记录的执行中任务和用例，如升级前遗留的数据
+	orphans := 0
+	targets := []struct {
+		kind  string
+		model interface{}
+	}{
+		{models.ExecutionKindTask, &models.TestTask{}},
+		{models.ExecutionKindCase, &models.TestCase{}},
+	}
+	for _, target := range targets {
+		result := db.Model(target.model).
+			Where("status = ?", models.RunStatusRunning).
+			Where("id NOT IN (?)", db.Table(models.Execution{}.TableName()).Select("target_id").
+				Where("kind = ? AND status = ?", target.kind, models.ExecutionRunning).QueryExpr()).
+			Update("status", models.RunStatusInterrupted)
+		if result.Error != nil {
+			return len(interrupted), result.Error
+		}
+		orphans += int(result.RowsAffected)
+	}
+
+	if s.requeue {
+		for _, execution := range interrupted {
+			s.requeueExecution(execution)
+		}
+	}
+	return len(interrupted) + orphans, nil
+}
+
+// interrupt 把执行标记为已中断，执行对象仍为执行中时同样标记为已中断；执行已被其他实例处理时返回false
+func (s *ExecutionService) interrupt(execution models.Execution) bool {
+	db := database.GetDB()
+	result := db.Model(&models.Execution{}).
+		Where("id = ? AND status = ?", execution.ID, models.ExecutionRunning).
+		Updates(map[string]interface{}{
+			"status":   models.ExecutionInterrupted,
+			"end_time": time.Now(),
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false
+	}
+
+	var target interface{} = &models.TestTask{}
+	if execution.Kind == models.ExecutionKindCase {
+		target = &models.TestCase{}
+	}
+	db.Model(target).Where("id = ? AND status = ?", execution.TargetID, models.RunStatusRunning).
+		Update("status", models.RunStatusInterrupted)
+
+	s.logger.Info("执行已中断",
+		"category", "EXECUTION",
+		"kind", execution.Kind,
+		"target_id", execution.TargetID,
+		"run_id", execution.RunID,
+		"owner", execution.Owner,
+	)
+	return true
+}
+
+// requeueExecution 重新执行被中断的任务或用例，每个执行记录只重新执行一次
+func (s *ExecutionService) requeueExecution(execution models.Execution) {
+	result := database.GetDB().Model(&models.Execution{}).
+		Where("id = ? AND requeued = ?", execution.ID, false).
+		Update("requeued", true)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return
+	}
+
+	var err error
+	switch execution.Kind {
+	case models.ExecutionKindTask:
+		err = s.taskService.StartTaskRun(context.Background(), execution.TargetID, NewRunID())
+	case models.ExecutionKindCase:
+		var envID uint
+		if execution.EnvID != nil {
+			envID = *execution.EnvID
+		}
+		err = s.taskService.StartCaseRun(context.Background(), execution.TargetID, envID)
+	}
+	if err != nil {
+		s.logger.Error("重新执行被中断的执行失败",
+			"category", "EXECUTION",
+			"kind", execution.Kind,
+			"target_id", execution.TargetID,
+			"error", err.Error(),
+		)
+	}
+}
@@ -1,13 +1,15 @@
 package services
 
+import "seldom-platform/config"
+
 var (
 	// GlobalScheduler 全局调度服务实例
 	GlobalScheduler *SchedulerService
 )
 
 // InitGlobalScheduler 初始化全局调度服务
-func InitGlobalScheduler() error {
-	GlobalScheduler = NewSchedulerService()
+func InitGlobalScheduler(cfg *config.Config) error {
+	GlobalScheduler = NewSchedulerService(cfg)
 	return GlobalScheduler.Start()
 }
 
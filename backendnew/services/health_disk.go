@@ -0,0 +1,14 @@
+//go:build !windows
+
+package services
+
+import "syscall"
+
+// diskFree 返回path所在文件系统对非特权用户可用的剩余空间（字节）
+func diskFree(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
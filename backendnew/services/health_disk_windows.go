@@ -0,0 +1,18 @@
+//go:build windows
+
+package services
+
+import "golang.org/x/sys/windows"
+
+// diskFree 返回path所在磁盘对当前用户可用的剩余空间（字节）
+func diskFree(path string) (uint64, error) {
+	dir, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var free uint64
+	if err := windows.GetDiskFreeSpaceEx(dir, &free, nil, nil); err != nil {
+		return 0, err
+	}
+	return free, nil
+}
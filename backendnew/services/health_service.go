@@ -0,0 +1,337 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"seldom-platform/config"
+	"seldom-platform/database"
+	"seldom-platform/utils"
+)
+
+// 健康检查状态
+const (
+	HealthOK       = "ok"       // 正常
+	HealthDegraded = "degraded" // 可用但性能下降，不影响就绪
+	HealthFail     = "fail"     // 不可用
+	HealthSkipped  = "skipped"  // 未开启或不适用
+)
+
+// HealthReport 健康检查结果，Checks为各组件的状态和详情
+type HealthReport struct {
+	Status string                            `json:"status"`
+	Checks map[string]map[string]interface{} `json:"checks"`
+}
+
+// OK 是否没有失败的检查项
+func (r *HealthReport) OK() bool {
+	return r.Status != HealthFail
+}
+
+// healthCheck 单个组件的检查函数，返回status字段和详情
+type healthCheck func(ctx context.Context) map[string]interface{}
+
+// HealthService 健康检查服务
+//
+// Liveness只检查进程自身是否卡死（调度循环能否响应），不依赖外部服务，失败时应重启实例；
+// Readiness检查数据库、迁移版本、调度器、执行器、工作目录磁盘空间和Redis（可选），失败时应摘除流量。
+type HealthService struct {
+	cfg    *config.Config
+	logger *utils.Logger
+}
+
+// NewHealthService 创建健康检查服务实例
+func NewHealthService(cfg *config.Config) *HealthService {
+	return &HealthService{
+		cfg:    cfg,
+		logger: utils.GetLogger(),
+	}
+}
+
+// Liveness 存活检查
+func (s *HealthService) Liveness(ctx context.Context) *HealthReport {
+	return s.run(ctx, map[string]healthCheck{
+		"scheduler": s.checkScheduler,
+	})
+}
+
+// Readiness 就绪检查
+func (s *HealthService) Readiness(ctx context.Context) *HealthReport {
+	return s.run(ctx, map[string]healthCheck{
+		"database":   s.checkDatabase,
+		"migrations": s.checkMigrations,
+		"scheduler":  s.checkScheduler,
+		"executor":   s.checkExecutor,
+		"disk":       s.checkDisk,
+		"redis":      s.checkRedis,
+	})
+}
+
+// run 并发执行检查项，超时未返回的检查项记为失败；有失败项时整体为fail，有降级项时为degraded
+func (s *HealthService) run(ctx context.Context, checks map[string]healthCheck) *HealthReport {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout())
+	defer cancel()
+
+	report := &HealthReport{Status: HealthOK, Checks: make(map[string]map[string]interface{}, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check healthCheck) {
+			defer wg.Done()
+
+			start := time.Now()
+			done := make(chan map[string]interface{}, 1)
+			go func() { done <- check(ctx) }()
+
+			var result map[string]interface{}
+			select {
+			case result = <-done:
+			case <-ctx.Done():
+				result = failed(fmt.Errorf("检查超时: %w", ctx.Err()))
+			}
+			result["latency_ms"] = float64(time.Since(start).Microseconds()) / 1000
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			switch result["status"] {
+			case HealthFail:
+				report.Status = HealthFail
+			case HealthDegraded:
+				if report.Status == HealthOK {
+					report.Status = HealthDegraded
+				}
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	if !report.OK() {
+		for name, result := range report.Checks {
+			if result["status"] == HealthFail {
+				s.logger.Ctx(ctx).Warn("Health check failed", "category", "Health", "check", name, "error", result["error"])
+			}
+		}
+	}
+	return report
+}
+
+func (s *HealthService) timeout() time.Duration {
+	timeout := s.cfg.Current().Health.Timeout
+	if timeout <= 0 {
+		return 2 * time.Second
+	}
+	return time.Duration(timeout) * time.Second
+}
+
+func failed(err error) map[string]interface{} {
+	return map[string]interface{}{"status": HealthFail, "error": err.Error()}
+}
+
+// checkDatabase 检查数据库连接
+func (s *HealthService) checkDatabase(ctx context.Context) map[string]interface{} {
+	db := database.GetDB()
+	if db == nil {
+		return failed(errors.New("数据库未初始化"))
+	}
+	if err := db.DB().PingContext(ctx); err != nil {
+		return failed(err)
+	}
+	stats := db.DB().Stats()
+	return map[string]interface{}{
+		"status":           HealthOK,
+		"open_connections": stats.OpenConnections,
+		"in_use":           stats.InUse,
+	}
+}
+
+// checkMigrations 检查数据库迁移版本是否与程序一致，有未执行、已修改或程序中不存在的迁移时失败
+func (s *HealthService) checkMigrations(ctx context.Context) map[string]interface{} {
+	db := database.GetDB()
+	if db == nil {
+		return failed(errors.New("数据库未初始化"))
+	}
+	migrator, err := database.NewMigrator(db, s.cfg.Database.Driver)
+	if err != nil {
+		return failed(err)
+	}
+	statuses, err := migrator.Status()
+	if err != nil {
+		return failed(err)
+	}
+
+	var version, latest uint64
+	var pending, modified, missing []uint64
+	for _, status := range statuses {
+		switch {
+		case status.Missing:
+			missing = append(missing, status.Version)
+		case status.AppliedAt == nil:
+			pending = append(pending, status.Version)
+		case status.Modified:
+			modified = append(modified, status.Version)
+		}
+		if status.AppliedAt != nil && status.Version > version {
+			version = status.Version
+		}
+		if !status.Missing && status.Version > latest {
+			latest = status.Version
+		}
+	}
+
+	result := map[string]interface{}{
+		"status":  HealthOK,
+		"version": version,
+		"latest":  latest,
+	}
+	var problems []string
+	if len(pending) > 0 {
+		result["pending"] = pending
+		problems = append(problems, fmt.Sprintf("%d个迁移未执行", len(pending)))
+	}
+	if len(modified) > 0 {
+		result["modified"] = modified
+		problems = append(problems, fmt.Sprintf("%d个迁移执行后被修改", len(modified)))
+	}
+	if len(missing) > 0 {
+		result["missing"] = missing
+		problems = append(problems, fmt.Sprintf("%d个已执行的迁移在程序中不存在", len(missing)))
+	}
+	if len(problems) > 0 {
+		result["status"] = HealthFail
+		result["error"] = strings.Join(problems, "，")
+	}
+	return result
+}
+
+// checkScheduler 检查调度循环是否响应，以及调度中的定时任务数
+func (s *HealthService) checkScheduler(ctx context.Context) map[string]interface{} {
+	scheduler := GlobalScheduler
+	if scheduler == nil {
+		return map[string]interface{}{"status": HealthSkipped}
+	}
+	timeout := s.timeout()
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	entries, err := scheduler.Ping(timeout)
+	if err != nil {
+		return failed(err)
+	}
+	return map[string]interface{}{"status": HealthOK, "entries": entries}
+}
+
+// checkExecutor 检查执行器，正在退出时失败，并发数已满且有排队时降级
+func (s *HealthService) checkExecutor(ctx context.Context) map[string]interface{} {
+	stats := GetExecutorStats()
+	result := map[string]interface{}{
+		"status": HealthOK,
+		"active": stats.Active,
+		"queued": stats.Queued,
+		"limit":  stats.Limit,
+	}
+	switch {
+	case stats.Draining:
+		result["status"] = HealthFail
+		result["error"] = "正在退出，不再接受新的执行"
+	case stats.Saturated():
+		result["status"] = HealthDegraded
+	}
+	return result
+}
+
+// checkDisk 检查工作目录的剩余磁盘空间
+func (s *HealthService) checkDisk(ctx context.Context) map[string]interface{} {
+	cfg := s.cfg.Current().Health
+	if cfg.DiskMinFreeMB <= 0 {
+		return map[string]interface{}{"status": HealthSkipped}
+	}
+	free, err := diskFree(cfg.DiskPath)
+	if err != nil {
+		return failed(err)
+	}
+
+	result := map[string]interface{}{
+		"status":      HealthOK,
+		"path":        cfg.DiskPath,
+		"free_mb":     free >> 20,
+		"min_free_mb": cfg.DiskMinFreeMB,
+	}
+	if free>>20 < uint64(cfg.DiskMinFreeMB) {
+		result["status"] = HealthFail
+		result["error"] = "磁盘剩余空间不足"
+	}
+	return result
+}
+
+// checkRedis 检查Redis连接，发送PING并等待PONG
+func (s *HealthService) checkRedis(ctx context.Context) map[string]interface{} {
+	if !s.cfg.Current().Health.Redis {
+		return map[string]interface{}{"status": HealthSkipped}
+	}
+	cfg := s.cfg.Redis
+	if err := pingRedis(ctx, net.JoinHostPort(cfg.Host, cfg.Port), cfg.Password, cfg.DB); err != nil {
+		return failed(err)
+	}
+	return map[string]interface{}{"status": HealthOK}
+}
+
+// pingRedis 使用RESP协议依次发送AUTH、SELECT和PING
+func pingRedis(ctx context.Context, addr, password string, db int) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	reader := bufio.NewReader(conn)
+	command := func(args ...string) (string, error) {
+		var b strings.Builder
+		fmt.Fprintf(&b, "*%d\r\n", len(args))
+		for _, arg := range args {
+			fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+		if _, err := conn.Write([]byte(b.String())); err != nil {
+			return "", err
+		}
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(line, "-") {
+			return "", errors.New(strings.TrimPrefix(line, "-"))
+		}
+		return line, nil
+	}
+
+	if password != "" {
+		if _, err := command("AUTH", password); err != nil {
+			return err
+		}
+	}
+	if db != 0 {
+		if _, err := command("SELECT", fmt.Sprint(db)); err != nil {
+			return err
+		}
+	}
+	reply, err := command("PING")
+	if err != nil {
+		return err
+	}
+	if reply != "+PONG" {
+		return fmt.Errorf("unexpected reply to PING: %s", reply)
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"seldom-platform/database"
+	"seldom-platform/models"
+	"seldom-platform/utils"
+)
+
+// ErrIdentityConflict 外部账号的用户名已被本地账号占用，且无法确认是同一个人
+var ErrIdentityConflict = errors.New("username is already used by a local account")
+
+// 用户组映射的目标类型
+const (
+	GroupRoleSuperuser = "superuser"
+	GroupRoleStaff     = "staff"
+	GroupRoleProject   = "project"
+	GroupRoleTeam      = "team"
+)
+
+// GroupRoleRule 外部用户组到平台角色的映射规则
+type GroupRoleRule struct {
+	Group string // 外部用户组
+	Kind  string // 目标类型：superuser、staff、project、team
+	Name  string // 项目或团队名称
+	Role  string // 项目或团队中的成员角色
+}
+
+// ParseGroupRoles 解析用户组映射规则
+//
+// 规则之间用逗号分隔，每条规则为 "用户组:目标"，目标可以是 superuser、staff、
+// project/<项目名>/<角色> 或 team/<团队名>/<角色>，例如：
+//
+//	platform-admins:superuser,qa:staff,qa:project/Demo/maintainer,ops:team/Ops/runner
+func ParseGroupRoles(spec string) ([]GroupRoleRule, error) {
+	var rules []GroupRoleRule
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		group, target, ok := strings.Cut(item, ":")
+		if !ok || group == "" || target == "" {
+			return nil, fmt.Errorf("invalid group role rule %q", item)
+		}
+
+		rule := GroupRoleRule{Group: group, Kind: target}
+		if target != GroupRoleSuperuser && target != GroupRoleStaff {
+			first := strings.Index(target, "/")
+			last := strings.LastIndex(target, "/")
+			if first < 0 || first == last {
+				return nil, fmt.Errorf("invalid group role rule %q", item)
+			}
+			rule.Kind, rule.Name, rule.Role = target[:first], target[first+1:last], target[last+1:]
+			if (rule.Kind != GroupRoleProject && rule.Kind != GroupRoleTeam) || rule.Name == "" || !models.IsValidRole(rule.Role) {
+				return nil, fmt.Errorf("invalid group role rule %q", item)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ExternalIdentity 外部身份源返回的用户信息
+type ExternalIdentity struct {
+	Provider      string   // 身份源
+	Subject       string   // 身份源中的唯一标识
+	Username      string   // 用户名
+	Email         string   // 邮箱
+	EmailVerified bool     // 邮箱是否已由身份源验证
+	FirstName     string   // 名
+	LastName      string   // 姓
+	Groups        []string // 用户组
+}
+
+// IdentityService 外部身份源用户服务，负责自动创建用户、关联账号和按用户组同步角色
+type IdentityService struct {
+	logger *utils.Logger
+}
+
+// NewIdentityService 创建外部身份源用户服务实例
+func NewIdentityService() *IdentityService {
+	return &IdentityService{
+		logger: utils.GetLogger(),
+	}
+}
+
+// Provision 根据外部身份查找或创建平台用户，同步用户资料并按用户组映射角色
+//
+// 首次登录时如果同名本地账号已存在，只有在身份源确认的邮箱与本地账号邮箱一致时才会关联，
+// 否则返回ErrIdentityConflict。调用方需要自行检查返回用户的IsActive。
+func (s *IdentityService) Provision(identity ExternalIdentity, rules []GroupRoleRule) (*models.User, error) {
+	if identity.Subject == "" || identity.Username == "" || len(identity.Username) > 150 {
+		return nil, fmt.Errorf("invalid %s identity: missing subject or username", identity.Provider)
+	}
+
+	var user models.User
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		var link models.UserIdentity
+		err := tx.Where("provider = ? AND subject = ?", identity.Provider, identity.Subject).First(&link).Error
+		switch {
+		case err == nil:
+			if err := tx.First(&user, link.UserID).Error; err != nil {
+				return err
+			}
+		case gorm.IsRecordNotFoundError(err):
+			if err := s.findOrCreateUser(tx, identity, &user); err != nil {
+				return err
+			}
+			link = models.UserIdentity{UserID: user.ID, Provider: identity.Provider, Subject: identity.Subject}
+			if err := tx.Create(&link).Error; err != nil {
+				return err
+			}
+			s.logger.Info("关联外部账号",
+				"category", "identity",
+				"username", user.Username,
+				"provider", identity.Provider,
+			)
+		default:
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&link).Update("last_login", &now).Error; err != nil {
+			return err
+		}
+
+		// 用户资料以身份源为准
+		updates := map[string]interface{}{}
+		if identity.Email != "" && identity.Email != user.Email {
+			updates["email"] = identity.Email
+		}
+		if identity.FirstName != "" && identity.FirstName != user.FirstName {
+			updates["first_name"] = identity.FirstName
+		}
+		if identity.LastName != "" && identity.LastName != user.LastName {
+			updates["last_name"] = identity.LastName
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&user).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		return s.applyGroupRoles(tx, &user, identity.Groups, rules)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// findOrCreateUser 首次登录时关联同名本地账号或创建新用户
+func (s *IdentityService) findOrCreateUser(tx *gorm.DB, identity ExternalIdentity, user *models.User) error {
+	err := tx.Where("username = ?", identity.Username).First(user).Error
+	if err == nil {
+		if identity.EmailVerified && user.Email != "" && strings.EqualFold(user.Email, identity.Email) {
+			return nil
+		}
+		return ErrIdentityConflict
+	}
+	if !gorm.IsRecordNotFoundError(err) {
+		return err
+	}
+
+	*user = models.User{
+		Username:  identity.Username,
+		Email:     identity.Email,
+		FirstName: identity.FirstName,
+		LastName:  identity.LastName,
+		IsActive:  true,
+	}
+	if err := user.SetUnusablePassword(); err != nil {
+		return err
+	}
+	if err := tx.Create(user).Error; err != nil {
+		return err
+	}
+	s.logger.Info("自动创建外部账号用户",
+		"category", "identity",
+		"username", user.Username,
+		"provider", identity.Provider,
+	)
+	return nil
+}
+
+// applyGroupRoles 按用户组映射同步用户的超级用户、员工身份以及项目和团队角色
+//
+// 映射规则中出现的目标由身份源管理：没有配置superuser规则时不修改超级用户身份，
+// 配置了则以用户组为准；映射中出现的项目和团队，成员角色取匹配规则中的最高角色，
+// 没有匹配时移除成员。未出现在映射中的项目和团队保持手动维护的成员关系。
+func (s *IdentityService) applyGroupRoles(tx *gorm.DB, user *models.User, groups []string, rules []GroupRoleRule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	inGroup := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		inGroup[group] = true
+	}
+
+	flags := map[string]bool{}
+	projectRoles := map[string]string{}
+	teamRoles := map[string]string{}
+	for _, rule := range rules {
+		matched := inGroup[rule.Group]
+		switch rule.Kind {
+		case GroupRoleSuperuser, GroupRoleStaff:
+			flags[rule.Kind] = flags[rule.Kind] || matched
+		case GroupRoleProject:
+			projectRoles[rule.Name] = mappedRole(projectRoles[rule.Name], rule.Role, matched)
+		case GroupRoleTeam:
+			teamRoles[rule.Name] = mappedRole(teamRoles[rule.Name], rule.Role, matched)
+		}
+	}
+
+	updates := map[string]interface{}{}
+	if superuser, ok := flags[GroupRoleSuperuser]; ok && superuser != user.IsSuperuser {
+		updates["is_superuser"] = superuser
+	}
+	if staff, ok := flags[GroupRoleStaff]; ok && staff != user.IsStaff {
+		updates["is_staff"] = staff
+	}
+	if len(updates) > 0 {
+		if err := tx.Model(user).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+
+	for name, role := range projectRoles {
+		var project models.Project
+		if err := tx.Where("name = ? AND is_delete = ?", name, false).First(&project).Error; err != nil {
+			s.logger.Error("用户组映射的项目不存在", "category", "identity", "project", name)
+			continue
+		}
+		where := map[string]interface{}{"project_id": project.ID, "user_id": user.ID}
+		if err := syncMember(tx, &models.ProjectMember{}, where, role); err != nil {
+			return err
+		}
+	}
+	for name, role := range teamRoles {
+		var team models.Team
+		if err := tx.Where("name = ? AND is_delete = ?", name, false).First(&team).Error; err != nil {
+			s.logger.Error("用户组映射的团队不存在", "category", "identity", "team", name)
+			continue
+		}
+		where := map[string]interface{}{"team_id": team.ID, "user_id": user.ID}
+		if err := syncMember(tx, &models.TeamMember{}, where, role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mappedRole 合并同一项目或团队的多条映射规则，取匹配规则中的最高角色
+func mappedRole(current, role string, matched bool) string {
+	if !matched {
+		return current
+	}
+	return higherRole(current, role)
+}
+
+// syncMember 将where条件对应的成员角色设置为role，role为空时移除成员
+func syncMember(tx *gorm.DB, member interface{}, where map[string]interface{}, role string) error {
+	if role == "" {
+		return tx.Where(where).Delete(member).Error
+	}
+	return tx.Where(where).Assign(map[string]interface{}{"role": role}).FirstOrCreate(member).Error
+}
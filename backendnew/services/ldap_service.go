@@ -0,0 +1,211 @@
+package services
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"seldom-platform/config"
+	"seldom-platform/database"
+	"seldom-platform/models"
+	"seldom-platform/utils"
+)
+
+// LDAP登录相关错误
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrLDAPUnavailable    = errors.New("ldap server is unavailable")
+)
+
+// LDAPService LDAP目录服务登录，先用服务账号搜索用户条目，再以用户DN和密码绑定校验
+type LDAPService struct {
+	logger          *utils.Logger
+	config          config.LDAPConfig
+	rules           []GroupRoleRule
+	identityService *IdentityService
+}
+
+// NewLDAPService 创建LDAP目录服务登录实例
+func NewLDAPService(cfg *config.Config) *LDAPService {
+	logger := utils.GetLogger()
+	rules, err := ParseGroupRoles(cfg.LDAP.GroupRoles)
+	if err != nil {
+		logger.Error("用户组映射配置错误，已忽略", "category", "ldap", "error", err.Error())
+	}
+
+	return &LDAPService{
+		logger:          logger,
+		config:          cfg.LDAP,
+		rules:           rules,
+		identityService: NewIdentityService(),
+	}
+}
+
+// Enabled 是否已配置LDAP目录服务
+func (s *LDAPService) Enabled() bool {
+	return s.config.URL != ""
+}
+
+// IsDirectoryUser 用户是否应通过目录服务登录：已关联LDAP账号，或没有本地密码
+func (s *LDAPService) IsDirectoryUser(user *models.User) bool {
+	if !user.HasUsablePassword() {
+		return true
+	}
+
+	var count int
+	database.GetDB().Model(&models.UserIdentity{}).
+		Where("user_id = ? AND provider = ?", user.ID, models.IdentityProviderLDAP).Count(&count)
+	return count > 0
+}
+
+// Authenticate 使用目录服务校验用户名和密码，返回自动创建或同步后的平台用户
+//
+// 用户不存在、存在多个匹配条目或密码错误时返回ErrInvalidCredentials，
+// 目录服务连接或查询失败时返回ErrLDAPUnavailable。
+func (s *LDAPService) Authenticate(username, password string) (*models.User, error) {
+	// 空密码会被服务器当作匿名绑定而成功，必须拒绝
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	entry, err := s.findUser(conn, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("%w: %v", ErrLDAPUnavailable, err)
+	}
+
+	groups, err := s.userGroups(conn, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := ExternalIdentity{
+		Provider:      models.IdentityProviderLDAP,
+		Subject:       strings.ToLower(entry.DN),
+		Username:      entry.GetAttributeValue(s.config.UsernameAttr),
+		Email:         entry.GetAttributeValue(s.config.EmailAttr),
+		EmailVerified: true,
+		FirstName:     entry.GetAttributeValue(s.config.FirstNameAttr),
+		LastName:      entry.GetAttributeValue(s.config.LastNameAttr),
+		Groups:        groups,
+	}
+	if identity.Username == "" {
+		identity.Username = username
+	}
+	return s.identityService.Provision(identity, s.rules)
+}
+
+// connect 连接目录服务并以服务账号绑定
+func (s *LDAPService) connect() (*ldap.Conn, error) {
+	timeout := time.Duration(s.config.Timeout) * time.Second
+	tlsConfig := &tls.Config{InsecureSkipVerify: s.config.InsecureSkipVerify}
+
+	conn, err := ldap.DialURL(s.config.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLDAPUnavailable, err)
+	}
+	conn.SetTimeout(timeout)
+
+	if s.config.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("%w: starttls: %v", ErrLDAPUnavailable, err)
+		}
+	}
+
+	if s.config.BindDN != "" {
+		err = conn.Bind(s.config.BindDN, s.config.BindPassword)
+	} else {
+		err = conn.UnauthenticatedBind("")
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("%w: service account bind: %v", ErrLDAPUnavailable, err)
+	}
+	return conn, nil
+}
+
+// findUser 按过滤器搜索用户条目，必须恰好匹配一个
+func (s *LDAPService) findUser(conn *ldap.Conn, username string) (*ldap.Entry, error) {
+	attributes := []string{s.config.UsernameAttr, s.config.EmailAttr, s.config.FirstNameAttr, s.config.LastNameAttr}
+	if s.config.GroupAttr != "" {
+		attributes = append(attributes, s.config.GroupAttr)
+	}
+
+	request := ldap.NewSearchRequest(
+		s.config.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, s.config.Timeout, false,
+		fmt.Sprintf(s.config.UserFilter, ldap.EscapeFilter(username)),
+		attributes, nil,
+	)
+	result, err := conn.Search(request)
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("%w: search user: %v", ErrLDAPUnavailable, err)
+	}
+	if len(result.Entries) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+	return result.Entries[0], nil
+}
+
+// userGroups 获取用户所属组名，组DN取第一个RDN的值（通常为cn）
+func (s *LDAPService) userGroups(conn *ldap.Conn, entry *ldap.Entry) ([]string, error) {
+	var groupDNs []string
+	if s.config.GroupAttr != "" {
+		groupDNs = append(groupDNs, entry.GetAttributeValues(s.config.GroupAttr)...)
+	}
+
+	if s.config.GroupFilter != "" {
+		baseDN := s.config.GroupBaseDN
+		if baseDN == "" {
+			baseDN = s.config.BaseDN
+		}
+		request := ldap.NewSearchRequest(
+			baseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, s.config.Timeout, false,
+			fmt.Sprintf(s.config.GroupFilter, ldap.EscapeFilter(entry.DN)),
+			[]string{"dn"}, nil,
+		)
+		result, err := conn.Search(request)
+		if err != nil {
+			return nil, fmt.Errorf("%w: search groups: %v", ErrLDAPUnavailable, err)
+		}
+		for _, group := range result.Entries {
+			groupDNs = append(groupDNs, group.DN)
+		}
+	}
+
+	groups := make([]string, 0, len(groupDNs))
+	for _, groupDN := range groupDNs {
+		groups = append(groups, groupName(groupDN))
+	}
+	return groups, nil
+}
+
+// groupName 从组DN中取出组名，无法解析为DN时原样返回
+func groupName(groupDN string) string {
+	dn, err := ldap.ParseDN(groupDN)
+	if err != nil || len(dn.RDNs) == 0 || len(dn.RDNs[0].Attributes) == 0 {
+		return groupDN
+	}
+	return dn.RDNs[0].Attributes[0].Value
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"seldom-platform/database"
+	"seldom-platform/models"
+)
+
+// ldapEntry 目录中的条目，password不为空时可以绑定
+type ldapEntry struct {
+	dn         string
+	password   string
+	attributes map[string][]string
+}
+
+// fakeLDAPServer 进程内的LDAP服务，只实现简单绑定和搜索，过滤器支持与、或、等值和存在判断
+type fakeLDAPServer struct {
+	listener net.Listener
+	entries  []ldapEntry
+	wg       sync.WaitGroup
+}
+
+func newFakeLDAPServer(t *testing.T, entries []ldapEntry) *fakeLDAPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeLDAPServer{listener: listener, entries: entries}
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(func() {
+		listener.Close()
+		s.wg.Wait()
+	})
+	return s
+}
+
+func (s *fakeLDAPServer) URL() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+func (s *fakeLDAPServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.handle(conn)
+		}()
+	}
+}
+
+// handle 处理一个连接上的请求，直到客户端解绑或断开
+func (s *fakeLDAPServer) handle(conn net.Conn) {
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		messageID := packet.Children[0].Value.(int64)
+		request := packet.Children[1]
+
+		switch request.Tag {
+		case ldap.ApplicationBindRequest:
+			name := request.Children[1].Value.(string)
+			password := request.Children[2].Data.String()
+			code := int(ldap.LDAPResultInvalidCredentials)
+			if name == "" && password == "" {
+				code = ldap.LDAPResultSuccess
+			} else if entry := s.find(name); entry != nil && entry.password != "" && entry.password == password {
+				code = ldap.LDAPResultSuccess
+			}
+			s.reply(conn, messageID, ldapResult(ldap.ApplicationBindResponse, code))
+		case ldap.ApplicationSearchRequest:
+			base := strings.ToLower(request.Children[0].Value.(string))
+			sizeLimit := int(request.Children[3].Value.(int64))
+			var matched []*ldapEntry
+			for i := range s.entries {
+				entry := &s.entries[i]
+				if strings.HasSuffix(strings.ToLower(entry.dn), base) && matchFilter(request.Children[6], entry) {
+					matched = append(matched, entry)
+				}
+			}
+			code := int(ldap.LDAPResultSuccess)
+			if sizeLimit > 0 && len(matched) > sizeLimit {
+				matched, code = matched[:sizeLimit], int(ldap.LDAPResultSizeLimitExceeded)
+			}
+			for _, entry := range matched {
+				s.reply(conn, messageID, searchEntry(entry))
+			}
+			s.reply(conn, messageID, ldapResult(ldap.ApplicationSearchResultDone, code))
+		default:
+			return
+		}
+	}
+}
+
+func (s *fakeLDAPServer) find(dn string) *ldapEntry {
+	for i := range s.entries {
+		if strings.EqualFold(s.entries[i].dn, dn) {
+			return &s.entries[i]
+		}
+	}
+	return nil
+}
+
+func (s *fakeLDAPServer) reply(conn net.Conn, messageID int64, response *ber.Packet) {
+	envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "MessageID"))
+	envelope.AppendChild(response)
+	conn.Write(envelope.Bytes())
+}
+
+func ldapResult(tag ber.Tag, code int) *ber.Packet {
+	packet := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "Result Code"))
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+	return packet
+}
+
+func searchEntry(entry *ldapEntry) *ber.Packet {
+	packet := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.dn, "Object Name"))
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	for name, values := range entry.attributes {
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+		}
+		attribute.AppendChild(set)
+		attributes.AppendChild(attribute)
+	}
+	packet.AppendChild(attributes)
+	return packet
+}
+
+// matchFilter 判断条目是否匹配过滤器，属性名和值都不区分大小写
+func matchFilter(filter *ber.Packet, entry *ldapEntry) bool {
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !matchFilter(child, entry) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, child := range filter.Children {
+			if matchFilter(child, entry) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterEqualityMatch:
+		for _, value := range entry.values(filter.Children[0].Data.String()) {
+			if strings.EqualFold(value, filter.Children[1].Data.String()) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterPresent:
+		return len(entry.values(filter.Data.String())) > 0
+	}
+	return false
+}
+
+func (e *ldapEntry) values(name string) []string {
+	for attribute, values := range e.attributes {
+		if strings.EqualFold(attribute, name) {
+			return values
+		}
+	}
+	return nil
+}
+
+const (
+	ldapBaseDN  = "dc=example,dc=com"
+	ldapAdminDN = "cn=admin,dc=example,dc=com"
+	ldapAliceDN = "uid=alice,ou=people,dc=example,dc=com"
+	ldapBobDN   = "uid=bob,ou=people,dc=example,dc=com"
+)
+
+// testDirectory 测试目录：alice通过memberOf属于qa组，bob通过组条目的member属于ops组，dup有两个条目
+func testDirectory() []ldapEntry {
+	return []ldapEntry{
+		{dn: ldapAdminDN, password: "admin-pass", attributes: map[string][]string{"cn": {"admin"}}},
+		{dn: ldapAliceDN, password: "alice-pass", attributes: map[string][]string{
+			"uid":       {"alice"},
+			"mail":      {"alice@example.com"},
+			"givenName": {"Alice"},
+			"sn":        {"Liddell"},
+			"memberOf":  {"cn=qa,ou=groups,dc=example,dc=com"},
+		}},
+		{dn: ldapBobDN, password: "bob-pass", attributes: map[string][]string{"uid": {"bob"}}},
+		{dn: "cn=ops,ou=groups,dc=example,dc=com", attributes: map[string][]string{"cn": {"ops"}, "member": {ldapBobDN}}},
+		{dn: "uid=dup,ou=people,dc=example,dc=com", password: "dup-pass", attributes: map[string][]string{"uid": {"dup"}}},
+		{dn: "uid=dup,ou=contractors,dc=example,dc=com", password: "dup-pass", attributes: map[string][]string{"uid": {"dup"}}},
+	}
+}
+
+// newTestLDAPService 创建连接到测试目录的LDAP服务
+func newTestLDAPService(t *testing.T, server *fakeLDAPServer, configure func(*LDAPService)) *LDAPService {
+	t.Helper()
+	cfg := newTestConfig(t)
+	setupTestDB(t, cfg)
+	cfg.LDAP.URL = server.URL()
+	cfg.LDAP.BindDN = ldapAdminDN
+	cfg.LDAP.BindPassword = "admin-pass"
+	cfg.LDAP.BaseDN = ldapBaseDN
+	cfg.LDAP.Timeout = 5
+	service := NewLDAPService(cfg)
+	if configure != nil {
+		configure(service)
+	}
+	return service
+}
+
+func TestLDAPAuthenticateProvisionsUser(t *testing.T) {
+	server := newFakeLDAPServer(t, testDirectory())
+	service := newTestLDAPService(t, server, func(s *LDAPService) {
+		s.rules, _ = ParseGroupRoles("qa:staff")
+	})
+
+	user, err := service.Authenticate("alice", "alice-pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "alice" || user.Email != "alice@example.com" || user.FirstName != "Alice" || user.LastName != "Liddell" || !user.IsStaff {
+		t.Errorf("user = %+v, want alice with profile and staff role", user)
+	}
+	if !service.IsDirectoryUser(user) {
+		t.Error("provisioned user is not a directory user")
+	}
+
+	var link models.UserIdentity
+	if err := database.GetDB().Where("provider = ? AND subject = ?", models.IdentityProviderLDAP, ldapAliceDN).First(&link).Error; err != nil || link.UserID != user.ID {
+		t.Errorf("identity link = %+v, %v, want linked to user %d", link, err, user.ID)
+	}
+}
+
+func TestLDAPAuthenticateSearchesGroups(t *testing.T) {
+	server := newFakeLDAPServer(t, testDirectory())
+	service := newTestLDAPService(t, server, func(s *LDAPService) {
+		s.config.GroupFilter = "(member=%s)"
+		s.rules, _ = ParseGroupRoles("ops:superuser")
+	})
+
+	user, err := service.Authenticate("bob", "bob-pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.IsSuperuser {
+		t.Error("group found through group_filter was not mapped to superuser")
+	}
+}
+
+func TestLDAPAuthenticateRejectsInvalidCredentials(t *testing.T) {
+	server := newFakeLDAPServer(t, testDirectory())
+	service := newTestLDAPService(t, server, nil)
+
+	tests := map[string][2]string{
+		"wrong password":   {"alice", "wrong"},
+		"empty password":   {"alice", ""},
+		"unknown user":     {"carol", "carol-pass"},
+		"ambiguous user":   {"dup", "dup-pass"},
+		"filter injection": {"*", "alice-pass"},
+	}
+	for name, credentials := range tests {
+		if _, err := service.Authenticate(credentials[0], credentials[1]); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s: err = %v, want ErrInvalidCredentials", name, err)
+		}
+	}
+
+	var count int
+	database.GetDB().Model(&models.User{}).Count(&count)
+	if count != 0 {
+		t.Errorf("%d users were created by rejected logins", count)
+	}
+}
+
+func TestLDAPAuthenticateReportsUnavailableServer(t *testing.T) {
+	server := newFakeLDAPServer(t, testDirectory())
+	service := newTestLDAPService(t, server, func(s *LDAPService) {
+		s.config.BindPassword = "wrong"
+	})
+	if _, err := service.Authenticate("alice", "alice-pass"); !errors.Is(err, ErrLDAPUnavailable) {
+		t.Errorf("wrong service account password: err = %v, want ErrLDAPUnavailable", err)
+	}
+
+	service.config.URL = "ldap://127.0.0.1:1"
+	if _, err := service.Authenticate("alice", "alice-pass"); !errors.Is(err, ErrLDAPUnavailable) {
+		t.Errorf("unreachable server: err = %v, want ErrLDAPUnavailable", err)
+	}
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"seldom-platform/config"
+	"seldom-platform/utils"
+)
+
+// ErrMailNotConfigured 未配置SMTP服务器
+var ErrMailNotConfigured = errors.New("smtp server is not configured")
+
+// MailService SMTP邮件服务，用于发送测试报告、密码重置和邮箱验证邮件，SMTP配置支持重新加载
+type MailService struct {
+	logger *utils.Logger
+	config *config.Config
+}
+
+// NewMailService 创建邮件服务实例
+func NewMailService(cfg *config.Config) *MailService {
+	return &MailService{
+		logger: utils.GetLogger(),
+		config: cfg,
+	}
+}
+
+// IsConfigured 是否已配置SMTP服务器
+func (s *MailService) IsConfigured() bool {
+	return s.config.Current().Mail.Host != ""
+}
+
+// Send 发送纯文本邮件，465端口使用SSL直连，其他端口在服务器支持时使用STARTTLS
+func (s *MailService) Send(to []string, subject, body string) error {
+	if !s.IsConfigured() {
+		return ErrMailNotConfigured
+	}
+	if len(to) == 0 {
+		return errors.New("no recipients")
+	}
+
+	mail := s.config.Current().Mail
+	addr := net.JoinHostPort(mail.Host, mail.Port)
+	message := buildMessage(mail.From, to, subject, body)
+
+	var auth smtp.Auth
+	if mail.Username != "" {
+		auth = smtp.PlainAuth("", mail.Username, mail.Password, mail.Host)
+	}
+
+	if mail.Port != "465" {
+		return smtp.SendMail(addr, auth, mail.From, to, message)
+	}
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, "tcp", addr,
+		&tls.Config{ServerName: mail.Host})
+	if err != nil {
+		return fmt.Errorf("连接SMTP服务器失败: %v", err)
+	}
+
+	client, err := smtp.NewClient(conn, mail.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("连接SMTP服务器失败: %v", err)
+	}
+	defer client.Close()
+
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP认证失败: %v", err)
+		}
+	}
+	if err := client.Mail(mail.From); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		writer.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// SendAsync 异步发送邮件，失败时记录日志
+func (s *MailService) SendAsync(to []string, subject, body string) {
+	go func() {
+		if err := s.Send(to, subject, body); err != nil {
+			s.logger.Error("发送邮件失败",
+				"category", "mail",
+				"to", strings.Join(to, ";"),
+				"subject", subject,
+				"error", err.Error(),
+			)
+		}
+	}()
+}
+
+// buildMessage 构造邮件内容
+func buildMessage(from string, to []string, subject, body string) []byte {
+	var builder strings.Builder
+	builder.WriteString("From: " + from + "\r\n")
+	builder.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	builder.WriteString("Subject: =?UTF-8?B?" + base64.StdEncoding.EncodeToString([]byte(subject)) + "?=\r\n")
+	builder.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	builder.WriteString("Content-Transfer-Encoding: base64\r\n")
+	builder.WriteString("\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(body))
+	for len(encoded) > 76 {
+		builder.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	builder.WriteString(encoded + "\r\n")
+	return []byte(builder.String())
+}
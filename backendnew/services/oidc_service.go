@@ -0,0 +1,483 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"seldom-platform/config"
+	"seldom-platform/models"
+	"seldom-platform/utils"
+)
+
+// OIDC登录相关错误
+var (
+	ErrOIDCNotConfigured = errors.New("oidc login is not configured")
+	ErrOIDCLoginFailed   = errors.New("oidc login failed")
+)
+
+// oidcKeysRefreshInterval 遇到未知kid时重新获取JWKS的最小间隔，避免被伪造的token打满身份提供方
+const oidcKeysRefreshInterval = time.Minute
+
+// oidcProviderMetadata 身份提供方的discovery文档
+type oidcProviderMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcTokenResponse 令牌端点的响应
+type oidcTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// jsonWebKey JWKS中的公钥
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// OIDCService OIDC单点登录服务，使用授权码模式并强制PKCE（S256）
+type OIDCService struct {
+	logger          *utils.Logger
+	config          config.OIDCConfig
+	client          *http.Client
+	rules           []GroupRoleRule
+	identityService *IdentityService
+
+	mu            sync.Mutex
+	metadata      *oidcProviderMetadata
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+// NewOIDCService 创建OIDC单点登录服务实例
+func NewOIDCService(cfg *config.Config) *OIDCService {
+	logger := utils.GetLogger()
+	rules, err := ParseGroupRoles(cfg.OIDC.GroupRoles)
+	if err != nil {
+		logger.Error("用户组映射配置错误，已忽略", "category", "oidc", "error", err.Error())
+	}
+
+	return &OIDCService{
+		logger:          logger,
+		config:          cfg.OIDC,
+		client:          &http.Client{Timeout: 10 * time.Second},
+		rules:           rules,
+		identityService: NewIdentityService(),
+	}
+}
+
+// Enabled 是否已配置OIDC单点登录
+func (s *OIDCService) Enabled() bool {
+	return s.config.Issuer != "" && s.config.ClientID != ""
+}
+
+// AuthorizationURL 生成跳转到身份提供方的授权地址
+func (s *OIDCService) AuthorizationURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	metadata, err := s.providerMetadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {s.config.ClientID},
+		"redirect_uri":          {s.config.RedirectURL},
+		"scope":                 {strings.Join(s.scopes(), " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {CodeChallenge(codeVerifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return metadata.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Authenticate 使用授权码换取并校验ID token，返回自动创建或同步后的平台用户
+func (s *OIDCService) Authenticate(ctx context.Context, code, codeVerifier, nonce string) (*models.User, error) {
+	if !s.Enabled() {
+		return nil, ErrOIDCNotConfigured
+	}
+
+	tokens, err := s.exchange(ctx, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := s.verifyIDToken(ctx, tokens.IDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	// 部分身份提供方只在userinfo中返回邮箱和用户组
+	if tokens.AccessToken != "" {
+		if err := s.mergeUserinfo(ctx, tokens.AccessToken, claims); err != nil {
+			s.logger.Error("获取userinfo失败", "category", "oidc", "error", err.Error())
+		}
+	}
+
+	return s.identityService.Provision(s.identityFromClaims(claims), s.rules)
+}
+
+// CodeChallenge 计算PKCE的S256 code_challenge
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// exchange 使用授权码和code_verifier向令牌端点换取令牌
+func (s *OIDCService) exchange(ctx context.Context, code, codeVerifier string) (*oidcTokenResponse, error) {
+	metadata, err := s.providerMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {s.config.RedirectURL},
+		"client_id":     {s.config.ClientID},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if s.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+	}
+
+	var tokens oidcTokenResponse
+	status, err := s.doJSON(req, &tokens)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK || tokens.Error != "" {
+		return nil, fmt.Errorf("%w: token endpoint returned %d %s %s", ErrOIDCLoginFailed, status, tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("%w: token response has no id_token", ErrOIDCLoginFailed)
+	}
+	return &tokens, nil
+}
+
+// verifyIDToken 校验ID token的签名、issuer、audience、有效期和nonce
+func (s *OIDCService) verifyIDToken(ctx context.Context, rawToken, nonce string) (jwt.MapClaims, error) {
+	metadata, err := s.providerMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return s.signingKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(metadata.Issuer),
+		jwt.WithAudience(s.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid id_token: %v", ErrOIDCLoginFailed, err)
+	}
+
+	if value, _ := claims["nonce"].(string); value != nonce {
+		return nil, fmt.Errorf("%w: id_token nonce mismatch", ErrOIDCLoginFailed)
+	}
+	if audience, _ := claims.GetAudience(); len(audience) > 1 {
+		if azp, _ := claims["azp"].(string); azp != s.config.ClientID {
+			return nil, fmt.Errorf("%w: id_token azp mismatch", ErrOIDCLoginFailed)
+		}
+	}
+	return claims, nil
+}
+
+// mergeUserinfo 将userinfo中ID token没有的claim合并进来
+func (s *OIDCService) mergeUserinfo(ctx context.Context, accessToken string, claims jwt.MapClaims) error {
+	metadata, err := s.providerMetadata(ctx)
+	if err != nil || metadata.UserinfoEndpoint == "" {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadata.UserinfoEndpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	userinfo := map[string]interface{}{}
+	status, err := s.doJSON(req, &userinfo)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("userinfo endpoint returned %d", status)
+	}
+	if userinfo["sub"] != claims["sub"] {
+		return errors.New("userinfo sub mismatch")
+	}
+
+	for key, value := range userinfo {
+		if _, ok := claims[key]; !ok {
+			claims[key] = value
+		}
+	}
+	return nil
+}
+
+// identityFromClaims 从claim中提取外部身份信息
+func (s *OIDCService) identityFromClaims(claims jwt.MapClaims) ExternalIdentity {
+	identity := ExternalIdentity{
+		Provider:  models.IdentityProviderOIDC,
+		Subject:   claimString(claims, "sub"),
+		Username:  claimString(claims, s.config.UsernameClaim),
+		Email:     claimString(claims, "email"),
+		FirstName: claimString(claims, "given_name"),
+		LastName:  claimString(claims, "family_name"),
+	}
+	if identity.Username == "" {
+		identity.Username = identity.Email
+	}
+
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+
+	switch groups := claims[s.config.GroupsClaim].(type) {
+	case []interface{}:
+		for _, group := range groups {
+			if name, ok := group.(string); ok {
+				identity.Groups = append(identity.Groups, name)
+			}
+		}
+	case string:
+		identity.Groups = strings.Fields(groups)
+	}
+	return identity
+}
+
+// providerMetadata 获取并缓存身份提供方的discovery文档
+func (s *OIDCService) providerMetadata(ctx context.Context) (*oidcProviderMetadata, error) {
+	if !s.Enabled() {
+		return nil, ErrOIDCNotConfigured
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.metadata != nil {
+		return s.metadata, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.config.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata oidcProviderMetadata
+	status, err := s.doJSON(req, &metadata)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: discovery returned %d", ErrOIDCLoginFailed, status)
+	}
+	if strings.TrimSuffix(metadata.Issuer, "/") != s.config.Issuer {
+		return nil, fmt.Errorf("%w: discovery issuer %q does not match %q", ErrOIDCLoginFailed, metadata.Issuer, s.config.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: incomplete discovery document", ErrOIDCLoginFailed)
+	}
+
+	s.metadata = &metadata
+	return s.metadata, nil
+}
+
+// signingKey 按kid查找ID token的验签公钥，未找到时重新获取JWKS以支持密钥轮换
+func (s *OIDCService) signingKey(ctx context.Context, kid string) (interface{}, error) {
+	metadata, err := s.providerMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key := s.lookupKey(kid); key != nil {
+		return key, nil
+	}
+	if time.Since(s.keysFetchedAt) < oidcKeysRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadata.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	status, err := s.doJSON(req, &jwks)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("jwks endpoint returned %d", status)
+	}
+
+	s.keys = make(map[string]interface{}, len(jwks.Keys))
+	s.keysFetchedAt = time.Now()
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			s.logger.Error("忽略无法解析的JWKS公钥",
+				"category", "oidc",
+				"kid", jwk.Kid,
+				"error", err.Error(),
+			)
+			continue
+		}
+		s.keys[jwk.Kid] = key
+	}
+
+	if key := s.lookupKey(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey 在已缓存的公钥中查找，token没有kid且只有一个公钥时直接使用该公钥
+func (s *OIDCService) lookupKey(kid string) interface{} {
+	if key, ok := s.keys[kid]; ok {
+		return key
+	}
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key
+		}
+	}
+	return nil
+}
+
+// scopes 申请的scope，始终包含openid
+func (s *OIDCService) scopes() []string {
+	for _, scope := range s.config.Scopes {
+		if scope == "openid" {
+			return s.config.Scopes
+		}
+	}
+	return append([]string{"openid"}, s.config.Scopes...)
+}
+
+// doJSON 发送请求并解析JSON响应
+func (s *OIDCService) doJSON(req *http.Request, out interface{}) (int, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if err := json.Unmarshal(body, out); err != nil && resp.StatusCode == http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("%w: invalid json response from %s", ErrOIDCLoginFailed, req.URL.Host)
+	}
+	return resp.StatusCode, nil
+}
+
+// publicKey 将JWK转换为RSA或ECDSA公钥
+func (k *jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("invalid rsa exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+func claimString(claims jwt.MapClaims, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
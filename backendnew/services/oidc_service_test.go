@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"seldom-platform/database"
+	"seldom-platform/models"
+)
+
+// fakeOIDCProvider 进程内的身份提供方，提供discovery、JWKS、令牌和userinfo端点。
+// 令牌端点只接受code和与code_challenge匹配的code_verifier，返回用key签名的ID token
+type fakeOIDCProvider struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	clientID  string
+	code      string
+	challenge string
+	claims    jwt.MapClaims // ID token中除iss、aud、exp外的claim
+	userinfo  map[string]interface{}
+}
+
+func newFakeOIDCProvider(t *testing.T) *fakeOIDCProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &fakeOIDCProvider{key: key, clientID: "seldom", code: "auth-code"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"userinfo_endpoint":      p.URL + "/userinfo",
+			"jwks_uri":               p.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("grant_type") != "authorization_code" || r.PostFormValue("code") != p.code ||
+			CodeChallenge(r.PostFormValue("code_verifier")) != p.challenge {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{
+			"access_token": "access-token",
+			"id_token":     p.idToken(t, p.key, "k1"),
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writeJSON(w, http.StatusOK, p.userinfo)
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// idToken 生成签名的ID token
+func (p *fakeOIDCProvider) idToken(t *testing.T, key *rsa.PrivateKey, kid string) string {
+	claims := jwt.MapClaims{"iss": p.URL, "aud": p.clientID, "exp": time.Now().Add(time.Hour).Unix()}
+	for name, value := range p.claims {
+		claims[name] = value
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Error(err)
+	}
+	return signed
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// newTestOIDCService 创建连接到provider的OIDC服务，开始一次登录并返回code_verifier
+func newTestOIDCService(t *testing.T, provider *fakeOIDCProvider, groupRoles string) (*OIDCService, string) {
+	t.Helper()
+	cfg := newTestConfig(t)
+	setupTestDB(t, cfg)
+	cfg.OIDC.Issuer = provider.URL
+	cfg.OIDC.ClientID = provider.clientID
+	cfg.OIDC.RedirectURL = "http://localhost:8080/api/auth/oidc/callback"
+	cfg.OIDC.GroupRoles = groupRoles
+	service := NewOIDCService(cfg)
+
+	verifier := "verifier-0123456789-0123456789-0123456789"
+	authURL, err := service.AuthorizationURL(context.Background(), "state", "nonce-1", verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("client_id") != provider.clientID || query.Get("nonce") != "nonce-1" {
+		t.Errorf("authorization url %q is missing PKCE or client parameters", authURL)
+	}
+	provider.challenge = query.Get("code_challenge")
+	return service, verifier
+}
+
+func TestOIDCAuthenticateProvisionsUser(t *testing.T) {
+	provider := newFakeOIDCProvider(t)
+	provider.claims = jwt.MapClaims{
+		"sub":                "user-1",
+		"nonce":              "nonce-1",
+		"preferred_username": "alice",
+		"email":              "alice@example.com",
+		"email_verified":     true,
+	}
+	// 用户组只在userinfo中返回
+	provider.userinfo = map[string]interface{}{"sub": "user-1", "given_name": "Alice", "groups": []string{"qa"}}
+	service, verifier := newTestOIDCService(t, provider, "qa:staff")
+
+	user, err := service.Authenticate(context.Background(), provider.code, verifier, "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "alice" || user.Email != "alice@example.com" || user.FirstName != "Alice" || !user.IsStaff {
+		t.Errorf("user = %+v, want alice, staff, with email and first name", user)
+	}
+	if user.HasUsablePassword() {
+		t.Error("OIDC user must not have a usable local password")
+	}
+
+	var link models.UserIdentity
+	if err := database.GetDB().Where("provider = ? AND subject = ?", models.IdentityProviderOIDC, "user-1").First(&link).Error; err != nil || link.UserID != user.ID {
+		t.Errorf("identity link = %+v, %v, want linked to user %d", link, err, user.ID)
+	}
+
+	// 再次登录使用同一个用户
+	again, err := service.Authenticate(context.Background(), provider.code, verifier, "nonce-1")
+	if err != nil || again.ID != user.ID {
+		t.Errorf("second login = %v, %v, want user %d", again, err, user.ID)
+	}
+}
+
+func TestOIDCAuthenticateRejectsInvalidLogins(t *testing.T) {
+	provider := newFakeOIDCProvider(t)
+	provider.claims = jwt.MapClaims{"sub": "user-1", "nonce": "nonce-1", "preferred_username": "alice"}
+	service, verifier := newTestOIDCService(t, provider, "")
+
+	if _, err := service.Authenticate(context.Background(), provider.code, "wrong-verifier", "nonce-1"); !errors.Is(err, ErrOIDCLoginFailed) {
+		t.Errorf("wrong code_verifier: err = %v, want ErrOIDCLoginFailed", err)
+	}
+	if _, err := service.Authenticate(context.Background(), provider.code, verifier, "other-nonce"); !errors.Is(err, ErrOIDCLoginFailed) {
+		t.Errorf("nonce mismatch: err = %v, want ErrOIDCLoginFailed", err)
+	}
+
+	// 不在JWKS中的密钥签名的ID token
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.verifyIDToken(context.Background(), provider.idToken(t, other, "k1"), "nonce-1"); !errors.Is(err, ErrOIDCLoginFailed) {
+		t.Errorf("foreign signature: err = %v, want ErrOIDCLoginFailed", err)
+	}
+
+	provider.clientID = "another-client"
+	if _, err := service.verifyIDToken(context.Background(), provider.idToken(t, provider.key, "k1"), "nonce-1"); !errors.Is(err, ErrOIDCLoginFailed) {
+		t.Errorf("wrong audience: err = %v, want ErrOIDCLoginFailed", err)
+	}
+
+	var count int
+	database.GetDB().Model(&models.User{}).Count(&count)
+	if count != 0 {
+		t.Errorf("%d users were created by rejected logins", count)
+	}
+}
+
+func TestOIDCAuthenticateConflictsWithLocalAccount(t *testing.T) {
+	provider := newFakeOIDCProvider(t)
+	provider.claims = jwt.MapClaims{
+		"sub":                "user-1",
+		"nonce":              "nonce-1",
+		"preferred_username": "alice",
+		"email":              "mallory@example.com",
+		"email_verified":     true,
+	}
+	service, verifier := newTestOIDCService(t, provider, "")
+	local := models.User{Username: "alice", Email: "alice@example.com", IsActive: true}
+	if err := local.SetPassword("local-password"); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.GetDB().Create(&local).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.Authenticate(context.Background(), provider.code, verifier, "nonce-1"); !errors.Is(err, ErrIdentityConflict) {
+		t.Errorf("err = %v, want ErrIdentityConflict", err)
+	}
+}
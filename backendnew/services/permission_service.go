@@ -0,0 +1,173 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+	"seldom-platform/database"
+	"seldom-platform/models"
+)
+
+// PermissionScope 权限校验范围，用户在项目和团队中的角色取较高者
+type PermissionScope struct {
+	ProjectID uint
+	TeamID    uint
+	Global    bool // 全局资源（如全局环境），所有用户可查看，员工可修改
+}
+
+// PermissionService 权限服务
+//
+// 超级用户拥有所有资源的owner权限；员工（IsStaff）可以创建项目和团队，
+// 并拥有所有项目和团队的viewer权限；其他用户的权限来自项目成员和团队成员角色。
+type PermissionService struct{}
+
+// NewPermissionService 创建权限服务实例
+func NewPermissionService() *PermissionService {
+	return &PermissionService{}
+}
+
+// EffectiveRole 获取用户在指定范围内的有效角色，无权限时返回空字符串
+func (s *PermissionService) EffectiveRole(user *models.User, scope PermissionScope) string {
+	if user.IsSuperuser {
+		return models.RoleOwner
+	}
+
+	role := ""
+	if user.IsStaff {
+		role = models.RoleViewer
+	}
+	if scope.Global {
+		role = models.RoleViewer
+		if user.IsStaff {
+			role = models.RoleMaintainer
+		}
+	}
+	if scope.ProjectID != 0 {
+		role = higherRole(role, s.ProjectRole(user.ID, scope.ProjectID))
+	}
+	if scope.TeamID != 0 {
+		role = higherRole(role, s.TeamRole(user.ID, scope.TeamID))
+	}
+	return role
+}
+
+// HasRole 检查用户在指定范围内是否至少拥有minRole角色
+func (s *PermissionService) HasRole(user *models.User, scope PermissionScope, minRole string) bool {
+	role := s.EffectiveRole(user, scope)
+	return role != "" && models.RoleLevel(role) >= models.RoleLevel(minRole)
+}
+
+// CanCreate 检查用户是否可以创建项目和团队
+func (s *PermissionService) CanCreate(user *models.User) bool {
+	return user.IsSuperuser || user.IsStaff
+}
+
+// ProjectRole 获取用户在项目中的角色
+func (s *PermissionService) ProjectRole(userID, projectID uint) string {
+	var member models.ProjectMember
+	if err := database.GetDB().Where("project_id = ? AND user_id = ?", projectID, userID).First(&member).Error; err != nil {
+		return ""
+	}
+	return member.Role
+}
+
+// TeamRole 获取用户在团队中的角色
+func (s *PermissionService) TeamRole(userID, teamID uint) string {
+	var member models.TeamMember
+	if err := database.GetDB().Where("team_id = ? AND user_id = ?", teamID, userID).First(&member).Error; err != nil {
+		return ""
+	}
+	return member.Role
+}
+
+// FilterProjects 将查询限制在用户可见的项目内，column为项目ID列名
+func (s *PermissionService) FilterProjects(query *gorm.DB, user *models.User, column string) *gorm.DB {
+	if user.IsSuperuser || user.IsStaff {
+		return query
+	}
+	return query.Where(fmt.Sprintf("%s IN (SELECT project_id FROM app_project_member WHERE user_id = ?)", column), user.ID)
+}
+
+// FilterTeams 将查询限制在用户可见的团队内，column为团队ID列名
+func (s *PermissionService) FilterTeams(query *gorm.DB, user *models.User, column string) *gorm.DB {
+	if user.IsSuperuser || user.IsStaff {
+		return query
+	}
+	return query.Where(fmt.Sprintf("%s IN (SELECT team_id FROM app_team_member WHERE user_id = ?)", column), user.ID)
+}
+
+// FilterEnvs 将环境查询限制在全局环境和用户可见项目的环境内
+func (s *PermissionService) FilterEnvs(query *gorm.DB, user *models.User) *gorm.DB {
+	if user.IsSuperuser || user.IsStaff {
+		return query
+	}
+	return query.Where("(project_id IS NULL OR project_id IN (SELECT project_id FROM app_project_member WHERE user_id = ?))", user.ID)
+}
+
+// FilterTasks 将任务查询限制在用户可见的项目或团队内
+func (s *PermissionService) FilterTasks(query *gorm.DB, user *models.User) *gorm.DB {
+	if user.IsSuperuser || user.IsStaff {
+		return query
+	}
+	return query.Where("(project_id IN (SELECT project_id FROM app_project_member WHERE user_id = ?) OR "+
+		"team_id IN (SELECT team_id FROM app_team_member WHERE user_id = ?))", user.ID, user.ID)
+}
+
+// AddProjectMember 添加或更新项目成员
+func (s *PermissionService) AddProjectMember(projectID, userID uint, role string) (*models.ProjectMember, error) {
+	db := database.GetDB()
+
+	var member models.ProjectMember
+	err := db.Where("project_id = ? AND user_id = ?", projectID, userID).First(&member).Error
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		return nil, err
+	}
+
+	member.ProjectID = projectID
+	member.UserID = userID
+	member.Role = role
+	if err := db.Save(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// AddTeamMember 添加或更新团队成员
+func (s *PermissionService) AddTeamMember(teamID, userID uint, role string) (*models.TeamMember, error) {
+	db := database.GetDB()
+
+	var member models.TeamMember
+	err := db.Where("team_id = ? AND user_id = ?", teamID, userID).First(&member).Error
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		return nil, err
+	}
+
+	member.TeamID = teamID
+	member.UserID = userID
+	member.Role = role
+	if err := db.Save(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// CountOwners 统计项目或团队的owner数量，用于防止移除最后一个owner
+func (s *PermissionService) CountOwners(scope PermissionScope) int {
+	db := database.GetDB()
+
+	var count int
+	if scope.ProjectID != 0 {
+		db.Model(&models.ProjectMember{}).Where("project_id = ? AND role = ?", scope.ProjectID, models.RoleOwner).Count(&count)
+	} else {
+		db.Model(&models.TeamMember{}).Where("team_id = ? AND role = ?", scope.TeamID, models.RoleOwner).Count(&count)
+	}
+	return count
+}
+
+// higherRole 返回两个角色中等级较高者
+func higherRole(a, b string) string {
+	if models.RoleLevel(b) > models.RoleLevel(a) {
+		return b
+	}
+	return a
+}
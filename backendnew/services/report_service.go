@@ -0,0 +1,422 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"seldom-platform/database"
+	"seldom-platform/models"
+	"seldom-platform/utils"
+
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// CompareMatchByName 按 类名.用例名 匹配
+	CompareMatchByName = "name"
+	// CompareMatchByHash 按用例hash匹配
+	CompareMatchByHash = "hash"
+
+	// DefaultDurationThreshold 默认耗时退化阈值（百分比）
+	DefaultDurationThreshold = 20.0
+)
+
+var (
+	// ErrRunNotFound 执行批次不存在
+	ErrRunNotFound = errors.New("run not found")
+	// ErrReportNotFound 报告不存在
+	ErrReportNotFound = errors.New("report not found")
+	// ErrReportTaskNotFound 报告所属任务不存在
+	ErrReportTaskNotFound = errors.New("report task not found")
+)
+
+// ReportService 报告服务
+type ReportService struct {
+	logger *utils.Logger
+}
+
+// NewReportService 创建报告服务实例
+func NewReportService() *ReportService {
+	return &ReportService{
+		logger: utils.GetLogger(),
+	}
+}
+
+// CompareOptions 报告对比参数
+type CompareOptions struct {
+	Match     string  // 匹配方式 name/hash
+	Threshold float64 // 耗时退化阈值（百分比）
+}
+
+// CaseComparison 单个用例的对比结果
+type CaseComparison struct {
+	Key          string  `json:"key"`
+	ClassName    string  `json:"class_name"`
+	Name         string  `json:"name"`
+	CaseHash     string  `json:"case_hash,omitempty"`
+	BaseStatus   string  `json:"base_status,omitempty"`
+	HeadStatus   string  `json:"head_status,omitempty"`
+	BaseTime     float64 `json:"base_time"`
+	HeadTime     float64 `json:"head_time"`
+	TimeIncrease float64 `json:"time_increase,omitempty"` // 耗时增长百分比
+	Message      string  `json:"message,omitempty"`       // head中的失败/错误信息
+}
+
+// ReportComparisonSummary 对比摘要
+type ReportComparisonSummary struct {
+	NewlyFailing      int `json:"newly_failing"`
+	NewlyPassing      int `json:"newly_passing"`
+	StillFailing      int `json:"still_failing"`
+	Added             int `json:"added"`
+	Removed           int `json:"removed"`
+	DurationRegressed int `json:"duration_regressed"`
+}
+
+// ReportComparison 两个报告的对比结果
+type ReportComparison struct {
+	Base              models.TaskReport       `json:"base"`
+	Head              models.TaskReport       `json:"head"`
+	Match             string                  `json:"match"`
+	Threshold         float64                 `json:"threshold"`
+	Summary           ReportComparisonSummary `json:"summary"`
+	NewlyFailing      []CaseComparison        `json:"newly_failing"`
+	NewlyPassing      []CaseComparison        `json:"newly_passing"`
+	StillFailing      []CaseComparison        `json:"still_failing"`
+	Added             []CaseComparison        `json:"added"`
+	Removed           []CaseComparison        `json:"removed"`
+	DurationRegressed []CaseComparison        `json:"duration_regressed"`
+}
+
+// ReportDetailStatus 根据报告详情推断用例结果 passed/failure/error/skipped
+func ReportDetailStatus(detail models.ReportDetails) string {
+	switch {
+	case detail.ErrorOut != "":
+		return "error"
+	case detail.FailureMessage != "":
+		return "failure"
+	case detail.SkippedMessage != "":
+		return "skipped"
+	}
+
+	switch strings.ToLower(detail.Status) {
+	case "error", "errored":
+		return "error"
+	case "failure", "failed", "fail":
+		return "failure"
+	case "skipped", "skip":
+		return "skipped"
+	}
+	return "passed"
+}
+
+// isFailingStatus 失败或错误都视为失败
+func isFailingStatus(status string) bool {
+	return status == "failure" || status == "error"
+}
+
+// parseRunTime 解析报告中的耗时字符串，例如 "1.23" 或 "1.23s"
+func parseRunTime(value string) float64 {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "s")
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return seconds
+}
+
+// CompareReports 对比两个任务报告，base为基准报告，head为待对比报告
+func (s *ReportService) CompareReports(baseID, headID uint, opts CompareOptions) (*ReportComparison, error) {
+	db := database.GetDB()
+
+	if opts.Match == "" {
+		opts.Match = CompareMatchByName
+	}
+	if opts.Match != CompareMatchByName && opts.Match != CompareMatchByHash {
+		return nil, fmt.Errorf("不支持的匹配方式: %s", opts.Match)
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultDurationThreshold
+	}
+
+	var base, head models.TaskReport
+	if err := db.First(&base, baseID).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrReportNotFound
+		}
+		return nil, fmt.Errorf("获取基准报告失败: %v", err)
+	}
+	if err := db.First(&head, headID).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrReportNotFound
+		}
+		return nil, fmt.Errorf("获取对比报告失败: %v", err)
+	}
+
+	baseCases, err := s.loadReportCases(base, opts.Match)
+	if err != nil {
+		return nil, err
+	}
+	headCases, err := s.loadReportCases(head, opts.Match)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReportComparison{
+		Base:              base,
+		Head:              head,
+		Match:             opts.Match,
+		Threshold:         opts.Threshold,
+		NewlyFailing:      make([]CaseComparison, 0),
+		NewlyPassing:      make([]CaseComparison, 0),
+		StillFailing:      make([]CaseComparison, 0),
+		Added:             make([]CaseComparison, 0),
+		Removed:           make([]CaseComparison, 0),
+		DurationRegressed: make([]CaseComparison, 0),
+	}
+
+	for key, h := range headCases {
+		b, exists := baseCases[key]
+		if !exists {
+			h.BaseStatus = ""
+			h.BaseTime = 0
+			result.Added = append(result.Added, h)
+			continue
+		}
+
+		item := h
+		item.BaseStatus = b.BaseStatus
+		item.BaseTime = b.BaseTime
+
+		baseFailing := isFailingStatus(item.BaseStatus)
+		headFailing := isFailingStatus(item.HeadStatus)
+		switch {
+		case headFailing && !baseFailing:
+			result.NewlyFailing = append(result.NewlyFailing, item)
+		case headFailing && baseFailing:
+			result.StillFailing = append(result.StillFailing, item)
+		case !headFailing && baseFailing && item.HeadStatus == "passed":
+			result.NewlyPassing = append(result.NewlyPassing, item)
+		}
+
+		if item.BaseTime > 0 && item.HeadTime > item.BaseTime {
+			increase := (item.HeadTime - item.BaseTime) / item.BaseTime * 100
+			if increase > opts.Threshold {
+				item.TimeIncrease = increase
+				result.DurationRegressed = append(result.DurationRegressed, item)
+			}
+		}
+	}
+
+	for key, b := range baseCases {
+		if _, exists := headCases[key]; !exists {
+			b.HeadStatus = ""
+			b.HeadTime = 0
+			b.Message = ""
+			result.Removed = append(result.Removed, b)
+		}
+	}
+
+	for _, list := range [][]CaseComparison{
+		result.NewlyFailing, result.NewlyPassing, result.StillFailing,
+		result.Added, result.Removed,
+	} {
+		sortCaseComparisons(list)
+	}
+	sort.Slice(result.DurationRegressed, func(i, j int) bool {
+		return result.DurationRegressed[i].TimeIncrease > result.DurationRegressed[j].TimeIncrease
+	})
+
+	result.Summary = ReportComparisonSummary{
+		NewlyFailing:      len(result.NewlyFailing),
+		NewlyPassing:      len(result.NewlyPassing),
+		StillFailing:      len(result.StillFailing),
+		Added:             len(result.Added),
+		Removed:           len(result.Removed),
+		DurationRegressed: len(result.DurationRegressed),
+	}
+
+	return result, nil
+}
+
+// loadReportCases 加载报告详情并按匹配键建立索引
+// 同一报告中重复出现的用例（例如重跑）以最后一条记录为准
+func (s *ReportService) loadReportCases(report models.TaskReport, match string) (map[string]CaseComparison, error) {
+	db := database.GetDB()
+
+	var details []models.ReportDetails
+	if err := db.Where("result_id = ?", report.ID).Order("id ASC").Find(&details).Error; err != nil {
+		return nil, fmt.Errorf("获取报告详情失败: %v", err)
+	}
+
+	hashes := map[string]string{}
+	if match == CompareMatchByHash {
+		var err error
+		if hashes, err = s.loadCaseHashes(report.TaskID); err != nil {
+			return nil, err
+		}
+	}
+
+	cases := make(map[string]CaseComparison, len(details))
+	for _, detail := range details {
+		nameKey := detail.ClassName + "." + detail.Name
+		status := ReportDetailStatus(detail)
+		item := CaseComparison{
+			Key:        nameKey,
+			ClassName:  detail.ClassName,
+			Name:       detail.Name,
+			BaseStatus: status,
+			HeadStatus: status,
+			BaseTime:   parseRunTime(detail.Time),
+			HeadTime:   parseRunTime(detail.Time),
+		}
+		if status == "error" {
+			item.Message = detail.ErrorOut
+		} else if status == "failure" {
+			item.Message = detail.FailureMessage
+		}
+		// 按hash匹配时，找不到对应用例的记录回退为按名称匹配
+		if hash, ok := hashes[nameKey]; ok {
+			item.Key = hash
+			item.CaseHash = hash
+		}
+		cases[item.Key] = item
+	}
+
+	return cases, nil
+}
+
+// loadCaseHashes 获取任务所属项目中 类名.用例名 到用例hash的映射
+func (s *ReportService) loadCaseHashes(taskID uint) (map[string]string, error) {
+	db := database.GetDB()
+
+	var task models.TestTask
+	if err := db.First(&task, taskID).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrReportTaskNotFound
+		}
+		return nil, fmt.Errorf("获取报告所属任务失败: %v", err)
+	}
+
+	var testCases []models.TestCase
+	if err := db.Where("project_id = ?", task.ProjectID).Find(&testCases).Error; err != nil {
+		return nil, fmt.Errorf("获取项目用例失败: %v", err)
+	}
+
+	hashes := make(map[string]string, len(testCases))
+	for _, testCase := range testCases {
+		hashes[testCase.ClassName+"."+testCase.CaseName] = testCase.CaseHash
+	}
+	return hashes, nil
+}
+
+// sortCaseComparisons 按匹配键排序，保证输出稳定
+func sortCaseComparisons(list []CaseComparison) {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Key < list[j].Key
+	})
+}
+
+// TaskRun 一次任务执行的汇总，Envs为各环境的报告
+type TaskRun struct {
+	RunID      string              `json:"run_id"`
+	TaskID     uint                `json:"task_id"`
+	Status     string              `json:"status"`
+	Passed     int                 `json:"passed"`
+	Error      int                 `json:"error"`
+	Failure    int                 `json:"failure"`
+	Skipped    int                 `json:"skipped"`
+	Tests      int                 `json:"tests"`
+	CreateTime time.Time           `json:"create_time"`
+	Envs       []models.TaskReport `json:"envs"`
+}
+
+// ListTaskRuns 按执行批次分页获取任务的执行记录，按执行时间倒序
+func (s *ReportService) ListTaskRuns(taskID uint, offset, limit int) ([]TaskRun, int64, error) {
+	db := database.GetDB()
+	query := db.Model(&models.TaskReport{}).Where("task_id = ? AND run_id <> ''", taskID)
+
+	var total int64
+	if err := query.Select("COUNT(DISTINCT run_id)").Row().Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := query.Select("run_id, MAX(id) AS last_id").Group("run_id").
+		Order("last_id DESC").Offset(offset).Limit(limit).Rows()
+	if err != nil {
+		return nil, 0, err
+	}
+	runIDs := make([]string, 0, limit)
+	for rows.Next() {
+		var runID string
+		var lastID uint
+		if err := rows.Scan(&runID, &lastID); err != nil {
+			rows.Close()
+			return nil, 0, err
+		}
+		runIDs = append(runIDs, runID)
+	}
+	rows.Close()
+
+	var reports []models.TaskReport
+	if len(runIDs) > 0 {
+		if err := db.Where("task_id = ? AND run_id IN (?)", taskID, runIDs).Order("id ASC").Find(&reports).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+
+	byRun := make(map[string][]models.TaskReport, len(runIDs))
+	for _, report := range reports {
+		byRun[report.RunID] = append(byRun[report.RunID], report)
+	}
+	runs := make([]TaskRun, 0, len(runIDs))
+	for _, runID := range runIDs {
+		runs = append(runs, newTaskRun(taskID, runID, byRun[runID]))
+	}
+	return runs, total, nil
+}
+
+// GetTaskRun 获取一次任务执行的汇总结果，报告按环境分组
+func (s *ReportService) GetTaskRun(taskID uint, runID string) (*TaskRun, error) {
+	if runID == "" {
+		return nil, ErrRunNotFound
+	}
+
+	var reports []models.TaskReport
+	if err := database.GetDB().Where("task_id = ? AND run_id = ?", taskID, runID).Order("id ASC").Find(&reports).Error; err != nil {
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return nil, ErrRunNotFound
+	}
+
+	run := newTaskRun(taskID, runID, reports)
+	return &run, nil
+}
+
+// newTaskRun 汇总同一批次的各环境报告，任一环境有失败或错误则批次失败
+func newTaskRun(taskID uint, runID string, reports []models.TaskReport) TaskRun {
+	run := TaskRun{
+		RunID:  runID,
+		TaskID: taskID,
+		Status: "success",
+		Envs:   reports,
+	}
+	for _, report := range reports {
+		run.Passed += report.Passed
+		run.Error += report.Error
+		run.Failure += report.Failure
+		run.Skipped += report.Skipped
+		run.Tests += report.Tests
+		if report.Error > 0 || report.Failure > 0 {
+			run.Status = "failed"
+		}
+		if run.CreateTime.IsZero() || report.CreateTime.Before(run.CreateTime) {
+			run.CreateTime = report.CreateTime
+		}
+	}
+	return run
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"seldom-platform/database"
+	"seldom-platform/models"
+)
+
+// addReportDetails 为任务添加一份报告和报告详情，详情按顺序写入
+func addReportDetails(t *testing.T, taskID uint, details []models.ReportDetails) models.TaskReport {
+	t.Helper()
+	db := database.GetDB()
+	report := models.TaskReport{TaskID: taskID}
+	if err := db.Create(&report).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, detail := range details {
+		detail.ResultID = report.ID
+		if err := db.Create(&detail).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return report
+}
+
+// comparisonKeys 各分组中的匹配键
+func comparisonKeys(result *ReportComparison) map[string][]string {
+	groups := map[string][]CaseComparison{
+		"newly_failing":      result.NewlyFailing,
+		"newly_passing":      result.NewlyPassing,
+		"still_failing":      result.StillFailing,
+		"added":              result.Added,
+		"removed":            result.Removed,
+		"duration_regressed": result.DurationRegressed,
+	}
+	keys := make(map[string][]string)
+	for name, items := range groups {
+		for _, item := range items {
+			keys[name] = append(keys[name], item.Key)
+		}
+	}
+	return keys
+}
+
+func TestCompareReports(t *testing.T) {
+	passed := func(class, name, time string) models.ReportDetails {
+		return models.ReportDetails{ClassName: class, Name: name, Status: "passed", Time: time}
+	}
+	failed := func(class, name string) models.ReportDetails {
+		return models.ReportDetails{ClassName: class, Name: name, FailureMessage: "assert failed", Time: "1"}
+	}
+
+	tests := []struct {
+		name   string
+		opts   CompareOptions
+		hashes map[string]string // 项目用例 类名.用例名 -> hash
+		base   []models.ReportDetails
+		head   []models.ReportDetails
+		want   map[string][]string
+	}{
+		{
+			name: "status changes",
+			base: []models.ReportDetails{
+				passed("Login", "test_a", "1"), failed("Login", "test_b"), failed("Login", "test_c"), failed("Login", "test_d"),
+			},
+			head: []models.ReportDetails{
+				failed("Login", "test_a"), failed("Login", "test_b"), passed("Login", "test_c", "1"),
+				{ClassName: "Login", Name: "test_d", SkippedMessage: "skip"},
+			},
+			want: map[string][]string{
+				"newly_failing": {"Login.test_a"},
+				"still_failing": {"Login.test_b"},
+				"newly_passing": {"Login.test_c"},
+			},
+		},
+		{
+			name: "renamed case matched by name",
+			base: []models.ReportDetails{passed("Login", "test_login", "1")},
+			head: []models.ReportDetails{failed("Login", "test_sign_in")},
+			want: map[string][]string{
+				"added":   {"Login.test_sign_in"},
+				"removed": {"Login.test_login"},
+			},
+		},
+		{
+			name:   "renamed case matched by hash",
+			opts:   CompareOptions{Match: CompareMatchByHash},
+			hashes: map[string]string{"Login.test_login": "h-login", "Login.test_sign_in": "h-login"},
+			base:   []models.ReportDetails{passed("Login", "test_login", "1"), passed("Cart", "test_add", "1")},
+			head:   []models.ReportDetails{failed("Login", "test_sign_in"), passed("Cart", "test_remove", "1")},
+			// 没有对应用例的记录回退为按名称匹配
+			want: map[string][]string{
+				"newly_failing": {"h-login"},
+				"added":         {"Cart.test_remove"},
+				"removed":       {"Cart.test_add"},
+			},
+		},
+		{
+			name: "duplicate names use the last record",
+			base: []models.ReportDetails{failed("Login", "test_a"), passed("Login", "test_a", "1"), passed("Login", "test_b", "1")},
+			head: []models.ReportDetails{passed("Login", "test_a", "1"), failed("Login", "test_a"), passed("Login", "test_b", "1"), passed("Login", "test_b", "1")},
+			want: map[string][]string{
+				"newly_failing": {"Login.test_a"},
+			},
+		},
+		{
+			name: "duration regressions sorted by increase",
+			base: []models.ReportDetails{passed("Login", "test_a", "1"), passed("Login", "test_b", "1s"), passed("Login", "test_c", "1"), passed("Login", "test_d", "0")},
+			head: []models.ReportDetails{passed("Login", "test_a", "1.5"), passed("Login", "test_b", "3s"), passed("Login", "test_c", "1.1"), passed("Login", "test_d", "9")},
+			want: map[string][]string{
+				"duration_regressed": {"Login.test_b", "Login.test_a"},
+			},
+		},
+		{
+			name: "custom threshold",
+			opts: CompareOptions{Threshold: 60},
+			base: []models.ReportDetails{passed("Login", "test_a", "1"), passed("Login", "test_b", "1")},
+			head: []models.ReportDetails{passed("Login", "test_a", "1.5"), passed("Login", "test_b", "3")},
+			want: map[string][]string{
+				"duration_regressed": {"Login.test_b"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestDB(t, newTestConfig(t))
+			project, task := createDashboardTask(t)
+			for key, hash := range tt.hashes {
+				className, caseName, _ := strings.Cut(key, ".")
+				testCase := models.TestCase{ProjectID: project.ID, ClassName: className, CaseName: caseName, CaseHash: hash}
+				if err := database.GetDB().Create(&testCase).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+			base := addReportDetails(t, task.ID, tt.base)
+			head := addReportDetails(t, task.ID, tt.head)
+
+			result, err := NewReportService().CompareReports(base.ID, head.ID, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := comparisonKeys(result); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("comparison = %v, want %v", got, tt.want)
+			}
+			summary := ReportComparisonSummary{
+				NewlyFailing:      len(tt.want["newly_failing"]),
+				NewlyPassing:      len(tt.want["newly_passing"]),
+				StillFailing:      len(tt.want["still_failing"]),
+				Added:             len(tt.want["added"]),
+				Removed:           len(tt.want["removed"]),
+				DurationRegressed: len(tt.want["duration_regressed"]),
+			}
+			if result.Summary != summary {
+				t.Errorf("summary = %+v, want %+v", result.Summary, summary)
+			}
+		})
+	}
+}
+
+func TestCompareReportsChangedCaseDetails(t *testing.T) {
+	setupTestDB(t, newTestConfig(t))
+	_, task := createDashboardTask(t)
+	base := addReportDetails(t, task.ID, []models.ReportDetails{{ClassName: "Login", Name: "test_a", Status: "passed", Time: "2"}})
+	head := addReportDetails(t, task.ID, []models.ReportDetails{{ClassName: "Login", Name: "test_a", ErrorOut: "Traceback", Time: "3s"}})
+
+	result, err := NewReportService().CompareReports(base.ID, head.ID, CompareOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Match != CompareMatchByName || result.Threshold != DefaultDurationThreshold {
+		t.Errorf("match = %s, threshold = %v, want defaults", result.Match, result.Threshold)
+	}
+	want := CaseComparison{
+		Key: "Login.test_a", ClassName: "Login", Name: "test_a",
+		BaseStatus: "passed", HeadStatus: "error", BaseTime: 2, HeadTime: 3,
+		Message: "Traceback",
+	}
+	if len(result.NewlyFailing) != 1 || result.NewlyFailing[0] != want {
+		t.Errorf("newly failing = %+v, want [%+v]", result.NewlyFailing, want)
+	}
+	if len(result.DurationRegressed) != 1 || result.DurationRegressed[0].TimeIncrease != 50 {
+		t.Errorf("duration regressed = %+v, want one case with a 50%% increase", result.DurationRegressed)
+	}
+}
+
+func TestCompareReportsErrors(t *testing.T) {
+	setupTestDB(t, newTestConfig(t))
+	_, task := createDashboardTask(t)
+	report := addReportDetails(t, task.ID, nil)
+	// 所属任务不存在的报告
+	orphan := addReportDetails(t, task.ID+100, nil)
+
+	tests := []struct {
+		name       string
+		base, head uint
+		opts       CompareOptions
+		wantErr    error
+	}{
+		{"missing base", report.ID + 100, report.ID, CompareOptions{}, ErrReportNotFound},
+		{"missing head", report.ID, report.ID + 100, CompareOptions{}, ErrReportNotFound},
+		{"missing task", orphan.ID, report.ID, CompareOptions{Match: CompareMatchByHash}, ErrReportTaskNotFound},
+		{"unknown match", report.ID, report.ID, CompareOptions{Match: "file"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewReportService().CompareReports(tt.base, tt.head, tt.opts)
+			if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
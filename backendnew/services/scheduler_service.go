@@ -1,11 +1,16 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/robfig/cron/v3"
+	"seldom-platform/config"
 	"seldom-platform/database"
+	"seldom-platform/metrics"
 	"seldom-platform/models"
 	"seldom-platform/utils"
 )
@@ -15,14 +20,15 @@ type SchedulerService struct {
 	cron   *cron.Cron
 	logger *utils.Logger
 	taskService *TaskService
+	running     atomic.Bool
 }
 
 // NewSchedulerService 创建调度服务实例
-func NewSchedulerService() *SchedulerService {
+func NewSchedulerService(cfg *config.Config) *SchedulerService {
 	return &SchedulerService{
 		cron:   cron.New(cron.WithSeconds()),
 		logger: utils.GetLogger(),
-		taskService: NewTaskService(),
+		taskService: NewTaskService(cfg),
 	}
 }
 
@@ -35,15 +41,17 @@ func (s *SchedulerService) Start() error {
 
 	// 启动cron调度器
 	s.cron.Start()
+	s.running.Store(true)
 	
-	s.logger.LogInfo("SCHEDULER", "调度服务已启动", nil)
+	s.logger.Info("调度服务已启动", "category", "SCHEDULER")
 	return nil
 }
 
 // Stop 停止调度服务
 func (s *SchedulerService) Stop() {
+	s.running.Store(false)
 	s.cron.Stop()
-	s.logger.LogInfo("SCHEDULER", "调度服务已停止", nil)
+	s.logger.Info("调度服务已停止", "category", "SCHEDULER")
 }
 
 // loadScheduledTasks 加载定时任务
@@ -58,14 +66,17 @@ func (s *SchedulerService) loadScheduledTasks() error {
 
 	for _, task := range tasks {
 		if err := s.addScheduledTask(task); err != nil {
-			s.logger.LogError("SCHEDULER", fmt.Sprintf("添加定时任务失败: %v", err), map[string]interface{}{
-				"task_id": task.ID,
-				"task_name": task.Name,
-			})
+			s.logger.Error("添加定时任务失败",
+				"category", "SCHEDULER",
+				"task_id", task.ID,
+				"task_name", task.Name,
+				"error", err,
+			)
 		}
 	}
 
-	s.logger.LogInfo("SCHEDULER", fmt.Sprintf("已加载 %d 个定时任务", len(tasks)), nil)
+	metrics.SchedulerEntries.Set(float64(len(s.cron.Entries())))
+	s.logger.Info("已加载定时任务", "category", "SCHEDULER", "count", len(tasks))
 	return nil
 }
 
@@ -88,52 +99,43 @@ func (s *SchedulerService) addScheduledTask(task models.TestTask) error {
 	if err != nil {
 		return fmt.Errorf("添加cron任务失败: %v", err)
 	}
+	metrics.SchedulerEntries.Set(float64(len(s.cron.Entries())))
 
-	s.logger.LogInfo("SCHEDULER", fmt.Sprintf("已添加定时任务: %s", task.Name), map[string]interface{}{
-		"task_id": task.ID,
-		"cron_expression": task.CronExpression,
-	})
+	s.logger.Info("已添加定时任务",
+		"category", "SCHEDULER",
+		"task_id", task.ID,
+		"task_name", task.Name,
+		"cron_expression", task.CronExpression,
+	)
 
 	return nil
 }
 
 // executeScheduledTask 执行定时任务
 func (s *SchedulerService) executeScheduledTask(taskID uint) {
-	s.logger.LogInfo("SCHEDULER", fmt.Sprintf("开始执行定时任务: %d", taskID), map[string]interface{}{
-		"task_id": taskID,
-	})
+	s.logger.Info("开始执行定时任务", "category", "SCHEDULER", "task_id", taskID)
 
 	// 检查任务是否已在运行
 	status, err := s.taskService.GetTaskStatus(taskID)
 	if err != nil {
-		s.logger.LogError("SCHEDULER", fmt.Sprintf("获取任务状态失败: %v", err), map[string]interface{}{
-			"task_id": taskID,
-		})
+		s.logger.Error("获取任务状态失败", "category", "SCHEDULER", "task_id", taskID, "error", err)
+		metrics.SchedulerFires.WithLabelValues("failed").Inc()
 		return
 	}
 
 	if status == "running" {
-		s.logger.LogInfo("SCHEDULER", fmt.Sprintf("任务 %d 已在运行中，跳过本次执行", taskID), map[string]interface{}{
-			"task_id": taskID,
-		})
+		s.logger.Info("任务已在运行中，跳过本次执行", "category", "SCHEDULER", "task_id", taskID)
+		metrics.SchedulerFires.WithLabelValues("skipped").Inc()
 		return
 	}
 
-	// 异步执行任务
-	go func() {
-		result, err := s.taskService.ExecuteTask(taskID)
-		if err != nil {
-			s.logger.LogError("SCHEDULER", fmt.Sprintf("定时任务执行失败: %v", err), map[string]interface{}{
-				"task_id": taskID,
-			})
-		} else {
-			s.logger.LogInfo("SCHEDULER", fmt.Sprintf("定时任务执行完成: %d", taskID), map[string]interface{}{
-				"task_id": taskID,
-				"status": result.Status,
-				"duration": result.Duration.String(),
-			})
-		}
-	}()
+	// 异步执行任务，服务退出期间不再启动新的执行
+	if err := s.taskService.StartTaskRun(context.Background(), taskID, NewRunID()); err != nil {
+		s.logger.Error("定时任务执行失败", "category", "SCHEDULER", "task_id", taskID, "error", err)
+		metrics.SchedulerFires.WithLabelValues("failed").Inc()
+		return
+	}
+	metrics.SchedulerFires.WithLabelValues("started").Inc()
 }
 
 // AddTask 添加新的定时任务
@@ -156,22 +158,48 @@ func (s *SchedulerService) AddTask(taskID uint) error {
 func (s *SchedulerService) RemoveTask(taskID uint) error {
 	// 注意：cron/v3 不支持直接通过ID移除任务
 	// 这里需要重新加载所有任务
+	if err := s.Reload(); err != nil {
+		return err
+	}
+	
+	s.logger.Info("已移除定时任务", "category", "SCHEDULER", "task_id", taskID)
+	
+	return nil
+}
+
+// Reload 重新加载所有未删除的定时任务
+func (s *SchedulerService) Reload() error {
 	s.cron.Stop()
 	s.cron = cron.New(cron.WithSeconds())
-	
+
 	if err := s.loadScheduledTasks(); err != nil {
 		return err
 	}
-	
+
 	s.cron.Start()
-	
-	s.logger.LogInfo("SCHEDULER", fmt.Sprintf("已移除定时任务: %d", taskID), map[string]interface{}{
-		"task_id": taskID,
-	})
-	
 	return nil
 }
 
+// Ping 检查调度循环是否仍在运行，返回调度器中的定时任务数；调度服务未启动或调度循环在timeout内没有响应时返回错误
+func (s *SchedulerService) Ping(timeout time.Duration) (int, error) {
+	if !s.running.Load() {
+		return 0, errors.New("调度服务未启动")
+	}
+
+	// 运行中的cron通过调度循环返回任务列表，调度循环卡住时不会返回
+	c := s.cron
+	entries := make(chan int, 1)
+	go func() {
+		entries <- len(c.Entries())
+	}()
+	select {
+	case n := <-entries:
+		return n, nil
+	case <-time.After(timeout):
+		return 0, errors.New("调度循环无响应")
+	}
+}
+
 // UpdateTask 更新定时任务
 func (s *SchedulerService) UpdateTask(taskID uint) error {
 	// 重新加载任务（简单实现）
@@ -183,7 +211,7 @@ func (s *SchedulerService) GetScheduledTasks() ([]models.TestTask, error) {
 	db := database.GetDB()
 	
 	var tasks []models.TestTask
-	if err := db.Where("is_scheduled = ?", true).Find(&tasks).Error; err != nil {
+	if err := db.Where("is_scheduled = ? AND is_delete = ?", true, false).Find(&tasks).Error; err != nil {
 		return nil, err
 	}
 
@@ -203,6 +231,11 @@ func (s *SchedulerService) GetNextRunTime(cronExpression string) (time.Time, err
 
 // ValidateCronExpression 验证cron表达式
 func (s *SchedulerService) ValidateCronExpression(expression string) error {
+	return ValidateCron(expression)
+}
+
+// ValidateCron 验证包含秒字段的cron表达式，调度服务未启动时也可使用
+func ValidateCron(expression string) error {
 	parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
 	_, err := parser.Parse(expression)
 	return err
@@ -240,52 +273,23 @@ func (s *SchedulerService) GetTaskHistory(taskID uint, limit int) ([]models.Task
 
 // GetTaskStatistics 获取任务统计信息
 func (s *SchedulerService) GetTaskStatistics(taskID uint, days int) (map[string]interface{}, error) {
-	db := database.GetDB()
-	
-	// 计算时间范围
-	endTime := time.Now()
-	startTime := endTime.AddDate(0, 0, -days)
-	
-	// 获取执行次数
-	var totalRuns int64
-	db.Model(&models.TaskReport{}).Where("task_id = ? AND created_at BETWEEN ? AND ?", taskID, startTime, endTime).Count(&totalRuns)
-	
-	// 获取成功次数
-	var successRuns int64
-	db.Model(&models.TaskReport{}).Where("task_id = ? AND status = ? AND created_at BETWEEN ? AND ?", taskID, "success", startTime, endTime).Count(&successRuns)
-	
-	// 获取失败次数
-	var failedRuns int64
-	db.Model(&models.TaskReport{}).Where("task_id = ? AND status = ? AND created_at BETWEEN ? AND ?", taskID, "failed", startTime, endTime).Count(&failedRuns)
-	
+	stats, err := NewDashboardService().GetRunStatistics(DashboardScope{TaskID: taskID}, days)
+	if err != nil {
+		return nil, err
+	}
+
 	// 计算成功率
 	var successRate float64
-	if totalRuns > 0 {
-		successRate = float64(successRuns) / float64(totalRuns) * 100
-	}
-	
-	// 获取平均执行时间
-	var avgDuration float64
-	var reports []models.TaskReport
-	db.Where("task_id = ? AND created_at BETWEEN ? AND ?", taskID, startTime, endTime).Find(&reports)
-	
-	if len(reports) > 0 {
-		var totalDuration time.Duration
-		for _, report := range reports {
-			// TaskReport中的RunTime是字符串格式，需要解析
-			if duration, err := time.ParseDuration(report.RunTime + "s"); err == nil {
-				totalDuration += duration
-			}
-		}
-		avgDuration = totalDuration.Seconds() / float64(len(reports))
+	if stats.TotalRuns > 0 {
+		successRate = float64(stats.SuccessRuns) / float64(stats.TotalRuns) * 100
 	}
-	
+
 	return map[string]interface{}{
-		"total_runs":    totalRuns,
-		"success_runs":  successRuns,
-		"failed_runs":   failedRuns,
+		"total_runs":    stats.TotalRuns,
+		"success_runs":  stats.SuccessRuns,
+		"failed_runs":   stats.FailedRuns,
 		"success_rate":  successRate,
-		"avg_duration":  avgDuration,
+		"avg_duration":  stats.AvgDuration,
 		"period_days":   days,
 	}, nil
 }
\ No newline at end of file
@@ -1,40 +1,77 @@
 package services
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"seldom-platform/config"
 	"seldom-platform/database"
+	"seldom-platform/metrics"
 	"seldom-platform/models"
+	"seldom-platform/tracing"
 	"seldom-platform/utils"
+
+	"github.com/jinzhu/gorm"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // TaskService 任务服务
 type TaskService struct {
-	logger *utils.Logger
+	logger          *utils.Logger
+	variableService *EnvVariableService
+	workspace       string // seldom进程的工作目录
 }
 
 // NewTaskService 创建任务服务实例
-func NewTaskService() *TaskService {
+func NewTaskService(cfg *config.Config) *TaskService {
 	return &TaskService{
-		logger: utils.GetLogger(),
+		logger:          utils.GetLogger(),
+		variableService: NewEnvVariableService(cfg),
+		workspace:       cfg.Run.Workspace,
 	}
 }
 
-// TaskExecutionResult 任务执行结果
+// TaskExecutionResult 任务执行结果，任务配置多个环境时每个环境执行一次，Envs为各环境的结果
 type TaskExecutionResult struct {
 	TaskID    uint                   `json:"task_id"`
+	RunID     string                 `json:"run_id"`
 	Status    string                 `json:"status"`
 	StartTime time.Time              `json:"start_time"`
 	EndTime   time.Time              `json:"end_time"`
 	Duration  time.Duration          `json:"duration"`
 	Results   []CaseExecutionResult  `json:"results"`
 	Summary   TaskExecutionSummary   `json:"summary"`
+	Envs      []EnvExecutionResult   `json:"envs"`
 	Error     string                 `json:"error,omitempty"`
 }
 
+// EnvExecutionResult 任务在单个环境中的执行结果
+type EnvExecutionResult struct {
+	EnvID     uint                  `json:"env_id"`
+	EnvName   string                `json:"env_name"`
+	ReportID  uint                  `json:"report_id"`
+	Status    string                `json:"status"`
+	StartTime time.Time             `json:"start_time"`
+	EndTime   time.Time             `json:"end_time"`
+	Duration  time.Duration         `json:"duration"`
+	Results   []CaseExecutionResult `json:"results"`
+	Summary   TaskExecutionSummary  `json:"summary"`
+}
+
 // CaseExecutionResult 用例执行结果
 type CaseExecutionResult struct {
 	CaseID      uint      `json:"case_id"`
@@ -46,6 +83,8 @@ type CaseExecutionResult struct {
 	ErrorMsg    string    `json:"error_msg,omitempty"`
 	Screenshots []string  `json:"screenshots,omitempty"`
 	Logs        []string  `json:"logs,omitempty"`
+
+	seldom *seldomReport // seldom的执行结果，seldom未运行时为nil
 }
 
 // TaskExecutionSummary 任务执行摘要
@@ -57,13 +96,84 @@ type TaskExecutionSummary struct {
 	PassRate    float64 `json:"pass_rate"`
 }
 
+// taskCase 任务关联的用例，Case为nil表示用例已不存在
+type taskCase struct {
+	Hash string
+	Case *models.TestCase
+	Err  error
+}
+
+// NewRunID 生成执行批次ID，同一次执行中各环境的报告使用相同的批次ID
+func NewRunID() string {
+	id, err := utils.GenerateTokenID()
+	if err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return id[:32]
+}
+
+// StartTaskRun 在后台以指定的批次ID执行任务并记录执行者，服务正在退出时返回ErrShuttingDown。
+// 执行的span为ctx中span的子span，ctx结束不影响执行
+func (s *TaskService) StartTaskRun(ctx context.Context, taskID uint, runID string) error {
+	execution := &models.Execution{Kind: models.ExecutionKindTask, TargetID: taskID, RunID: runID}
+	if err := executions.begin(execution); err != nil {
+		return err
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer executions.finish(execution)
+		release := executions.acquire(models.ExecutionKindTask)
+		defer release()
+		if _, err := s.ExecuteTaskRun(ctx, taskID, runID); err != nil {
+			s.logger.Ctx(ctx).Error("任务执行失败", "task_id", taskID, "run_id", runID, "error", err)
+		}
+	}()
+	return nil
+}
+
+// StartCaseRun 在后台执行单个用例并记录执行者，服务正在退出时返回ErrShuttingDown
+func (s *TaskService) StartCaseRun(ctx context.Context, caseID, envID uint) error {
+	execution := &models.Execution{Kind: models.ExecutionKindCase, TargetID: caseID}
+	if envID != 0 {
+		execution.EnvID = &envID
+	}
+	if err := executions.begin(execution); err != nil {
+		return err
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer executions.finish(execution)
+		release := executions.acquire(models.ExecutionKindCase)
+		defer release()
+		if _, err := s.ExecuteCase(ctx, caseID, envID); err != nil {
+			s.logger.Ctx(ctx).Error("用例执行失败", "case_id", caseID, "env_id", envID, "error", err)
+		}
+	}()
+	return nil
+}
+
 // ExecuteTask 执行任务
-func (s *TaskService) ExecuteTask(taskID uint) (*TaskExecutionResult, error) {
-	db := database.GetDB()
+func (s *TaskService) ExecuteTask(ctx context.Context, taskID uint) (*TaskExecutionResult, error) {
+	return s.ExecuteTaskRun(ctx, taskID, NewRunID())
+}
+
+// ExecuteTaskRun 以指定的批次ID执行任务，每个执行环境并发执行一次并分别保存报告
+func (s *TaskService) ExecuteTaskRun(ctx context.Context, taskID uint, runID string) (*TaskExecutionResult, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "ExecuteTask", trace.WithAttributes(
+		attribute.Int64("task.id", int64(taskID)),
+		attribute.String("task.run_id", runID),
+	))
+	defer span.End()
+	ctx = utils.WithLogFields(ctx, "task_id", taskID, "run_id", runID)
+	logger := s.logger.Ctx(ctx)
+	db := database.WithContext(ctx)
 	
 	// 获取任务信息
 	var task models.TestTask
-	if err := db.First(&task, taskID).Error; err != nil {
+	if err := db.Where("is_delete = ?", false).First(&task, taskID).Error; err != nil {
+		span.SetStatus(codes.Error, "任务不存在")
 		return nil, fmt.Errorf("任务不存在: %v", err)
 	}
 
@@ -73,120 +183,269 @@ func (s *TaskService) ExecuteTask(taskID uint) (*TaskExecutionResult, error) {
 
 	result := &TaskExecutionResult{
 		TaskID:    taskID,
+		RunID:     runID,
 		Status:    "running",
 		StartTime: time.Now(),
 		Results:   make([]CaseExecutionResult, 0),
+		Envs:      make([]EnvExecutionResult, 0),
 	}
 
 	// 记录开始执行
-	s.logger.LogInfo("TASK_EXECUTION", fmt.Sprintf("开始执行任务: %d", taskID), map[string]interface{}{
-		"task_id": taskID,
-		"task_name": task.Name,
-	})
+	logger.Info("开始执行任务", "category", "TASK_EXECUTION", "task_name", task.Name)
 
 	// 获取任务关联的测试用例
 	var relevances []models.TaskCaseRelevance
 	if err := db.Where("task_id = ?", taskID).Find(&relevances).Error; err != nil {
 		result.Status = "failed"
 		result.Error = fmt.Sprintf("获取任务用例失败: %v", err)
-		s.updateTaskStatus(&task, "failed", result.Error)
+		s.updateTaskStatus(ctx, &task, "failed", result.Error)
+		span.SetStatus(codes.Error, result.Error)
+		metrics.ObserveRun(models.ExecutionKindTask, result.Status, time.Since(result.StartTime))
 		return result, err
 	}
 
-	// 执行每个测试用例
+	cases := make([]taskCase, 0, len(relevances))
 	for _, relevance := range relevances {
 		// 根据CaseHash查找测试用例
 		var testCase models.TestCase
 		if err := db.Where("case_hash = ?", relevance.CaseHash).First(&testCase).Error; err != nil {
-			// 如果找不到用例，创建一个失败的结果
-			caseResult := CaseExecutionResult{
-				CaseID:    0, // 用例不存在
-				CaseName:  relevance.CaseHash,
-				Status:    "failed",
-				StartTime: time.Now(),
-				EndTime:   time.Now(),
-				Duration:  0,
-				ErrorMsg:  fmt.Sprintf("用例不存在: %v", err),
-			}
-			result.Results = append(result.Results, caseResult)
+			cases = append(cases, taskCase{Hash: relevance.CaseHash, Err: err})
 			continue
 		}
-		
-		caseResult := s.executeSingleCase(testCase.ID)
-		result.Results = append(result.Results, caseResult)
-		
-		// 保存用例执行结果
-		s.saveCaseResult(taskID, caseResult)
+		cases = append(cases, taskCase{Hash: relevance.CaseHash, Case: &testCase})
+	}
+
+	// 获取执行环境，未配置环境时执行一次
+	envs, err := s.runEnvs(&task)
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		s.updateTaskStatus(ctx, &task, "failed", result.Error)
+		span.SetStatus(codes.Error, result.Error)
+		metrics.ObserveRun(models.ExecutionKindTask, result.Status, time.Since(result.StartTime))
+		return result, err
+	}
+
+	// 每个环境并发执行一次
+	subResults := make([]EnvExecutionResult, len(envs))
+	var wg sync.WaitGroup
+	for i, env := range envs {
+		wg.Add(1)
+		go func(i int, env *models.Env) {
+			defer wg.Done()
+			subResults[i] = s.executeInEnv(ctx, env, cases)
+		}(i, env)
+	}
+	wg.Wait()
+
+	// 按环境保存用例结果和报告
+	failed := false
+	for i := range subResults {
+		for _, caseResult := range subResults[i].Results {
+			if caseResult.CaseID != 0 {
+				s.saveCaseResult(ctx, taskID, caseResult)
+			}
+			metrics.ObserveCase(task.ProjectID, caseResult.Status)
+		}
+		subResults[i].ReportID = s.saveTaskReport(ctx, taskID, runID, &subResults[i])
+		result.Results = append(result.Results, subResults[i].Results...)
+		if subResults[i].Status == "failed" {
+			failed = true
+		}
 	}
+	result.Envs = subResults
 
 	// 计算执行结果
 	result.EndTime = time.Now()
 	result.Duration = result.EndTime.Sub(result.StartTime)
 	result.Summary = s.calculateSummary(result.Results)
 	
-	// 确定任务最终状态
-	if result.Summary.FailedCases > 0 {
+	// 确定任务最终状态，任一环境失败则任务失败
+	if failed {
 		result.Status = "failed"
 	} else {
 		result.Status = "success"
 	}
 
 	// 更新任务状态
-	s.updateTaskStatus(&task, result.Status, "")
-
-	// 保存任务报告
-	s.saveTaskReport(taskID, result)
+	s.updateTaskStatus(ctx, &task, result.Status, "")
+	span.SetAttributes(attribute.String("task.status", result.Status))
+	metrics.ObserveRun(models.ExecutionKindTask, result.Status, result.Duration)
 
 	// 记录执行完成
-	s.logger.LogInfo("TASK_EXECUTION", fmt.Sprintf("任务执行完成: %d", taskID), map[string]interface{}{
-		"task_id": taskID,
-		"status": result.Status,
-		"duration": result.Duration.String(),
-		"total_cases": result.Summary.TotalCases,
-		"passed_cases": result.Summary.PassedCases,
-		"failed_cases": result.Summary.FailedCases,
-	})
+	logger.Info("任务执行完成",
+		"category", "TASK_EXECUTION",
+		"status", result.Status,
+		"duration", result.Duration.String(),
+		"envs", len(result.Envs),
+		"total_cases", result.Summary.TotalCases,
+		"passed_cases", result.Summary.PassedCases,
+		"failed_cases", result.Summary.FailedCases,
+	)
 
 	return result, nil
 }
 
-// executeSingleCase 执行单个测试用例
-func (s *TaskService) executeSingleCase(caseID uint) CaseExecutionResult {
-	db := database.GetDB()
-	
-	result := CaseExecutionResult{
-		CaseID:    caseID,
+// runEnvs 获取任务的执行环境，未配置环境时返回一个nil表示不指定环境
+func (s *TaskService) runEnvs(task *models.TestTask) ([]*models.Env, error) {
+	envIDs := s.TaskEnvIDs(task)
+	if len(envIDs) == 0 {
+		return []*models.Env{nil}, nil
+	}
+
+	var found []models.Env
+	if err := database.GetDB().Where("id IN (?) AND is_delete = ?", envIDs, false).Find(&found).Error; err != nil {
+		return nil, fmt.Errorf("获取任务环境失败: %v", err)
+	}
+	byID := make(map[uint]*models.Env, len(found))
+	for i := range found {
+		byID[found[i].ID] = &found[i]
+	}
+
+	// 按任务配置的顺序执行，跳过已删除的环境
+	envs := make([]*models.Env, 0, len(envIDs))
+	for _, id := range envIDs {
+		if env, ok := byID[id]; ok {
+			envs = append(envs, env)
+		}
+	}
+	if len(envs) == 0 {
+		return nil, fmt.Errorf("任务配置的环境均已删除")
+	}
+	return envs, nil
+}
+
+// executeInEnv 在单个环境中执行任务的所有用例
+func (s *TaskService) executeInEnv(ctx context.Context, env *models.Env, cases []taskCase) EnvExecutionResult {
+	ctx, span := tracing.Tracer().Start(ctx, "ExecuteEnv")
+	defer span.End()
+
+	result := EnvExecutionResult{
 		StartTime: time.Now(),
-		Status:    "running",
+		Results:   make([]CaseExecutionResult, 0, len(cases)),
+	}
+	if env != nil {
+		result.EnvID = env.ID
+		result.EnvName = env.Name
+		span.SetAttributes(attribute.Int64("env.id", int64(env.ID)), attribute.String("env.name", env.Name))
 	}
+	variables, err := s.envVariables(env)
 
-	// 获取用例信息
-	var testCase models.TestCase
-	if err := db.First(&testCase, caseID).Error; err != nil {
+	// 执行每个测试用例
+	for _, item := range cases {
+		if err != nil {
+			// 环境变量不可用时该环境的用例全部失败
+			result.Results = append(result.Results, CaseExecutionResult{
+				CaseName:  item.Hash,
+				Status:    "failed",
+				StartTime: time.Now(),
+				EndTime:   time.Now(),
+				ErrorMsg:  err.Error(),
+			})
+			continue
+		}
+		if item.Case == nil {
+			// 如果找不到用例，创建一个失败的结果
+			result.Results = append(result.Results, CaseExecutionResult{
+				CaseID:    0, // 用例不存在
+				CaseName:  item.Hash,
+				Status:    "failed",
+				StartTime: time.Now(),
+				EndTime:   time.Now(),
+				Duration:  0,
+				ErrorMsg:  fmt.Sprintf("用例不存在: %v", item.Err),
+			})
+			continue
+		}
+
+		result.Results = append(result.Results, s.executeSingleCase(ctx, item.Case, variables))
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime)
+	result.Summary = s.calculateSummary(result.Results)
+	if result.Summary.FailedCases > 0 {
 		result.Status = "failed"
-		result.ErrorMsg = fmt.Sprintf("获取用例失败: %v", err)
-		result.EndTime = time.Now()
-		result.Duration = result.EndTime.Sub(result.StartTime)
-		return result
+	} else {
+		result.Status = "success"
 	}
+	span.SetAttributes(attribute.String("env.status", result.Status))
+	return result
+}
 
-	result.CaseName = testCase.CaseName
+// runEnv 传给seldom进程的环境变量，secrets为需要在日志中隐藏的密钥值
+type runEnv struct {
+	vars    map[string]string
+	secrets []string
+}
 
-	// 解析用例数据
-	var caseData map[string]interface{}
-	if err := json.Unmarshal([]byte(testCase.CaseDoc), &caseData); err != nil {
-		result.Status = "failed"
-		result.ErrorMsg = fmt.Sprintf("解析用例数据失败: %v", err)
-		result.EndTime = time.Now()
-		result.Duration = result.EndTime.Sub(result.StartTime)
-		return result
+// mask 隐藏文本中的密钥值
+func (e *runEnv) mask(text string) string {
+	if e == nil {
+		return text
+	}
+	return MaskSecrets(text, e.secrets)
+}
+
+// envVariables 将执行环境及其变量转换为传给seldom的环境变量，自定义变量覆盖同名的内置变量
+func (s *TaskService) envVariables(env *models.Env) (*runEnv, error) {
+	if env == nil {
+		return nil, nil
 	}
 
-	// 执行用例（这里简化处理，实际应该根据用例类型执行不同的逻辑）
-	if err := s.runTestCase(caseData); err != nil {
+	variables, secrets, err := s.variableService.ResolveVariables(env.ID)
+	if err != nil {
+		return nil, err
+	}
+	vars := map[string]string{
+		"ENV":        env.Env,
+		"BASE_URL":   env.BaseURL,
+		"BROWSER":    env.Browser,
+		"REMOTE":     env.Remote,
+		"APP_SERVER": env.AppServer,
+		"APP_INFO":   env.AppInfo,
+		"RERUN":      fmt.Sprintf("%d", env.Rerun),
+	}
+	for name, value := range variables {
+		vars[name] = value
+	}
+	return &runEnv{vars: vars, secrets: secrets}, nil
+}
+
+// executeSingleCase 执行单个测试用例
+func (s *TaskService) executeSingleCase(ctx context.Context, testCase *models.TestCase, env *runEnv) (result CaseExecutionResult) {
+	ctx, span := tracing.Tracer().Start(ctx, "ExecuteCase", trace.WithAttributes(
+		attribute.Int64("case.id", int64(testCase.ID)),
+		attribute.String("case.name", testCase.CaseName),
+	))
+	defer func() {
+		span.SetAttributes(attribute.String("case.status", result.Status))
+		if result.Status == "failed" {
+			span.SetStatus(codes.Error, result.ErrorMsg)
+		}
+		span.End()
+	}()
+
+	result = CaseExecutionResult{
+		CaseID:    testCase.ID,
+		CaseName:  testCase.CaseName,
+		StartTime: time.Now(),
+		Status:    "running",
+	}
+
+	// 生成了报告时以报告中的结果为准，否则以seldom进程是否正常退出为准
+	report, err := s.runTestCase(ctx, testCase, env)
+	result.seldom = report
+	switch {
+	case err != nil && (report == nil || report.Tests == 0):
 		result.Status = "failed"
-		result.ErrorMsg = err.Error()
-	} else {
+		result.ErrorMsg = env.mask(err.Error())
+	case report.Failure+report.Error > 0:
+		result.Status = "failed"
+		result.ErrorMsg = fmt.Sprintf("%d个失败，%d个错误", report.Failure, report.Error)
+	case report.Tests > 0 && report.Skipped == report.Tests:
+		result.Status = "skipped"
+	default:
 		result.Status = "passed"
 	}
 
@@ -196,43 +455,184 @@ func (s *TaskService) executeSingleCase(caseID uint) CaseExecutionResult {
 	return result
 }
 
-// runTestCase 运行测试用例
-func (s *TaskService) runTestCase(caseData map[string]interface{}) error {
-	// 这里是简化的实现，实际应该根据用例类型执行不同的测试逻辑
-	// 例如：HTTP接口测试、UI自动化测试等
-	
-	// 模拟执行时间
-	time.Sleep(time.Millisecond * 100)
-	
-	// 模拟随机成功/失败（实际应该根据真实测试结果）
-	// 这里总是返回成功，实际实现中应该执行真正的测试逻辑
-	return nil
+// seldomCase seldom -j 参数的用例格式，与seldom收集用例（-c）的输出一致
+type seldomCase struct {
+	File   string            `json:"file"`
+	Class  map[string]string `json:"class"`
+	Method map[string]string `json:"method"`
+}
+
+// seldomReport seldom的执行结果，由XML报告统计得到，Report和Output中的密钥值已隐藏
+type seldomReport struct {
+	Tests   int
+	Passed  int
+	Failure int
+	Error   int
+	Skipped int
+	RunTime float64
+	Report  string // XML报告
+	Output  string // 进程输出
+}
+
+// runTestCase 在项目代码目录中用seldom运行测试用例，env为执行环境的变量
+func (s *TaskService) runTestCase(ctx context.Context, testCase *models.TestCase, env *runEnv) (*seldomReport, error) {
+	var project models.Project
+	if err := database.WithContext(ctx).First(&project, testCase.ProjectID).Error; err != nil {
+		return nil, fmt.Errorf("用例所属项目不存在: %v", err)
+	}
+
+	dir := s.projectDir(&project)
+	if info, err := os.Stat(filepath.Join(dir, project.CaseDir)); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("用例目录不存在: %s", filepath.Join(dir, project.CaseDir))
+	}
+
+	return s.executeSeldomTest(ctx, dir, project.CaseDir, []seldomCase{{
+		File:   testCase.FileName,
+		Class:  map[string]string{"name": testCase.ClassName, "doc": testCase.ClassDoc},
+		Method: map[string]string{"name": testCase.CaseName, "doc": testCase.CaseDoc},
+	}}, env)
+}
+
+// projectDir 项目代码在工作目录中的位置，与原后端的目录结构一致：<workspace>/<项目名>/<仓库名>[_<运行版本>]
+func (s *TaskService) projectDir(project *models.Project) string {
+	repo := strings.TrimSuffix(path.Base(strings.TrimRight(project.Address, "/")), ".git")
+	if project.RunVersion != "" {
+		repo += "_" + project.RunVersion
+	}
+	return filepath.Join(s.workspace, project.Name, repo)
 }
 
-// executeSeldomTest 执行Seldom测试
-func (s *TaskService) executeSeldomTest(scriptPath string, env map[string]string) error {
+// executeSeldomTest 在dir中执行seldom命令行运行指定用例，环境变量追加在当前进程的环境变量之后，
+// 并通过TRACEPARENT环境变量把追踪上下文传给seldom进程
+func (s *TaskService) executeSeldomTest(ctx context.Context, dir, caseDir string, cases []seldomCase, env *runEnv) (*seldomReport, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "RunSeldom", trace.WithAttributes(
+		attribute.String("seldom.dir", dir),
+		attribute.String("seldom.path", caseDir),
+	))
+	defer span.End()
+
+	// 用例列表写入临时文件，报告由seldom写入 <dir>/reports 目录
+	tmp, err := os.MkdirTemp("", "seldom-run-")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	caseFile := filepath.Join(tmp, "cases.json")
+	data, _ := json.Marshal(cases)
+	if err := os.WriteFile(caseFile, data, 0o600); err != nil {
+		return nil, fmt.Errorf("写入用例列表失败: %v", err)
+	}
+	reportName := filepath.Base(tmp) + ".xml"
+	reportPath := filepath.Join(dir, "reports", reportName)
+	defer os.Remove(reportPath)
+
 	// 构建命令
-	cmd := exec.Command("python", "-m", "seldom", scriptPath)
-	
+	cmd := exec.Command(seldomCommand, seldomArgs(caseDir, caseFile, reportName, env)...)
+	cmd.Dir = dir
+
 	// 设置环境变量
+	cmd.Env = append(os.Environ(), tracing.Environ(ctx)...)
 	if env != nil {
-		for key, value := range env {
+		for key, value := range env.vars {
 			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, value))
 		}
 	}
 
-	// 执行命令
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("执行Seldom测试失败: %v, 输出: %s", err, string(output))
+	// 执行命令，输出和报告中的密钥值不写入结果
+	output, runErr := cmd.CombinedOutput()
+	report := &seldomReport{Output: env.mask(string(output))}
+	if xmlData, err := os.ReadFile(reportPath); err == nil {
+		if err := parseSeldomReport(xmlData, report); err != nil {
+			s.logger.Ctx(ctx).Warn("解析seldom报告失败", "category", "TASK_EXECUTION", "report", reportPath, "error", err)
+		}
+		report.Report = env.mask(string(xmlData))
+	}
+	span.SetAttributes(
+		attribute.Int("seldom.tests", report.Tests),
+		attribute.Int("seldom.failures", report.Failure+report.Error),
+	)
+	if runErr != nil {
+		span.SetStatus(codes.Error, runErr.Error())
+		return report, fmt.Errorf("执行Seldom测试失败: %v, 输出: %s", runErr, report.Output)
+	}
+
+	return report, nil
+}
+
+// seldomCommand seldom命令行工具
+const seldomCommand = "seldom"
+
+// seldomArgs 生成seldom命令行参数，执行环境的ENV、BASE_URL、BROWSER和RERUN转换为对应的参数
+func seldomArgs(caseDir, caseFile, reportName string, env *runEnv) []string {
+	args := []string{"-p", caseDir, "-j", caseFile, "-r", reportName}
+	if env == nil {
+		return args
+	}
+	if value := env.vars["ENV"]; value != "" {
+		args = append(args, "-e", value)
+	}
+	if value := env.vars["BASE_URL"]; value != "" {
+		args = append(args, "-u", value)
+	}
+	// 原后端的浏览器简称
+	switch browser := env.vars["BROWSER"]; browser {
+	case "":
+	case "gc":
+		args = append(args, "-b", "chrome")
+	case "ff":
+		args = append(args, "-b", "firefox")
+	default:
+		args = append(args, "-b", browser)
+	}
+	if value := env.vars["RERUN"]; value != "" && value != "0" {
+		args = append(args, "-rr", value)
 	}
+	return args
+}
 
+// parseSeldomReport 汇总XML报告中各testsuite的用例数
+func parseSeldomReport(data []byte, report *seldomReport) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "testsuite" {
+			continue
+		}
+		for _, attr := range start.Attr {
+			switch attr.Name.Local {
+			case "tests":
+				report.Tests += atoi(attr.Value)
+			case "failures":
+				report.Failure += atoi(attr.Value)
+			case "errors":
+				report.Error += atoi(attr.Value)
+			case "skipped":
+				report.Skipped += atoi(attr.Value)
+			case "time":
+				value, _ := strconv.ParseFloat(attr.Value, 64)
+				report.RunTime += value
+			}
+		}
+	}
+	report.Passed = report.Tests - report.Failure - report.Error - report.Skipped
 	return nil
 }
 
+func atoi(value string) int {
+	n, _ := strconv.Atoi(value)
+	return n
+}
+
 // saveCaseResult 保存用例执行结果
-func (s *TaskService) saveCaseResult(taskID uint, result CaseExecutionResult) {
-	db := database.GetDB()
+func (s *TaskService) saveCaseResult(ctx context.Context, taskID uint, result CaseExecutionResult) {
+	db := database.WithContext(ctx)
 	// 保存用例执行结果到数据库
 	caseResult := models.CaseResult{
 		CaseID:     result.CaseID,
@@ -240,21 +640,35 @@ func (s *TaskService) saveCaseResult(taskID uint, result CaseExecutionResult) {
 		Report:     fmt.Sprintf("Status: %s, Duration: %v", result.Status, result.Duration),
 		RunTime:    result.Duration.Seconds(),
 	}
+	if report := result.seldom; report != nil {
+		caseResult.Tests = report.Tests
+		caseResult.Passed = report.Passed
+		caseResult.Failure = report.Failure
+		caseResult.Error = report.Error
+		caseResult.Skipped = report.Skipped
+		caseResult.SystemOut = report.Output
+		if report.Report != "" {
+			caseResult.Report = report.Report
+		}
+	}
 
 	if err := db.Create(&caseResult).Error; err != nil {
-		s.logger.LogError("SAVE_CASE_RESULT", fmt.Sprintf("保存用例结果失败: %v", err), map[string]interface{}{
-			"task_id": taskID,
-			"case_id": result.CaseID,
-		})
+		s.logger.Ctx(ctx).Error("保存用例结果失败",
+			"category", "SAVE_CASE_RESULT",
+			"case_id", result.CaseID,
+			"error", err,
+		)
 	}
 }
 
-// saveTaskReport 保存任务报告
-func (s *TaskService) saveTaskReport(taskID uint, result *TaskExecutionResult) {
-	db := database.GetDB()
+// saveTaskReport 保存任务在单个环境中的报告，返回报告ID
+func (s *TaskService) saveTaskReport(ctx context.Context, taskID uint, runID string, result *EnvExecutionResult) uint {
+	db := database.WithContext(ctx)
 	// 创建任务报告
 	report := models.TaskReport{
 		TaskID:  taskID,
+		RunID:   runID,
+		EnvName: result.EnvName,
 		Name:    fmt.Sprintf("Task %d Execution Report", taskID),
 		Report:  fmt.Sprintf("Task executed with status: %s", result.Status),
 		Passed:  result.Summary.PassedCases,
@@ -264,17 +678,25 @@ func (s *TaskService) saveTaskReport(taskID uint, result *TaskExecutionResult) {
 		Tests:   result.Summary.TotalCases,
 		RunTime: fmt.Sprintf("%.2fs", result.Duration.Seconds()),
 	}
+	if result.EnvID != 0 {
+		envID := result.EnvID
+		report.EnvID = &envID
+		report.Name += " - " + result.EnvName
+	}
 
 	if err := db.Create(&report).Error; err != nil {
-		s.logger.LogError("SAVE_TASK_REPORT", fmt.Sprintf("保存任务报告失败: %v", err), map[string]interface{}{
-			"task_id": taskID,
-		})
+		s.logger.Ctx(ctx).Error("保存任务报告失败",
+			"category", "SAVE_TASK_REPORT",
+			"env_id", result.EnvID,
+			"error", err,
+		)
 	}
+	return report.ID
 }
 
 // updateTaskStatus 更新任务状态
-func (s *TaskService) updateTaskStatus(task *models.TestTask, status, errorMsg string) {
-	db := database.GetDB()
+func (s *TaskService) updateTaskStatus(ctx context.Context, task *models.TestTask, status, errorMsg string) {
+	db := database.WithContext(ctx)
 	
 	// 将字符串状态转换为整数
 	var statusInt int
@@ -328,10 +750,7 @@ func (s *TaskService) GetTaskStatus(taskID uint) (string, error) {
 		return "", fmt.Errorf("任务不存在: %v", err)
 	}
 
-	utils.LogInfo("Getting task status", map[string]interface{}{
-		"task_id":   task.ID,
-		"task_name": task.Name,
-	})
+	s.logger.Debug("获取任务状态", "task_id", task.ID, "task_name", task.Name)
 
 	return fmt.Sprintf("%d", task.Status), nil
 }
@@ -357,9 +776,154 @@ func (s *TaskService) StopTask(taskID uint) error {
 		return fmt.Errorf("更新任务状态失败: %v", err)
 	}
 
-	s.logger.LogInfo("TASK_STOP", fmt.Sprintf("任务已停止: %d", taskID), map[string]interface{}{
-		"task_id": taskID,
+	s.logger.Info("任务已停止", "category", "TASK_STOP", "task_id", taskID)
+
+	return nil
+}
+// ExecuteCase 在指定环境中执行单个用例并保存执行结果，envID为0时不指定环境
+func (s *TaskService) ExecuteCase(ctx context.Context, caseID, envID uint) (*CaseExecutionResult, error) {
+	db := database.WithContext(ctx)
+
+	var testCase models.TestCase
+	if err := db.First(&testCase, caseID).Error; err != nil {
+		return nil, fmt.Errorf("用例不存在: %v", err)
+	}
+
+	var env *models.Env
+	if envID != 0 {
+		env = &models.Env{}
+		if err := db.Where("is_delete = ?", false).First(env, envID).Error; err != nil {
+			return nil, fmt.Errorf("环境不存在: %v", err)
+		}
+	}
+	variables, err := s.envVariables(env)
+	if err != nil {
+		return nil, err
+	}
+
+	db.Model(&testCase).Update("status", 1)
+	result := s.executeSingleCase(ctx, &testCase, variables)
+	s.saveCaseResult(ctx, 0, result)
+	db.Model(&testCase).Update("status", 2)
+	metrics.ObserveCase(testCase.ProjectID, result.Status)
+	metrics.ObserveRun(models.ExecutionKindCase, result.Status, result.Duration)
+
+	return &result, nil
+}
+
+// TaskCaseHashes 获取任务关联的用例hash，按关联顺序返回
+func (s *TaskService) TaskCaseHashes(taskID uint) []string {
+	var relevances []models.TaskCaseRelevance
+	database.GetDB().Where("task_id = ?", taskID).Order("id ASC").Find(&relevances)
+
+	hashes := make([]string, 0, len(relevances))
+	for _, relevance := range relevances {
+		hashes = append(hashes, relevance.CaseHash)
+	}
+	return hashes
+}
+
+// SetTaskCases 替换任务关联的用例，重复的hash只保留一个
+func (s *TaskService) SetTaskCases(taskID uint, hashes []string) error {
+	seen := make(map[string]bool, len(hashes))
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("task_id = ?", taskID).Delete(&models.TaskCaseRelevance{}).Error; err != nil {
+			return err
+		}
+		for _, hash := range hashes {
+			if hash == "" || seen[hash] {
+				continue
+			}
+			seen[hash] = true
+			if err := tx.Create(&models.TaskCaseRelevance{TaskID: taskID, CaseHash: hash}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// TaskEnvIDs 获取任务配置的执行环境ID，按配置顺序返回；没有关联记录时兼容旧数据使用EnvID
+func (s *TaskService) TaskEnvIDs(task *models.TestTask) []uint {
+	var relevances []models.TaskEnvRelevance
+	database.GetDB().Where("task_id = ?", task.ID).Order("id ASC").Find(&relevances)
+
+	envIDs := make([]uint, 0, len(relevances))
+	for _, relevance := range relevances {
+		envIDs = append(envIDs, relevance.EnvID)
+	}
+	if len(envIDs) == 0 && task.EnvID != nil && *task.EnvID != 0 {
+		envIDs = append(envIDs, *task.EnvID)
+	}
+	return envIDs
+}
+
+// LoadTaskEnvs 填充任务列表的执行环境ID
+func (s *TaskService) LoadTaskEnvs(tasks []models.TestTask) {
+	for i := range tasks {
+		tasks[i].EnvIDs = s.TaskEnvIDs(&tasks[i])
+	}
+}
+
+// ValidateTaskEnvs 校验环境是否存在且可被项目使用（全局环境或项目自身的环境）
+func (s *TaskService) ValidateTaskEnvs(projectID uint, envIDs []uint) error {
+	envIDs = uniqueIDs(envIDs)
+	if len(envIDs) == 0 {
+		return nil
+	}
+
+	var count int
+	database.GetDB().Model(&models.Env{}).
+		Where("id IN (?) AND is_delete = ? AND (project_id IS NULL OR project_id = ?)", envIDs, false, projectID).
+		Count(&count)
+	if count != len(envIDs) {
+		return &ValidationError{Message: "Environment not found or not available to the project"}
+	}
+	return nil
+}
+
+// SetTaskEnvs 校验并替换任务的执行环境，EnvID同步为第一个环境
+func (s *TaskService) SetTaskEnvs(task *models.TestTask, envIDs []uint) error {
+	envIDs = uniqueIDs(envIDs)
+	if err := s.ValidateTaskEnvs(task.ProjectID, envIDs); err != nil {
+		return err
+	}
+
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("task_id = ?", task.ID).Delete(&models.TaskEnvRelevance{}).Error; err != nil {
+			return err
+		}
+		for _, envID := range envIDs {
+			if err := tx.Create(&models.TaskEnvRelevance{TaskID: task.ID, EnvID: envID}).Error; err != nil {
+				return err
+			}
+		}
+
+		var envID *uint
+		if len(envIDs) > 0 {
+			first := envIDs[0]
+			envID = &first
+		}
+		return tx.Model(task).Update("env_id", envID).Error
 	})
+	if err != nil {
+		return err
+	}
 
+	task.EnvIDs = envIDs
 	return nil
-}
\ No newline at end of file
+}
+
+// uniqueIDs 去除重复和为0的ID，保持原有顺序
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return result
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"seldom-platform/database"
+	"seldom-platform/models"
+	"seldom-platform/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// fakeSeldom 在PATH中放入一个seldom脚本：记录命令行参数和环境变量，输出密钥值，
+// 并按 -r 参数在 reports 目录中写入一个失败用例的XML报告
+const fakeSeldom = `#!/bin/sh
+echo "$*" > "$SELDOM_TEST_OUT/args"
+env > "$SELDOM_TEST_OUT/env"
+echo "login with $PASSWORD"
+report=""
+while [ $# -gt 0 ]; do
+	if [ "$1" = "-r" ]; then report="$2"; fi
+	shift
+done
+mkdir -p reports
+cat > "reports/$report" <<XML
+<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+	<testsuite name="LoginTest" tests="2" errors="0" failures="1" skipped="0" time="1.5">
+		<testcase name="test_login"><failure>password $PASSWORD rejected</failure></testcase>
+		<testcase name="test_logout"/>
+	</testsuite>
+</testsuites>
+XML
+exit 1
+`
+
+// installFakeSeldom 安装fakeSeldom，返回记录参数和环境变量的目录
+func installFakeSeldom(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake seldom is a shell script")
+	}
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "seldom"), []byte(fakeSeldom), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	out := t.TempDir()
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("SELDOM_TEST_OUT", out)
+	return out
+}
+
+// createTestCase 创建项目和用例，mkdir为true时在工作目录中创建项目的用例目录
+func createTestCase(t *testing.T, workspace string, mkdir bool) models.TestCase {
+	t.Helper()
+	db := database.GetDB()
+	project := models.Project{Name: "demo", Address: "https://github.com/seldomQA/demo-tests.git", CaseDir: "test_dir"}
+	if err := db.Create(&project).Error; err != nil {
+		t.Fatal(err)
+	}
+	if mkdir {
+		if err := os.MkdirAll(filepath.Join(workspace, "demo", "demo-tests", "test_dir"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	testCase := models.TestCase{
+		ProjectID: project.ID,
+		FileName:  "test_login",
+		ClassName: "LoginTest",
+		CaseName:  "test_login",
+		CaseHash:  "hash-login",
+	}
+	if err := db.Create(&testCase).Error; err != nil {
+		t.Fatal(err)
+	}
+	return testCase
+}
+
+func TestExecuteCaseRunsSeldomWithEnvVariables(t *testing.T) {
+	out := installFakeSeldom(t)
+	cfg := newTestConfig(t)
+	cfg.Run.Workspace = t.TempDir()
+	setupTestDB(t, cfg)
+	testCase := createTestCase(t, cfg.Run.Workspace, true)
+
+	env := models.Env{Name: "staging", Env: "staging", BaseURL: "http://staging.example.com", Browser: "gc"}
+	if err := database.GetDB().Create(&env).Error; err != nil {
+		t.Fatal(err)
+	}
+	_, _, err := NewEnvVariableService(cfg).SetVariables(env.ID, []EnvVariableInput{
+		{Name: "USERNAME", Value: "alice"},
+		{Name: "PASSWORD", Value: "s3cr3t-value", IsSecret: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewTaskService(cfg).ExecuteCase(context.Background(), testCase.ID, env.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != "failed" {
+		t.Errorf("status = %q, want failed", result.Status)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(out, "args"))
+	for _, want := range []string{"-p test_dir", "-e staging", "-u http://staging.example.com", "-b chrome", ".xml"} {
+		if !strings.Contains(string(args), want) {
+			t.Errorf("args %q missing %q", args, want)
+		}
+	}
+	environ, _ := os.ReadFile(filepath.Join(out, "env"))
+	for _, want := range []string{"USERNAME=alice", "PASSWORD=s3cr3t-value", "BASE_URL=http://staging.example.com"} {
+		if !strings.Contains(string(environ), want) {
+			t.Errorf("seldom environment missing %q", want)
+		}
+	}
+
+	var saved models.CaseResult
+	if err := database.GetDB().Where("case_id = ?", testCase.ID).First(&saved).Error; err != nil {
+		t.Fatal(err)
+	}
+	if saved.Tests != 2 || saved.Passed != 1 || saved.Failure != 1 {
+		t.Errorf("saved counts tests=%d passed=%d failure=%d, want 2/1/1", saved.Tests, saved.Passed, saved.Failure)
+	}
+	for name, text := range map[string]string{"system_out": saved.SystemOut, "report": saved.Report, "error": result.ErrorMsg} {
+		if strings.Contains(text, "s3cr3t-value") {
+			t.Errorf("%s leaks the secret: %q", name, text)
+		}
+	}
+	if !strings.Contains(saved.SystemOut, "login with "+SecretMask) {
+		t.Errorf("system_out = %q, want the secret masked", saved.SystemOut)
+	}
+	if !strings.Contains(saved.Report, "<testsuite") {
+		t.Errorf("report = %q, want the XML report", saved.Report)
+	}
+}
+
+func TestExecuteTaskTracesSeldomRun(t *testing.T) {
+	out := installFakeSeldom(t)
+	cfg := newTestConfig(t)
+	cfg.Run.Workspace = t.TempDir()
+	setupTestDB(t, cfg)
+	testCase := createTestCase(t, cfg.Run.Workspace, true)
+
+	previous, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.Install(exporter)
+	t.Cleanup(func() {
+		provider.Shutdown(context.Background())
+		otel.SetTracerProvider(previous)
+		otel.SetTextMapPropagator(propagator)
+	})
+
+	db := database.GetDB()
+	env := models.Env{Name: "staging", Env: "staging"}
+	task := models.TestTask{ProjectID: testCase.ProjectID, Name: "nightly"}
+	for _, record := range []interface{}{&env, &task} {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, record := range []interface{}{
+		&models.TaskCaseRelevance{TaskID: task.ID, CaseHash: testCase.CaseHash},
+		&models.TaskEnvRelevance{TaskID: task.ID, EnvID: env.ID},
+	} {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := NewTaskService(cfg).ExecuteTask(context.Background(), task.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := make(map[string]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+	chain := []string{"ExecuteTask", "ExecuteEnv", "ExecuteCase", "RunSeldom"}
+	for i, name := range chain {
+		span, ok := spans[name]
+		if !ok {
+			t.Fatalf("span %s was not recorded, got %v", name, exporter.GetSpans().Snapshots())
+		}
+		if i > 0 && span.Parent.SpanID() != spans[chain[i-1]].SpanContext.SpanID() {
+			t.Errorf("span %s is not a child of %s", name, chain[i-1])
+		}
+	}
+
+	// seldom进程收到RunSeldom的追踪上下文
+	run := spans["RunSeldom"].SpanContext
+	traceparent := "TRACEPARENT=00-" + run.TraceID().String() + "-" + run.SpanID().String() + "-01"
+	environ, _ := os.ReadFile(filepath.Join(out, "env"))
+	if !strings.Contains(string(environ), traceparent) {
+		t.Errorf("seldom environment missing %q", traceparent)
+	}
+}
+
+func TestExecuteCaseFailsWithoutCaseDir(t *testing.T) {
+	installFakeSeldom(t)
+	cfg := newTestConfig(t)
+	cfg.Run.Workspace = t.TempDir()
+	setupTestDB(t, cfg)
+	testCase := createTestCase(t, cfg.Run.Workspace, false)
+
+	result, err := NewTaskService(cfg).ExecuteCase(context.Background(), testCase.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != "failed" || !strings.Contains(result.ErrorMsg, "用例目录不存在") {
+		t.Errorf("result = %q %q, want failed with missing case dir", result.Status, result.ErrorMsg)
+	}
+}
+
+func TestParseSeldomReportSumsSuites(t *testing.T) {
+	var report seldomReport
+	err := parseSeldomReport([]byte(`<testsuites>
+		<testsuite tests="3" errors="1" failures="0" skipped="1" time="0.25"/>
+		<testsuite tests="2" errors="0" failures="1" skipped="0" time="0.5"/>
+	</testsuites>`), &report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Tests != 5 || report.Error != 1 || report.Failure != 1 || report.Skipped != 1 || report.Passed != 2 || report.RunTime != 0.75 {
+		t.Errorf("report = %+v", report)
+	}
+}
+
+func TestSeldomArgsMapsEnv(t *testing.T) {
+	env := &runEnv{vars: map[string]string{"ENV": "prod", "BROWSER": "ff", "RERUN": "2", "BASE_URL": ""}}
+	got := strings.Join(seldomArgs("test_dir", "/tmp/cases.json", "r.xml", env), " ")
+	want := "-p test_dir -j /tmp/cases.json -r r.xml -e prod -b firefox -rr 2"
+	if got != want {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,378 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"seldom-platform/database"
+	"seldom-platform/models"
+	"seldom-platform/utils"
+)
+
+// 回收站相关错误
+var (
+	ErrParentDeleted = errors.New("parent resource is deleted")
+	ErrNameConflict  = errors.New("name already exists")
+)
+
+// 回收站中的资源类型，与审计日志的资源类型一致
+var trashResources = map[string]bool{
+	models.AuditResourceProject: true,
+	models.AuditResourceEnv:     true,
+	models.AuditResourceTask:    true,
+	models.AuditResourceTeam:    true,
+}
+
+// IsTrashResource 资源类型是否支持回收站
+func IsTrashResource(resourceType string) bool {
+	return trashResources[resourceType]
+}
+
+// TrashService 回收站服务
+//
+// 项目、环境、任务和团队删除时只标记is_delete并记录删除时间，可以从回收站恢复；
+// 删除项目会同时删除其下的任务和环境，任务从调度器中移除，恢复项目时一并恢复。
+// 超过保留期的资源由Purge彻底删除。
+type TrashService struct {
+	logger       *utils.Logger
+	auditService *AuditService
+}
+
+// NewTrashService 创建回收站服务实例
+func NewTrashService() *TrashService {
+	return &TrashService{
+		logger:       utils.GetLogger(),
+		auditService: NewAuditService(),
+	}
+}
+
+// DeleteProject 将项目及其任务和环境移入回收站
+func (s *TrashService) DeleteProject(project *models.Project) error {
+	now := time.Now()
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(project).Updates(map[string]interface{}{"is_delete": true, "delete_time": now}).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.TestTask{}, &models.Env{}} {
+			if err := tx.Model(model).Where("project_id = ? AND is_delete = ?", project.ID, false).
+				Updates(map[string]interface{}{"is_delete": true, "delete_time": now}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.reloadScheduler()
+	return nil
+}
+
+// RestoreProject 从回收站恢复项目，以及随项目一起删除的任务和环境
+func (s *TrashService) RestoreProject(project *models.Project) error {
+	if exists(&models.Project{}, "name = ? AND is_delete = ? AND id <> ?", project.Name, false, project.ID) {
+		return ErrNameConflict
+	}
+
+	deleteTime := project.DeleteTime
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(project).Updates(map[string]interface{}{"is_delete": false, "delete_time": nil}).Error; err != nil {
+			return err
+		}
+
+		// 删除项目之后不能再单独删除任务和环境，删除时间不早于项目的都是随项目删除的
+		for _, model := range []interface{}{&models.TestTask{}, &models.Env{}} {
+			query := tx.Model(model).Where("project_id = ? AND is_delete = ?", project.ID, true)
+			if deleteTime != nil {
+				query = query.Where("delete_time >= ?", *deleteTime)
+			}
+			if err := query.Updates(map[string]interface{}{"is_delete": false, "delete_time": nil}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.reloadScheduler()
+	return nil
+}
+
+// DeleteTask 将任务移入回收站
+func (s *TrashService) DeleteTask(task *models.TestTask) error {
+	if err := markDeleted(task); err != nil {
+		return err
+	}
+
+	s.reloadScheduler()
+	return nil
+}
+
+// RestoreTask 从回收站恢复任务，所属项目仍在回收站中时返回ErrParentDeleted
+func (s *TrashService) RestoreTask(task *models.TestTask) error {
+	if !exists(&models.Project{}, "id = ? AND is_delete = ?", task.ProjectID, false) {
+		return ErrParentDeleted
+	}
+	if err := markRestored(task); err != nil {
+		return err
+	}
+
+	s.reloadScheduler()
+	return nil
+}
+
+// DeleteEnv 将环境移入回收站
+func (s *TrashService) DeleteEnv(env *models.Env) error {
+	return markDeleted(env)
+}
+
+// RestoreEnv 从回收站恢复环境，所属项目在回收站中时返回ErrParentDeleted，
+// 同一项目（或全局）内已有同名环境时返回ErrNameConflict
+func (s *TrashService) RestoreEnv(env *models.Env) error {
+	query := "name = ? AND is_delete = ? AND id <> ? AND project_id IS NULL"
+	args := []interface{}{env.Name, false, env.ID}
+	if env.ProjectID != nil {
+		if !exists(&models.Project{}, "id = ? AND is_delete = ?", *env.ProjectID, false) {
+			return ErrParentDeleted
+		}
+		query = "name = ? AND is_delete = ? AND id <> ? AND project_id = ?"
+		args = append(args, *env.ProjectID)
+	}
+	if exists(&models.Env{}, query, args...) {
+		return ErrNameConflict
+	}
+	return markRestored(env)
+}
+
+// DeleteTeam 将团队移入回收站
+func (s *TrashService) DeleteTeam(team *models.Team) error {
+	return markDeleted(team)
+}
+
+// RestoreTeam 从回收站恢复团队，已有同名团队时返回ErrNameConflict
+func (s *TrashService) RestoreTeam(team *models.Team) error {
+	if exists(&models.Team{}, "name = ? AND is_delete = ? AND id <> ?", team.Name, false, team.ID) {
+		return ErrNameConflict
+	}
+	return markRestored(team)
+}
+
+// Purge 彻底删除在回收站中超过保留期的资源，返回删除的资源数量
+//
+// 没有删除时间的历史数据按更新时间计算。
+func (s *TrashService) Purge(retention time.Duration) (int, error) {
+	cutoff := time.Now().Add(-retention)
+	expired := func(query *gorm.DB) *gorm.DB {
+		return query.Where("is_delete = ? AND COALESCE(delete_time, update_time) < ?", true, cutoff)
+	}
+	db := database.GetDB()
+	purged := 0
+
+	// 先清理项目，项目下的任务随项目一起删除
+	var projects []models.Project
+	if err := expired(db).Find(&projects).Error; err != nil {
+		return purged, err
+	}
+	for i := range projects {
+		if err := db.Transaction(func(tx *gorm.DB) error { return purgeProject(tx, projects[i].ID) }); err != nil {
+			return purged, err
+		}
+		s.recordPurge(models.AuditResourceProject, projects[i].ID, projects[i])
+		purged++
+	}
+
+	var tasks []models.TestTask
+	if err := expired(db).Find(&tasks).Error; err != nil {
+		return purged, err
+	}
+	for i := range tasks {
+		if err := db.Transaction(func(tx *gorm.DB) error { return purgeTasks(tx, []uint{tasks[i].ID}) }); err != nil {
+			return purged, err
+		}
+		s.recordPurge(models.AuditResourceTask, tasks[i].ID, tasks[i])
+		purged++
+	}
+
+	var teams []models.Team
+	if err := expired(db).Find(&teams).Error; err != nil {
+		return purged, err
+	}
+	for i := range teams {
+		if err := db.Transaction(func(tx *gorm.DB) error { return purgeTeam(tx, teams[i].ID) }); err != nil {
+			return purged, err
+		}
+		s.recordPurge(models.AuditResourceTeam, teams[i].ID, teams[i])
+		purged++
+	}
+
+	var envs []models.Env
+	if err := expired(db).Find(&envs).Error; err != nil {
+		return purged, err
+	}
+	for i := range envs {
+		if err := db.Transaction(func(tx *gorm.DB) error { return purgeEnv(tx, envs[i].ID) }); err != nil {
+			return purged, err
+		}
+		s.recordPurge(models.AuditResourceEnv, envs[i].ID, envs[i])
+		purged++
+	}
+
+	return purged, nil
+}
+
+// StartPurge 按间隔定期清理回收站，retentionDays为0时不启动，返回用于停止的函数
+func (s *TrashService) StartPurge(retentionDays, intervalMinutes int) func() {
+	if retentionDays <= 0 {
+		return func() {}
+	}
+	if intervalMinutes <= 0 {
+		intervalMinutes = 60
+	}
+
+	retention := time.Duration(retentionDays) * 24 * time.Hour
+	ticker := time.NewTicker(time.Duration(intervalMinutes) * time.Minute)
+	done := make(chan struct{})
+
+	purge := func() {
+		purged, err := s.Purge(retention)
+		if err != nil {
+			s.logger.Error("清理回收站失败", "category", "TRASH", "error", err.Error())
+			return
+		}
+		if purged > 0 {
+			s.logger.Info("已清理回收站中过期的资源", "category", "TRASH", "count", purged)
+		}
+	}
+
+	go func() {
+		purge()
+		for {
+			select {
+			case <-ticker.C:
+				purge()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
+
+// recordPurge 记录清理操作的审计日志，操作人为系统
+func (s *TrashService) recordPurge(resourceType string, resourceID uint, before interface{}) {
+	s.auditService.Record(AuditEntry{
+		ActorName:    "system",
+		Action:       models.AuditActionPurge,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+	}, before, nil)
+}
+
+// reloadScheduler 重新加载定时任务，移除已删除的任务或加入恢复的任务
+func (s *TrashService) reloadScheduler() {
+	if GlobalScheduler == nil {
+		return
+	}
+	if err := GlobalScheduler.Reload(); err != nil {
+		s.logger.Error("重新加载定时任务失败", "category", "TRASH", "error", err.Error())
+	}
+}
+
+// markDeleted 标记资源为已删除并记录删除时间
+func markDeleted(value interface{}) error {
+	return database.GetDB().Model(value).Updates(map[string]interface{}{"is_delete": true, "delete_time": time.Now()}).Error
+}
+
+// markRestored 取消资源的删除标记
+func markRestored(value interface{}) error {
+	return database.GetDB().Model(value).Updates(map[string]interface{}{"is_delete": false, "delete_time": nil}).Error
+}
+
+// exists 检查是否存在满足条件的记录
+func exists(model interface{}, query string, args ...interface{}) bool {
+	var count int
+	database.GetDB().Model(model).Where(query, args...).Count(&count)
+	return count > 0
+}
+
+// purgeProject 彻底删除项目及其任务、环境、用例和成员
+func purgeProject(tx *gorm.DB, projectID uint) error {
+	var taskIDs []uint
+	if err := tx.Model(&models.TestTask{}).Where("project_id = ?", projectID).Pluck("id", &taskIDs).Error; err != nil {
+		return err
+	}
+	if err := purgeTasks(tx, taskIDs); err != nil {
+		return err
+	}
+
+	var envIDs []uint
+	if err := tx.Model(&models.Env{}).Where("project_id = ?", projectID).Pluck("id", &envIDs).Error; err != nil {
+		return err
+	}
+	for _, envID := range envIDs {
+		if err := purgeEnv(tx, envID); err != nil {
+			return err
+		}
+	}
+
+	caseIDs := tx.Model(&models.TestCase{}).Select("id").Where("project_id = ?", projectID).SubQuery()
+	if err := tx.Where("case_id IN ?", caseIDs).Delete(&models.CaseResult{}).Error; err != nil {
+		return err
+	}
+	for _, model := range []interface{}{&models.TestCase{}, &models.TestCaseTemp{}, &models.ProjectMember{}} {
+		if err := tx.Where("project_id = ?", projectID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Where("id = ?", projectID).Delete(&models.Project{}).Error
+}
+
+// purgeTasks 彻底删除任务及其报告和用例关联
+func purgeTasks(tx *gorm.DB, taskIDs []uint) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+
+	reportIDs := tx.Model(&models.TaskReport{}).Select("id").Where("task_id IN (?)", taskIDs).SubQuery()
+	if err := tx.Where("result_id IN ?", reportIDs).Delete(&models.ReportDetails{}).Error; err != nil {
+		return err
+	}
+	for _, model := range []interface{}{&models.TaskReport{}, &models.TaskCaseRelevance{}, &models.TaskEnvRelevance{}} {
+		if err := tx.Where("task_id IN (?)", taskIDs).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Where("id IN (?)", taskIDs).Delete(&models.TestTask{}).Error
+}
+
+// purgeTeam 彻底删除团队及其成员，任务不再关联该团队
+func purgeTeam(tx *gorm.DB, teamID uint) error {
+	if err := tx.Model(&models.TestTask{}).Where("team_id = ?", teamID).UpdateColumn("team_id", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("team_id = ?", teamID).Delete(&models.TeamMember{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("id = ?", teamID).Delete(&models.Team{}).Error
+}
+
+// purgeEnv 彻底删除环境及其变量，任务不再关联该环境
+func purgeEnv(tx *gorm.DB, envID uint) error {
+	if err := tx.Model(&models.TestTask{}).Where("env_id = ?", envID).UpdateColumn("env_id", nil).Error; err != nil {
+		return err
+	}
+	for _, model := range []interface{}{&models.TaskEnvRelevance{}, &models.EnvVariable{}} {
+		if err := tx.Where("env_id = ?", envID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Where("id = ?", envID).Delete(&models.Env{}).Error
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"seldom-platform/config"
+	"seldom-platform/database"
+	"seldom-platform/models"
+	"seldom-platform/utils"
+)
+
+// ValidationError 用户输入校验错误
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// 用户管理相关错误
+var (
+	ErrInvalidUsername = &ValidationError{"Username must be 3-150 characters of letters, digits, underscores or hyphens"}
+	ErrInvalidEmail    = &ValidationError{"Invalid email"}
+	ErrUsernameExists  = &ValidationError{"Username already exists"}
+)
+
+// CreateUserInput 创建用户参数
+type CreateUserInput struct {
+	Username    string
+	Password    string
+	Email       string
+	FirstName   string
+	LastName    string
+	IsStaff     bool
+	IsSuperuser bool
+}
+
+// UserService 用户管理服务，供管理接口和命令行使用
+type UserService struct {
+	logger      *utils.Logger
+	authService *AuthService
+}
+
+// NewUserService 创建用户管理服务实例
+func NewUserService(cfg *config.Config) *UserService {
+	return &UserService{
+		logger:      utils.GetLogger(),
+		authService: NewAuthService(cfg),
+	}
+}
+
+// CreateUser 校验用户名、邮箱和密码策略后创建已激活的用户
+func (s *UserService) CreateUser(input CreateUserInput) (*models.User, error) {
+	input.Username = strings.TrimSpace(input.Username)
+	if !utils.IsValidUsername(input.Username) {
+		return nil, ErrInvalidUsername
+	}
+	if !utils.IsValidEmail(input.Email) {
+		return nil, ErrInvalidEmail
+	}
+	if err := s.authService.ValidatePassword(input.Password, input.Username); err != nil {
+		return nil, &ValidationError{err.Error()}
+	}
+
+	db := database.GetDB()
+
+	var count int
+	db.Model(&models.User{}).Where("username = ?", input.Username).Count(&count)
+	if count > 0 {
+		return nil, ErrUsernameExists
+	}
+
+	user := models.User{
+		Username:    input.Username,
+		Email:       input.Email,
+		FirstName:   input.FirstName,
+		LastName:    input.LastName,
+		IsStaff:     input.IsStaff || input.IsSuperuser,
+		IsSuperuser: input.IsSuperuser,
+		IsActive:    true,
+	}
+	if err := user.SetPassword(input.Password); err != nil {
+		return nil, err
+	}
+	if err := db.Create(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SetActive 启用或禁用用户，禁用后用户的所有会话立即失效；
+// 同时递增邮箱验证令牌版本，此前发出的验证链接不能再激活账号
+func (s *UserService) SetActive(user *models.User, active bool) error {
+	err := database.GetDB().Model(user).
+		Updates(map[string]interface{}{"is_active": active, "verify_version": gorm.Expr("verify_version + 1")}).Error
+	if err != nil {
+		return err
+	}
+	if !active {
+		return s.authService.RevokeUserSessions(user.ID, "")
+	}
+	return nil
+}
+
+// SetStaff 设置或取消用户的员工身份
+func (s *UserService) SetStaff(user *models.User, staff bool) error {
+	return database.GetDB().Model(user).Update("is_staff", staff).Error
+}
+
+// ResetPassword 管理员重置用户密码，并吊销该用户的所有会话
+func (s *UserService) ResetPassword(user *models.User, password string) error {
+	if err := s.authService.ValidatePassword(password, user.Username); err != nil {
+		return &ValidationError{err.Error()}
+	}
+	return s.authService.ChangePassword(user, password, "")
+}
+
+// ForceLogout 吊销用户的所有会话
+func (s *UserService) ForceLogout(user *models.User) error {
+	return s.authService.RevokeUserSessions(user.ID, "")
+}
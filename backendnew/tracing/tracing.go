@@ -0,0 +1,90 @@
+// Package tracing 配置OpenTelemetry链路追踪，span通过OTLP导出
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"seldom-platform/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "seldom-platform"
+
+// Setup 按配置初始化全局TracerProvider，返回用于退出时导出剩余span的函数。
+// 未开启时只设置传播器，span不会被记录
+func Setup(cfg config.TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	// 导出地址、请求头等读取OTEL_EXPORTER_OTLP_*环境变量，默认 http://localhost:4318
+	exporter, err := otlptracehttp.New(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.New(context.Background(),
+		resource.WithAttributes(attribute.String("service.name", cfg.ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Install 使用指定的exporter同步导出所有span，用于测试，如配合tracetest.NewInMemoryExporter
+func Install(exporter sdktrace.SpanExporter) *sdktrace.TracerProvider {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	otel.SetTracerProvider(provider)
+	return provider
+}
+
+// Tracer 返回平台使用的Tracer
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// TraceID 返回上下文中span的trace ID，没有span时返回空字符串
+func TraceID(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
+
+// Environ 返回传给子进程的追踪上下文环境变量，如TRACEPARENT=00-...，没有span时返回空
+func Environ(ctx context.Context) []string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	env := make([]string, 0, len(carrier))
+	for _, key := range []string{"traceparent", "tracestate", "baggage"} {
+		if value := carrier.Get(key); value != "" {
+			env = append(env, strings.ToUpper(key)+"="+value)
+		}
+	}
+	return env
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DjangoError Django兼容接口的错误码，与原后端 app_utils/response.py 保持一致
+type DjangoError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// WithMessage 返回使用指定错误信息的错误码副本
+func (e DjangoError) WithMessage(message string) DjangoError {
+	e.Message = message
+	return e
+}
+
+// Django兼容接口错误码
+var (
+	DjangoErrSuccess           = DjangoError{20000, ""}
+	DjangoErrSystem            = DjangoError{50000, "系统错误"}
+	DjangoErrInvalidToken      = DjangoError{40001, "Token无效或已过期"}
+	DjangoErrPermissionDenied  = DjangoError{40002, "没有操作权限"}
+	DjangoErrParamsType        = DjangoError{30002, "参数类型错误"}
+	DjangoErrJSONType          = DjangoError{30003, "JSON格式错误"}
+	DjangoErrUserOrPawdNull    = DjangoError{10010, "用户名密码为空"}
+	DjangoErrUserOrPawdError   = DjangoError{10011, "用户名密码错误"}
+	DjangoErrPawdError         = DjangoError{10012, "两次密码不一致"}
+	DjangoErrUserHasRegistered = DjangoError{10013, "用户已注册"}
+	DjangoErrRegisterRestrict  = DjangoError{40012, "未开放注册, 联系作者获取体验账号"}
+	DjangoErrProjectIDNull     = DjangoError{10020, "项目id不存在"}
+	DjangoErrProjectObjectNull = DjangoError{10021, "通过id查询项目不存在"}
+	DjangoErrProjectDelete     = DjangoError{10023, "项目删除失败"}
+	DjangoErrTeamEmail         = DjangoError{10041, "邮箱格式错误"}
+	DjangoErrTeamExist         = DjangoError{10042, "创建团队已经存在"}
+	DjangoErrCaseRunning       = DjangoError{20044, "用例正在执行中"}
+	DjangoErrEnvIsNull         = DjangoError{10041, "查询环境为空"}
+	DjangoErrEnvInUse          = DjangoError{10042, "此环境被任务使用"}
+	DjangoErrTaskIDNull        = DjangoError{10051, "任务ID不能为空"}
+	DjangoErrTaskRunning       = DjangoError{10052, "任务正在运行中"}
+	DjangoErrTimedAdd          = DjangoError{10053, "定时任务添加失败"}
+	DjangoErrTimedUpdate       = DjangoError{10054, "定时任务开关失败"}
+	DjangoErrTimedDelete       = DjangoError{10055, "定时任务删除失败"}
+	DjangoErrTimedTask         = DjangoError{10056, "定时任务服务报错"}
+)
+
+// DjangoResponse Django兼容接口的统一响应结构
+type DjangoResponse struct {
+	Success bool        `json:"success"`
+	Error   DjangoError `json:"error"`
+	Result  interface{} `json:"result"`
+}
+
+// DjangoPageCode Django兼容接口分页响应中的状态字段
+type DjangoPageCode struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// DjangoPageResponse Django兼容接口的分页响应结构
+type DjangoPageResponse struct {
+	Success bool           `json:"success"`
+	Code    DjangoPageCode `json:"code"`
+	Total   int64          `json:"total"`
+	Page    int            `json:"page"`
+	Size    int            `json:"size"`
+	Result  interface{}    `json:"result"`
+}
+
+// DjangoSuccess Django兼容接口成功响应，result为nil时返回空对象
+func DjangoSuccess(c *gin.Context, result interface{}) {
+	if result == nil {
+		result = gin.H{}
+	}
+	c.JSON(http.StatusOK, DjangoResponse{
+		Success: true,
+		Error:   DjangoErrSuccess,
+		Result:  result,
+	})
+}
+
+// DjangoFail Django兼容接口错误响应，与原后端一致使用200状态码
+func DjangoFail(c *gin.Context, err DjangoError) {
+	c.JSON(http.StatusOK, DjangoResponse{
+		Success: false,
+		Error:   err,
+		Result:  gin.H{},
+	})
+}
+
+// DjangoUnauthorized Django兼容接口认证失败响应，前端收到401后跳转登录页
+func DjangoUnauthorized(c *gin.Context) {
+	c.JSON(http.StatusUnauthorized, DjangoResponse{
+		Success: false,
+		Error:   DjangoErrInvalidToken,
+		Result:  gin.H{},
+	})
+}
+
+// DjangoPage Django兼容接口分页响应，result为nil时返回空列表
+func DjangoPage(c *gin.Context, result interface{}, total int64, page, size int) {
+	if result == nil {
+		result = []interface{}{}
+	}
+	c.JSON(http.StatusOK, DjangoPageResponse{
+		Success: true,
+		Total:   total,
+		Page:    page,
+		Size:    size,
+		Result:  result,
+	})
+}
+
+// DjangoTime 按原后端model_to_dict的格式输出时间
+func DjangoTime(t time.Time) string {
+	return FormatTime(t, DateTimeFormat)
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -8,32 +10,49 @@ import (
 
 // JWTClaims JWT声明结构
 type JWTClaims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+	SessionID string `json:"sid,omitempty"` // 会话ID，对应refresh token族
 	jwt.RegisteredClaims
 }
 
-// GenerateJWT 生成JWT token
-func GenerateJWT(userID uint, username, secret string, expireHours int) (string, error) {
-	claims := JWTClaims{
-		UserID:   userID,
-		Username: username,
+// GenerateJWT 生成JWT access token，每个token带有唯一的jti用于吊销
+func GenerateJWT(userID uint, username, sessionID, secret string, expire time.Duration) (string, *JWTClaims, error) {
+	jti, err := GenerateTokenID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	claims := &JWTClaims{
+		UserID:    userID,
+		Username:  username,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expireHours) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(now.Add(expire)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    "seldom-platform",
 			Subject:   username,
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	signed, err := token.SignedString([]byte(secret))
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
 }
 
 // ParseJWT 解析JWT token
 func ParseJWT(tokenString, secret string) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
+		// 验证签名方法
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
 		return []byte(secret), nil
 	})
 
@@ -46,4 +65,13 @@ func ParseJWT(tokenString, secret string) (*JWTClaims, error) {
 	}
 
 	return nil, jwt.ErrTokenInvalidClaims
-}
\ No newline at end of file
+}
+
+// GenerateTokenID 生成32字节的随机十六进制串，用于jti、会话ID和refresh token
+func GenerateTokenID() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
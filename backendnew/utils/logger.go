@@ -1,54 +1,131 @@
 package utils
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"seldom-platform/config"
+
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-// Logger 日志记录器
+// Logger 结构化日志记录器，按级别输出console或JSON格式的日志，With附加的字段写入每条日志。
+// 日志统一交给InitLogger配置的输出，InitLogger之前创建的Logger同样生效
 type Logger struct {
-	infoLogger  *log.Logger
-	errorLogger *log.Logger
-	debugLogger *log.Logger
+	attrs []any
 }
 
-var logger *Logger
+var (
+	root    atomic.Pointer[slog.Logger]
+	logger  = &Logger{}
+	logMu   sync.Mutex
+	logFile *lumberjack.Logger
+	logStop chan struct{}
+)
+
+func init() {
+	root.Store(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+}
 
-// InitLogger 初始化日志记录器
-func InitLogger() error {
-	// 创建logs目录
-	logDir := "logs"
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		return err
+// InitLogger 按配置初始化日志输出：同时输出到控制台和日志目录下的seldom.log，
+// 日志文件超过MaxSize或跨天时滚动，滚动后的文件按MaxAge和MaxBackups清理
+func InitLogger(cfg config.LogConfig) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return fmt.Errorf("无效的日志级别: %s", cfg.Level)
 	}
 
-	// 创建日志文件
-	today := time.Now().Format("2006-01-02")
-	infoFile, err := os.OpenFile(filepath.Join(logDir, fmt.Sprintf("info_%s.log", today)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		return err
+	logMu.Lock()
+	defer logMu.Unlock()
+	closeLogFile()
+
+	var out io.Writer = os.Stdout
+	if cfg.Dir != "" {
+		if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+			return err
+		}
+		logFile = &lumberjack.Logger{
+			Filename:   filepath.Join(cfg.Dir, "seldom.log"),
+			MaxSize:    cfg.MaxSize,
+			MaxAge:     cfg.MaxAge,
+			MaxBackups: cfg.MaxBackups,
+			LocalTime:  true,
+			Compress:   cfg.Compress,
+		}
+		logStop = make(chan struct{})
+		go rotateDaily(logFile, logStop)
+		out = io.MultiWriter(os.Stdout, logFile)
 	}
 
-	errorFile, err := os.OpenFile(filepath.Join(logDir, fmt.Sprintf("error_%s.log", today)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		return err
+	opts := &slog.HandlerOptions{Level: level, AddSource: true, ReplaceAttr: shortSource}
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "console":
+		handler = slog.NewTextHandler(out, opts)
+	case "json":
+		handler = slog.NewJSONHandler(out, opts)
+	default:
+		return fmt.Errorf("无效的日志格式: %s", cfg.Format)
 	}
 
-	debugFile, err := os.OpenFile(filepath.Join(logDir, fmt.Sprintf("debug_%s.log", today)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		return err
+	l := slog.New(handler)
+	root.Store(l)
+	// 标准库log的输出同样写入结构化日志
+	slog.SetDefault(l)
+	return nil
+}
+
+// CloseLogger 关闭日志文件，之后的日志只输出到控制台
+func CloseLogger() {
+	logMu.Lock()
+	defer logMu.Unlock()
+	root.Store(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	closeLogFile()
+}
+
+func closeLogFile() {
+	if logFile == nil {
+		return
 	}
+	close(logStop)
+	logFile.Close()
+	logFile, logStop = nil, nil
+}
 
-	logger = &Logger{
-		infoLogger:  log.New(infoFile, "[INFO] ", log.Ldate|log.Ltime|log.Lshortfile),
-		errorLogger: log.New(errorFile, "[ERROR] ", log.Ldate|log.Ltime|log.Lshortfile),
-		debugLogger: log.New(debugFile, "[DEBUG] ", log.Ldate|log.Ltime|log.Lshortfile),
+// rotateDaily 每天零点滚动日志文件
+func rotateDaily(file *lumberjack.Logger, stop chan struct{}) {
+	for {
+		now := time.Now()
+		midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+		timer := time.NewTimer(midnight.Sub(now))
+		select {
+		case <-timer.C:
+			file.Rotate()
+		case <-stop:
+			timer.Stop()
+			return
+		}
 	}
+}
 
-	return nil
+// shortSource 调用位置只保留文件名和行号
+func shortSource(groups []string, attr slog.Attr) slog.Attr {
+	if attr.Key == slog.SourceKey && len(groups) == 0 {
+		if source, ok := attr.Value.Any().(*slog.Source); ok {
+			return slog.String(slog.SourceKey, fmt.Sprintf("%s:%d", filepath.Base(source.File), source.Line))
+		}
+	}
+	return attr
 }
 
 // GetLogger 获取日志记录器实例
@@ -56,84 +133,83 @@ func GetLogger() *Logger {
 	return logger
 }
 
-// LogInfo 记录信息日志
-func LogInfo(message string, args ...interface{}) {
-	if logger != nil {
-		logger.infoLogger.Printf(message, args...)
-	}
-	// 同时输出到控制台
-	log.Printf("[INFO] "+message, args...)
+type logFieldsKey struct{}
+
+// WithLogFields 返回附加了日志字段的上下文，如request_id、user_id、run_id，
+// 通过Logger.Ctx记录的日志会带上这些字段
+func WithLogFields(ctx context.Context, args ...any) context.Context {
+	fields, _ := ctx.Value(logFieldsKey{}).([]any)
+	merged := make([]any, 0, len(fields)+len(args))
+	merged = append(append(merged, fields...), args...)
+	return context.WithValue(ctx, logFieldsKey{}, merged)
 }
 
-// LogError 记录错误日志
-func LogError(message string, args ...interface{}) {
-	if logger != nil {
-		logger.errorLogger.Printf(message, args...)
+// With 返回附加了字段的日志记录器，args为交替的键和值
+func (l *Logger) With(args ...any) *Logger {
+	if l == nil {
+		l = logger
 	}
-	// 同时输出到控制台
-	log.Printf("[ERROR] "+message, args...)
+	attrs := make([]any, 0, len(l.attrs)+len(args))
+	return &Logger{attrs: append(append(attrs, l.attrs...), args...)}
 }
 
-// LogDebug 记录调试日志
-func LogDebug(message string, args ...interface{}) {
-	if logger != nil {
-		logger.debugLogger.Printf(message, args...)
+// Ctx 返回附加了上下文中日志字段和trace ID的日志记录器
+func (l *Logger) Ctx(ctx context.Context) *Logger {
+	if ctx == nil {
+		return l
+	}
+	fields, _ := ctx.Value(logFieldsKey{}).([]any)
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		fields = append(fields[:len(fields):len(fields)], "trace_id", spanContext.TraceID().String())
 	}
-	// 调试模式下输出到控制台
-	if os.Getenv("DEBUG") == "true" {
-		log.Printf("[DEBUG] "+message, args...)
+	if len(fields) == 0 {
+		return l
 	}
+	return l.With(fields...)
 }
 
-// LogRequest 记录HTTP请求日志
-func LogRequest(method, path, ip string, statusCode int, duration time.Duration) {
-	message := fmt.Sprintf("%s %s from %s - Status: %d, Duration: %v", method, path, ip, statusCode, duration)
-	LogInfo(message)
+// Debug 记录调试日志
+func (l *Logger) Debug(msg string, args ...any) {
+	l.log(slog.LevelDebug, msg, args)
 }
 
-// LogDatabaseOperation 记录数据库操作日志
-func LogDatabaseOperation(operation, table string, affected int64, duration time.Duration) {
-	message := fmt.Sprintf("DB %s on %s - Affected: %d, Duration: %v", operation, table, affected, duration)
-	LogDebug(message)
+// Info 记录信息日志
+func (l *Logger) Info(msg string, args ...any) {
+	l.log(slog.LevelInfo, msg, args)
 }
 
-// LogAuth 记录认证相关日志
-func LogAuth(username, action, ip string, success bool) {
-	status := "SUCCESS"
-	if !success {
-		status = "FAILED"
-	}
-	message := fmt.Sprintf("Auth %s for user %s from %s - %s", action, username, ip, status)
-	LogInfo(message)
+// Warn 记录警告日志
+func (l *Logger) Warn(msg string, args ...any) {
+	l.log(slog.LevelWarn, msg, args)
 }
 
-// Logger结构体的方法
-func (l *Logger) LogInfo(category, message string, data map[string]interface{}) {
-	if l != nil && l.infoLogger != nil {
-		logMsg := fmt.Sprintf("[%s] %s", category, message)
-		if data != nil {
-			logMsg += fmt.Sprintf(" - Data: %+v", data)
-		}
-		l.infoLogger.Println(logMsg)
-	}
+// Error 记录错误日志
+func (l *Logger) Error(msg string, args ...any) {
+	l.log(slog.LevelError, msg, args)
 }
 
-func (l *Logger) LogError(category, message string, data map[string]interface{}) {
-	if l != nil && l.errorLogger != nil {
-		logMsg := fmt.Sprintf("[%s] %s", category, message)
-		if data != nil {
-			logMsg += fmt.Sprintf(" - Data: %+v", data)
-		}
-		l.errorLogger.Println(logMsg)
+// Auth 记录认证相关事件，失败时为警告级别
+func (l *Logger) Auth(action, username, ip string, success bool) {
+	level := slog.LevelInfo
+	if !success {
+		level = slog.LevelWarn
 	}
+	l.log(level, "auth "+action, []any{"action", action, "username", username, "ip", ip, "success", success})
 }
 
-func (l *Logger) LogDebug(category, message string, data map[string]interface{}) {
-	if l != nil && l.debugLogger != nil {
-		logMsg := fmt.Sprintf("[%s] %s", category, message)
-		if data != nil {
-			logMsg += fmt.Sprintf(" - Data: %+v", data)
-		}
-		l.debugLogger.Println(logMsg)
+// log 写入一条日志，调用位置为Debug、Info等方法的调用方
+func (l *Logger) log(level slog.Level, msg string, args []any) {
+	handler := root.Load().Handler()
+	if !handler.Enabled(context.Background(), level) {
+		return
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	record := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	if l != nil {
+		record.Add(l.attrs...)
 	}
-}
\ No newline at end of file
+	record.Add(args...)
+	_ = handler.Handle(context.Background(), record)
+}
@@ -0,0 +1,308 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// PasswordHasher 密码哈希算法，编码格式与Django的PASSWORD_HASHERS保持一致，
+// 使Go后端和Django后端可以共用auth_user表
+type PasswordHasher interface {
+	// Algorithm 算法名称，即编码串中第一个$之前的部分
+	Algorithm() string
+	// Encode 计算密码的编码串
+	Encode(password string) (string, error)
+	// Verify 校验密码是否与编码串匹配
+	Verify(password, encoded string) bool
+	// MustUpdate 编码串的参数是否弱于当前配置，需要重新计算
+	MustUpdate(encoded string) bool
+}
+
+// unusablePasswordPrefix 与Django的UNUSABLE_PASSWORD_PREFIX一致，
+// 单点登录和目录服务自动创建的用户使用不可用密码，无法通过本地密码登录
+const unusablePasswordPrefix = "!"
+
+// passwordHashers 已注册的密码哈希算法，第一个为默认算法
+var passwordHashers = []PasswordHasher{
+	&PBKDF2SHA256Hasher{Iterations: 600000},
+	&Argon2Hasher{Time: 2, Memory: 102400, Threads: 8},
+	&BcryptSHA256Hasher{Cost: 12},
+	&BcryptHasher{Cost: 12},
+}
+
+// MakePassword 使用默认算法计算密码编码串
+func MakePassword(password string) (string, error) {
+	return passwordHashers[0].Encode(password)
+}
+
+// MakeUnusablePassword 生成不可用密码，任何密码都无法通过校验
+func MakeUnusablePassword() (string, error) {
+	suffix, err := GenerateRandomString(40)
+	if err != nil {
+		return "", err
+	}
+	return unusablePasswordPrefix + suffix, nil
+}
+
+// IsPasswordUsable 编码串是否为可用密码
+func IsPasswordUsable(encoded string) bool {
+	return encoded != "" && !strings.HasPrefix(encoded, unusablePasswordPrefix)
+}
+
+// CheckPassword 校验密码，自动识别编码串使用的算法
+func CheckPassword(password, encoded string) bool {
+	hasher := IdentifyHasher(encoded)
+	if hasher == nil {
+		return false
+	}
+	return hasher.Verify(password, encoded)
+}
+
+// PasswordMustUpdate 编码串不是默认算法或参数已过时，登录成功后应重新计算
+func PasswordMustUpdate(encoded string) bool {
+	hasher := IdentifyHasher(encoded)
+	if hasher == nil {
+		return false
+	}
+	preferred := passwordHashers[0]
+	return hasher.Algorithm() != preferred.Algorithm() || preferred.MustUpdate(encoded)
+}
+
+// IdentifyHasher 根据编码串识别密码哈希算法，无法识别时返回nil
+func IdentifyHasher(encoded string) PasswordHasher {
+	// 早期Go后端直接保存bcrypt结果，没有算法前缀
+	if strings.HasPrefix(encoded, "$2") {
+		return passwordHashers[len(passwordHashers)-1]
+	}
+
+	algorithm := strings.SplitN(encoded, "$", 2)[0]
+	for _, hasher := range passwordHashers {
+		if hasher.Algorithm() == algorithm {
+			return hasher
+		}
+	}
+	return nil
+}
+
+// PBKDF2SHA256Hasher Django默认的pbkdf2_sha256算法
+// 格式: pbkdf2_sha256$<iterations>$<salt>$<base64 hash>
+type PBKDF2SHA256Hasher struct {
+	Iterations int
+}
+
+// Algorithm 算法名称
+func (h *PBKDF2SHA256Hasher) Algorithm() string {
+	return "pbkdf2_sha256"
+}
+
+// Encode 计算密码的编码串
+func (h *PBKDF2SHA256Hasher) Encode(password string) (string, error) {
+	salt, err := GenerateRandomString(22)
+	if err != nil {
+		return "", err
+	}
+	return h.encode(password, salt, h.Iterations), nil
+}
+
+// Verify 校验密码
+func (h *PBKDF2SHA256Hasher) Verify(password, encoded string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(h.encode(password, parts[2], iterations)), []byte(encoded)) == 1
+}
+
+// MustUpdate 迭代次数与当前配置不一致时需要更新
+func (h *PBKDF2SHA256Hasher) MustUpdate(encoded string) bool {
+	parts := strings.Split(encoded, "$")
+	return len(parts) != 4 || parts[1] != strconv.Itoa(h.Iterations)
+}
+
+func (h *PBKDF2SHA256Hasher) encode(password, salt string, iterations int) string {
+	key := pbkdf2.Key([]byte(password), []byte(salt), iterations, sha256.Size, sha256.New)
+	return fmt.Sprintf("%s$%d$%s$%s", h.Algorithm(), iterations, salt, base64.StdEncoding.EncodeToString(key))
+}
+
+// Argon2Hasher Django的argon2算法（argon2id，兼容校验旧的argon2i）
+// 格式: argon2$argon2id$v=19$m=<memory>,t=<time>,p=<threads>$<salt>$<hash>
+type Argon2Hasher struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+// argon2Params argon2编码串解析结果
+type argon2Params struct {
+	variant string
+	time    uint32
+	memory  uint32
+	threads uint8
+	salt    []byte
+	hash    []byte
+}
+
+// Algorithm 算法名称
+func (h *Argon2Hasher) Algorithm() string {
+	return "argon2"
+}
+
+// Encode 计算密码的编码串
+func (h *Argon2Hasher) Encode(password string) (string, error) {
+	salt, err := GenerateRandomString(22)
+	if err != nil {
+		return "", err
+	}
+
+	hash := argon2.IDKey([]byte(password), []byte(salt), h.Time, h.Memory, h.Threads, 32)
+	return fmt.Sprintf("%s$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", h.Algorithm(), argon2.Version,
+		h.Memory, h.Time, h.Threads,
+		base64.RawStdEncoding.EncodeToString([]byte(salt)),
+		base64.RawStdEncoding.EncodeToString(hash)), nil
+}
+
+// Verify 校验密码
+func (h *Argon2Hasher) Verify(password, encoded string) bool {
+	params, ok := h.decode(encoded)
+	if !ok {
+		return false
+	}
+
+	var hash []byte
+	switch params.variant {
+	case "argon2id":
+		hash = argon2.IDKey([]byte(password), params.salt, params.time, params.memory, params.threads, uint32(len(params.hash)))
+	case "argon2i":
+		hash = argon2.Key([]byte(password), params.salt, params.time, params.memory, params.threads, uint32(len(params.hash)))
+	default:
+		return false
+	}
+	return subtle.ConstantTimeCompare(hash, params.hash) == 1
+}
+
+// MustUpdate 变体或参数与当前配置不一致时需要更新
+func (h *Argon2Hasher) MustUpdate(encoded string) bool {
+	params, ok := h.decode(encoded)
+	if !ok {
+		return true
+	}
+	return params.variant != "argon2id" || params.time != h.Time ||
+		params.memory != h.Memory || params.threads != h.Threads
+}
+
+func (h *Argon2Hasher) decode(encoded string) (*argon2Params, bool) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[2] != fmt.Sprintf("v=%d", argon2.Version) {
+		return nil, false
+	}
+
+	params := &argon2Params{variant: parts[1]}
+	var threads int
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &threads); err != nil {
+		return nil, false
+	}
+	if threads <= 0 || threads > 255 {
+		return nil, false
+	}
+	params.threads = uint8(threads)
+
+	var err error
+	if params.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, false
+	}
+	if params.hash, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(params.hash) == 0 {
+		return nil, false
+	}
+	return params, true
+}
+
+// BcryptSHA256Hasher Django的bcrypt_sha256算法，先对密码做SHA256以突破bcrypt的72字节限制
+// 格式: bcrypt_sha256$<bcrypt hash>
+type BcryptSHA256Hasher struct {
+	Cost int
+}
+
+// Algorithm 算法名称
+func (h *BcryptSHA256Hasher) Algorithm() string {
+	return "bcrypt_sha256"
+}
+
+// Encode 计算密码的编码串
+func (h *BcryptSHA256Hasher) Encode(password string) (string, error) {
+	return encodeBcrypt(h.Algorithm(), h.prepare(password), h.Cost)
+}
+
+// Verify 校验密码
+func (h *BcryptSHA256Hasher) Verify(password, encoded string) bool {
+	return verifyBcrypt(h.Algorithm(), h.prepare(password), encoded)
+}
+
+// MustUpdate cost与当前配置不一致时需要更新
+func (h *BcryptSHA256Hasher) MustUpdate(encoded string) bool {
+	return bcryptMustUpdate(h.Algorithm(), encoded, h.Cost)
+}
+
+func (h *BcryptSHA256Hasher) prepare(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+// BcryptHasher Django的bcrypt算法，同时兼容早期Go后端没有前缀的bcrypt编码串
+// 格式: bcrypt$<bcrypt hash>
+type BcryptHasher struct {
+	Cost int
+}
+
+// Algorithm 算法名称
+func (h *BcryptHasher) Algorithm() string {
+	return "bcrypt"
+}
+
+// Encode 计算密码的编码串
+func (h *BcryptHasher) Encode(password string) (string, error) {
+	return encodeBcrypt(h.Algorithm(), password, h.Cost)
+}
+
+// Verify 校验密码
+func (h *BcryptHasher) Verify(password, encoded string) bool {
+	return verifyBcrypt(h.Algorithm(), password, encoded)
+}
+
+// MustUpdate cost与当前配置不一致时需要更新
+func (h *BcryptHasher) MustUpdate(encoded string) bool {
+	return bcryptMustUpdate(h.Algorithm(), encoded, h.Cost)
+}
+
+func encodeBcrypt(algorithm, password string, cost int) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return "", err
+	}
+	return algorithm + "$" + string(hash), nil
+}
+
+func verifyBcrypt(algorithm, password, encoded string) bool {
+	hash := strings.TrimPrefix(encoded, algorithm+"$")
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+func bcryptMustUpdate(algorithm, encoded string, cost int) bool {
+	if !strings.HasPrefix(encoded, algorithm+"$") {
+		return true
+	}
+	current, err := bcrypt.Cost([]byte(strings.TrimPrefix(encoded, algorithm+"$")))
+	return err != nil || current != cost
+}
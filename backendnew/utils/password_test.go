@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// djangoPBKDF2 Django对密码lètmein、盐seasalt使用pbkdf2_sha256计算的编码串
+const djangoPBKDF2 = "pbkdf2_sha256$600000$seasalt$OAXyhAQ/4ZDA9V5RMExt3C1OwQdUpLZ99vm1McFlLRA="
+
+func TestCheckPasswordDjangoPBKDF2(t *testing.T) {
+	if !CheckPassword("lètmein", djangoPBKDF2) {
+		t.Error("Django pbkdf2_sha256 password was rejected")
+	}
+	if CheckPassword("letmein", djangoPBKDF2) {
+		t.Error("wrong password was accepted")
+	}
+	if PasswordMustUpdate(djangoPBKDF2) {
+		t.Error("password with the current iterations must not be updated")
+	}
+	if !PasswordMustUpdate(strings.Replace(djangoPBKDF2, "600000", "260000", 1)) {
+		t.Error("password with fewer iterations must be updated")
+	}
+}
+
+func TestPasswordHashersRoundTrip(t *testing.T) {
+	hashers := []PasswordHasher{
+		&PBKDF2SHA256Hasher{Iterations: 1000},
+		&Argon2Hasher{Time: 1, Memory: 64, Threads: 1},
+		&BcryptSHA256Hasher{Cost: 4},
+		&BcryptHasher{Cost: 4},
+	}
+	for _, hasher := range hashers {
+		t.Run(hasher.Algorithm(), func(t *testing.T) {
+			encoded, err := hasher.Encode("s3cret")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(encoded, hasher.Algorithm()+"$") {
+				t.Errorf("encoded %q does not start with the algorithm", encoded)
+			}
+			if !hasher.Verify("s3cret", encoded) || hasher.Verify("s3cret!", encoded) {
+				t.Errorf("Verify does not match the encoded password %q", encoded)
+			}
+			if hasher.MustUpdate(encoded) {
+				t.Error("freshly encoded password must not be updated")
+			}
+			if got := IdentifyHasher(encoded); got == nil || got.Algorithm() != hasher.Algorithm() {
+				t.Errorf("IdentifyHasher(%q) = %v", encoded, got)
+			}
+		})
+	}
+}
+
+func TestBcryptSHA256AllowsLongPasswords(t *testing.T) {
+	hasher := &BcryptSHA256Hasher{Cost: 4}
+	long := strings.Repeat("a", 80)
+	encoded, err := hasher.Encode(long)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// bcrypt只使用前72字节，bcrypt_sha256先做摘要，第72字节之后的差异同样有效
+	if hasher.Verify(long[:72]+"b", encoded) {
+		t.Error("password differing after 72 bytes was accepted")
+	}
+}
+
+func TestArgon2VerifiesLegacyArgon2i(t *testing.T) {
+	salt := []byte("somesalt")
+	hash := argon2.Key([]byte("secret"), salt, 1, 64, 1, 32)
+	encoded := fmt.Sprintf("argon2$argon2i$v=%d$m=64,t=1,p=1$%s$%s", argon2.Version,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(hash))
+
+	hasher := &Argon2Hasher{Time: 1, Memory: 64, Threads: 1}
+	if !hasher.Verify("secret", encoded) {
+		t.Error("argon2i password was rejected")
+	}
+	if !hasher.MustUpdate(encoded) {
+		t.Error("argon2i password must be upgraded to argon2id")
+	}
+}
+
+func TestCheckPasswordLegacyBcrypt(t *testing.T) {
+	// 早期Go后端保存的bcrypt结果没有算法前缀
+	encoded, err := (&BcryptHasher{Cost: 4}).Encode("s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := strings.TrimPrefix(encoded, "bcrypt$")
+	if !CheckPassword("s3cret", legacy) {
+		t.Error("unprefixed bcrypt password was rejected")
+	}
+	if !PasswordMustUpdate(legacy) {
+		t.Error("unprefixed bcrypt password must be updated")
+	}
+}
+
+func TestUnusablePassword(t *testing.T) {
+	encoded, err := MakeUnusablePassword()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsPasswordUsable(encoded) || IsPasswordUsable("") {
+		t.Error("unusable password reported as usable")
+	}
+	if CheckPassword("", encoded) || CheckPassword(encoded[1:], encoded) {
+		t.Error("unusable password was accepted")
+	}
+	if !IsPasswordUsable(djangoPBKDF2) {
+		t.Error("Django password reported as unusable")
+	}
+}
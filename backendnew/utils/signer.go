@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 签名令牌相关错误
+var (
+	ErrInvalidSignedToken = errors.New("invalid token")
+	ErrExpiredSignedToken = errors.New("token expired")
+)
+
+// MakeSignedToken 生成用于密码重置、邮箱验证等一次性链接的签名令牌
+//
+// 令牌格式为 base64url(purpose:userID:expires).base64url(hmac)。签名中混入state，
+// 调用方传入会随令牌使用而改变的用户状态（例如密码摘要），状态改变后令牌自动失效，
+// 从而无需在服务端保存令牌即可做到一次性使用。
+func MakeSignedToken(secret, purpose string, userID uint, state string, expire time.Duration) string {
+	payload := fmt.Sprintf("%s:%d:%d", purpose, userID, time.Now().Add(expire).Unix())
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + signToken(secret, purpose, encoded, state)
+}
+
+// ParseSignedToken 解析签名令牌中的用户ID并检查用途和有效期，不校验签名
+func ParseSignedToken(token, purpose string) (uint, error) {
+	encoded, _, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, ErrInvalidSignedToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0, ErrInvalidSignedToken
+	}
+
+	parts := strings.Split(string(payload), ":")
+	if len(parts) != 3 || parts[0] != purpose {
+		return 0, ErrInvalidSignedToken
+	}
+	userID, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return 0, ErrInvalidSignedToken
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return 0, ErrInvalidSignedToken
+	}
+	if time.Now().Unix() > expires {
+		return 0, ErrExpiredSignedToken
+	}
+	return uint(userID), nil
+}
+
+// CheckSignedToken 使用用户当前状态校验令牌签名
+func CheckSignedToken(token, secret, purpose, state string) bool {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	expected := signToken(secret, purpose, encoded, state)
+	return hmac.Equal([]byte(signature), []byte(expected))
+}
+
+// SignValue 对任意字符串签名并附带过期时间，结果可放入Cookie等客户端存储
+func SignValue(secret, purpose, value string, expire time.Duration) string {
+	payload := strconv.FormatInt(time.Now().Add(expire).Unix(), 10) + ":" + value
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + signToken(secret, purpose, encoded, "")
+}
+
+// VerifySignedValue 校验SignValue生成的签名串并取出原始值
+func VerifySignedValue(secret, purpose, signed string) (string, error) {
+	if !CheckSignedToken(signed, secret, purpose, "") {
+		return "", ErrInvalidSignedToken
+	}
+
+	encoded, _, _ := strings.Cut(signed, ".")
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidSignedToken
+	}
+
+	expiresText, value, ok := strings.Cut(string(payload), ":")
+	if !ok {
+		return "", ErrInvalidSignedToken
+	}
+	expires, err := strconv.ParseInt(expiresText, 10, 64)
+	if err != nil {
+		return "", ErrInvalidSignedToken
+	}
+	if time.Now().Unix() > expires {
+		return "", ErrExpiredSignedToken
+	}
+	return value, nil
+}
+
+func signToken(secret, purpose, encoded, state string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose + "|" + encoded + "|" + state))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
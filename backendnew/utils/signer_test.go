@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "signer-test-secret"
+
+func TestSignedTokenRoundTrip(t *testing.T) {
+	token := MakeSignedToken(testSecret, "reset", 42, "state-1", time.Hour)
+
+	userID, err := ParseSignedToken(token, "reset")
+	if err != nil || userID != 42 {
+		t.Fatalf("ParseSignedToken = %d, %v, want 42", userID, err)
+	}
+	if !CheckSignedToken(token, testSecret, "reset", "state-1") {
+		t.Error("valid token was rejected")
+	}
+}
+
+func TestSignedTokenRejectsChanges(t *testing.T) {
+	token := MakeSignedToken(testSecret, "reset", 42, "state-1", time.Hour)
+
+	if CheckSignedToken(token, testSecret, "reset", "state-2") {
+		t.Error("token was accepted after the user state changed")
+	}
+	if CheckSignedToken(token, "other-secret", "reset", "state-1") {
+		t.Error("token was accepted with another secret")
+	}
+	if CheckSignedToken(token, testSecret, "verify", "state-1") {
+		t.Error("token was accepted for another purpose")
+	}
+	if _, err := ParseSignedToken(token, "verify"); !errors.Is(err, ErrInvalidSignedToken) {
+		t.Errorf("ParseSignedToken for another purpose = %v, want ErrInvalidSignedToken", err)
+	}
+
+	// 修改载荷中的用户ID后签名不再匹配
+	forged := MakeSignedToken(testSecret, "reset", 43, "state-1", time.Hour)
+	payload, _, _ := strings.Cut(forged, ".")
+	_, signature, _ := strings.Cut(token, ".")
+	if CheckSignedToken(payload+"."+signature, testSecret, "reset", "state-1") {
+		t.Error("token with a swapped payload was accepted")
+	}
+
+	for _, malformed := range []string{"", "no-dot", "!!!.sig"} {
+		if _, err := ParseSignedToken(malformed, "reset"); !errors.Is(err, ErrInvalidSignedToken) {
+			t.Errorf("ParseSignedToken(%q) = %v, want ErrInvalidSignedToken", malformed, err)
+		}
+	}
+}
+
+func TestSignedTokenExpires(t *testing.T) {
+	token := MakeSignedToken(testSecret, "reset", 42, "", -2*time.Second)
+	if _, err := ParseSignedToken(token, "reset"); !errors.Is(err, ErrExpiredSignedToken) {
+		t.Errorf("ParseSignedToken = %v, want ErrExpiredSignedToken", err)
+	}
+}
+
+func TestSignValue(t *testing.T) {
+	signed := SignValue(testSecret, "oidc", "state:nonce", time.Minute)
+
+	value, err := VerifySignedValue(testSecret, "oidc", signed)
+	if err != nil || value != "state:nonce" {
+		t.Fatalf("VerifySignedValue = %q, %v, want state:nonce", value, err)
+	}
+	if _, err := VerifySignedValue(testSecret, "other", signed); !errors.Is(err, ErrInvalidSignedToken) {
+		t.Errorf("VerifySignedValue for another purpose = %v, want ErrInvalidSignedToken", err)
+	}
+	if _, err := VerifySignedValue(testSecret, "oidc", signed+"x"); !errors.Is(err, ErrInvalidSignedToken) {
+		t.Errorf("VerifySignedValue for a tampered value = %v, want ErrInvalidSignedToken", err)
+	}
+
+	expired := SignValue(testSecret, "oidc", "value", -2*time.Second)
+	if _, err := VerifySignedValue(testSecret, "oidc", expired); !errors.Is(err, ErrExpiredSignedToken) {
+		t.Errorf("VerifySignedValue for an expired value = %v, want ErrExpiredSignedToken", err)
+	}
+}
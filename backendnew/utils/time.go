@@ -169,4 +169,26 @@ func FromTimestamp(timestamp int64) time.Time {
 // FromTimestampMilli 从毫秒时间戳创建时间
 func FromTimestampMilli(timestamp int64) time.Time {
 	return time.Unix(0, timestamp*int64(time.Millisecond))
-}
\ No newline at end of file
+}
+
+// SQLDateBucket 返回按天分桶的SQL表达式，结果格式为 YYYY-MM-DD
+// sqlite中时间以本地时间字符串存储，直接截取日期部分即可
+func SQLDateBucket(dialect, column string) string {
+	switch dialect {
+	case "mysql":
+		return fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m-%%d')", column)
+	case "postgres":
+		return fmt.Sprintf("TO_CHAR(%s, 'YYYY-MM-DD')", column)
+	default:
+		return fmt.Sprintf("SUBSTR(%s, 1, 10)", column)
+	}
+}
+
+// DayBuckets 获取[start, end]之间每天的日期字符串（DateFormat格式）
+func DayBuckets(start, end time.Time) []string {
+	buckets := make([]string, 0, DiffDays(start, end)+1)
+	for day := GetBeginningOfDay(start); !day.After(end); day = AddDays(day, 1) {
+		buckets = append(buckets, FormatTime(day, DateFormat))
+	}
+	return buckets
+}
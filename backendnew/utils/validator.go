@@ -1,8 +1,11 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
 	"regexp"
 	"strings"
+	"unicode"
 )
 
 // IsValidEmail 验证邮箱格式
@@ -26,17 +29,49 @@ func IsValidUsername(username string) bool {
 	return usernameRegex.MatchString(username)
 }
 
-// IsValidPassword 验证密码强度
-func IsValidPassword(password string) bool {
-	if len(password) < 6 {
-		return false
+// PasswordPolicy 密码策略，由security配置项生成，注册、重置和修改密码时校验
+type PasswordPolicy struct {
+	MinLength     int  // 最小长度
+	RequireUpper  bool // 要求包含大写字母
+	RequireLower  bool // 要求包含小写字母
+	RequireDigit  bool // 要求包含数字
+	RequireSymbol bool // 要求包含特殊字符
+}
+
+// Validate 校验密码是否符合策略，返回第一条不满足的规则
+func (p PasswordPolicy) Validate(password, username string) error {
+	if len([]rune(password)) < p.MinLength {
+		return fmt.Errorf("Password must be at least %d characters", p.MinLength)
 	}
-	
-	// 至少包含一个字母和一个数字
-	hasLetter := regexp.MustCompile(`[a-zA-Z]`).MatchString(password)
-	hasNumber := regexp.MustCompile(`[0-9]`).MatchString(password)
-	
-	return hasLetter && hasNumber
+	if username != "" && strings.EqualFold(password, username) {
+		return errors.New("Password must not be the same as the username")
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	switch {
+	case p.RequireUpper && !hasUpper:
+		return errors.New("Password must contain an uppercase letter")
+	case p.RequireLower && !hasLower:
+		return errors.New("Password must contain a lowercase letter")
+	case p.RequireDigit && !hasDigit:
+		return errors.New("Password must contain a digit")
+	case p.RequireSymbol && !hasSymbol:
+		return errors.New("Password must contain a special character")
+	}
+	return nil
 }
 
 // SanitizeString 清理字符串，移除危险字符
@@ -0,0 +1,20 @@
+//go:build embedweb
+
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed all:dist
+var dist embed.FS
+
+// Dist 返回内嵌的前端构建产物，构建前需要把 frontendv3/dist 复制到 web/dist
+func Dist() fs.FS {
+	sub, err := fs.Sub(dist, "dist")
+	if err != nil {
+		return nil
+	}
+	return sub
+}
@@ -0,0 +1,10 @@
+//go:build !embedweb
+
+package web
+
+import "io/fs"
+
+// Dist 未使用 embedweb 构建标签时不内嵌前端，返回nil
+func Dist() fs.FS {
+	return nil
+}
@@ -0,0 +1,5 @@
+// Package web 提供内嵌到二进制中的前端（frontendv3）构建产物
+//
+// 使用 `go build -tags embedweb` 构建时内嵌 web/dist 目录，否则不内嵌，
+// 此时可以通过 WEB_DIR 指定磁盘上的构建产物目录。
+package web